@@ -34,6 +34,8 @@ func CreatePipeline(codec webrtc.TrackType) *Pipeline {
 		pipelineStr += " ! rtpvp9depay ! decodebin ! autovideosink"
 	case webrtc.H264:
 		pipelineStr += " ! rtph264depay ! decodebin ! autovideosink"
+	case webrtc.H265:
+		pipelineStr += " ! rtph265depay ! decodebin ! autovideosink"
 	default:
 		panic("Unhandled codec " + codec.String())
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/pions/webrtc"
 	"github.com/pions/webrtc/examples/gstreamer-receive/gst"
 	"github.com/pions/webrtc/pkg/ice"
+	"github.com/pions/webrtc/pkg/media/jitterbuffer"
 	"github.com/pions/webrtc/pkg/rtp"
 )
 
@@ -35,6 +36,8 @@ func main() {
 				URLs: []string{"stun:stun.l.google.com:19302"},
 			},
 		},
+		// Reorder packets before they reach GStreamer, the delay adapts to the network jitter
+		JitterBuffer: &jitterbuffer.Config{},
 	})
 	if err != nil {
 		panic(err)
@@ -42,9 +45,9 @@ func main() {
 
 	// Set a handler for when a new remote track starts, this handler creates a gstreamer pipeline
 	// for the given codec
-	peerConnection.Ontrack = func(mediaType webrtc.TrackType, packets <-chan *rtp.Packet) {
-		fmt.Printf("Track has started, of type %s \n", mediaType.String())
-		pipeline := gst.CreatePipeline(mediaType)
+	peerConnection.Ontrack = func(track *webrtc.RTCTrack, packets <-chan *rtp.Packet) {
+		fmt.Printf("Track %s has started, of type %s \n", track.ID(), track.Codec().Name())
+		pipeline := gst.CreatePipeline(track.Codec().Type)
 		pipeline.Start()
 		for {
 			p := <-packets
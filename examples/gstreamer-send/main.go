@@ -40,13 +40,13 @@ func main() {
 	}
 
 	// Create a audio track
-	opusIn, err := peerConnection.AddTrack(webrtc.Opus, 48000)
+	opusIn, err := peerConnection.AddTrack(webrtc.NewRTCRtpOpusCodec(webrtc.DefaultPayloadTypeOpus), "audio", "pion")
 	if err != nil {
 		panic(err)
 	}
 
 	// Create a video track
-	vp8In, err := peerConnection.AddTrack(webrtc.VP8, 90000)
+	vp8In, err := peerConnection.AddTrack(webrtc.NewRTCRtpVP8Codec(webrtc.DefaultPayloadTypeVP8), "video", "pion")
 	if err != nil {
 		panic(err)
 	}
@@ -5,14 +5,12 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/pions/webrtc/pkg/rtp"
-	"github.com/pions/webrtc/pkg/rtp/codecs"
+	"github.com/pions/webrtc/pkg/media"
 )
 
 type ivfWriter struct {
-	fd           *os.File
-	count        uint64
-	currentFrame []byte
+	fd    *os.File
+	count uint64
 }
 
 func panicWrite(fd *os.File, data []byte) {
@@ -21,7 +19,7 @@ func panicWrite(fd *os.File, data []byte) {
 	}
 }
 
-func newIVFWriter(fileName string) (*ivfWriter, error) {
+func newIVFWriter(fileName, fourcc string) (*ivfWriter, error) {
 	f, err := os.Create(fileName)
 	if err != nil {
 		return nil, err
@@ -31,7 +29,7 @@ func newIVFWriter(fileName string) (*ivfWriter, error) {
 	copy(header[0:], []byte("DKIF"))                // DKIF
 	binary.LittleEndian.PutUint16(header[4:], 0)    // Version
 	binary.LittleEndian.PutUint16(header[6:], 32)   // Header Size
-	copy(header[8:], []byte("VP80"))                // FOURCC
+	copy(header[8:], []byte(fourcc))                // FOURCC
 	binary.LittleEndian.PutUint16(header[12:], 640) // Version
 	binary.LittleEndian.PutUint16(header[14:], 480) // Header Size
 	binary.LittleEndian.PutUint32(header[16:], 30)  // Framerate numerator
@@ -45,31 +43,18 @@ func newIVFWriter(fileName string) (*ivfWriter, error) {
 	return i, nil
 }
 
-func (i *ivfWriter) addPacket(packet *rtp.Packet) {
-
-	vp8Packet := codecs.VP8Packet{}
-	err := vp8Packet.Unmarshal(packet)
-	if err != nil {
-		panic(err)
-	}
-
-	i.currentFrame = append(i.currentFrame, vp8Packet.Payload[0:]...)
-
-	if !packet.Marker {
-		return
-	} else if len(i.currentFrame) == 0 {
+func (i *ivfWriter) addSample(sample *media.Sample) {
+	if len(sample.Data) == 0 {
 		fmt.Println("skipping")
 		return
 	}
 
 	frameHeader := make([]byte, 12)
-	binary.LittleEndian.PutUint32(frameHeader[0:], uint32(len(i.currentFrame))) // Frame length
-	binary.LittleEndian.PutUint64(frameHeader[4:], i.count)                     // PTS
+	binary.LittleEndian.PutUint32(frameHeader[0:], uint32(len(sample.Data))) // Frame length
+	binary.LittleEndian.PutUint64(frameHeader[4:], i.count)                  // PTS
 
 	i.count++
 
 	panicWrite(i.fd, frameHeader)
-	panicWrite(i.fd, i.currentFrame)
-
-	i.currentFrame = nil
+	panicWrite(i.fd, sample.Data)
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pions/webrtc"
 	"github.com/pions/webrtc/pkg/ice"
+	"github.com/pions/webrtc/pkg/media/samplebuilder"
 	"github.com/pions/webrtc/pkg/rtp"
 )
 
@@ -41,15 +42,32 @@ func main() {
 	// Set a handler for when a new remote track starts, this handler saves buffers to disk as
 	// an ivf file, since we could have multiple video tracks we provide a counter.
 	// In your application this is where you would handle/process video
-	peerConnection.Ontrack = func(mediaType webrtc.TrackType, packets <-chan *rtp.Packet) {
-		if mediaType == webrtc.VP8 {
-			fmt.Println("Got VP8 track, saving to disk as output.ivf")
-			i, err := newIVFWriter("output.ivf")
-			if err != nil {
-				panic(err)
-			}
-			for {
-				i.addPacket(<-packets)
+	peerConnection.Ontrack = func(track *webrtc.RTCTrack, packets <-chan *rtp.Packet) {
+		codec := track.Codec()
+		var fourcc string
+		switch codec.Type {
+		case webrtc.VP8:
+			fourcc = "VP80"
+		case webrtc.VP9:
+			fourcc = "VP90"
+		case webrtc.AV1:
+			fourcc = "AV01"
+		default:
+			return
+		}
+
+		fmt.Printf("Got %s track, saving to disk as output.ivf \n", codec.Name())
+		i, err := newIVFWriter("output.ivf", fourcc)
+		if err != nil {
+			panic(err)
+		}
+
+		// The SampleBuilder reorders the packets and joins them into complete frames
+		builder := samplebuilder.New(256, codec.NewDepacketizer())
+		for {
+			builder.Push(<-packets)
+			for s := builder.Pop(); s != nil; s = builder.Pop() {
+				i.addSample(s)
 			}
 		}
 	}
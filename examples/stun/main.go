@@ -35,8 +35,8 @@ func main() {
 		panic(err)
 	}
 
-	peerConnection.Ontrack = func(mediaType webrtc.TrackType, packets <-chan *rtp.Packet) {
-		fmt.Printf("Got a %s track\n", mediaType)
+	peerConnection.Ontrack = func(track *webrtc.RTCTrack, packets <-chan *rtp.Packet) {
+		fmt.Printf("Got a %s track %s of stream %s\n", track.Codec().Name(), track.ID(), track.StreamID())
 	}
 
 	peerConnection.OnICEConnectionStateChange = func(connectionState ice.ConnectionState) {
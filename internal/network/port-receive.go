@@ -35,18 +35,19 @@ func (p *Port) handleSRTP(b BufferTransportGenerator, certPair *dtls.CertPair, b
 		}
 	}
 
-	packet := &rtp.Packet{}
-	if err := packet.Unmarshal(buffer); err != nil {
-		fmt.Println("Failed to unmarshal RTP packet")
+	// Only the header is in plaintext, the payload and padding can't be parsed until decrypted
+	header := &rtp.Header{}
+	if err := header.Unmarshal(buffer); err != nil {
+		fmt.Println("Failed to unmarshal RTP header")
 		return
 	}
 
-	contextMapKey := p.ListeningAddr.String() + ":" + fmt.Sprint(packet.SSRC)
+	contextMapKey := p.ListeningAddr.String() + ":" + fmt.Sprint(header.SSRC)
 	p.srtpContextsLock.Lock()
 	srtpContext, ok := p.srtpContexts[contextMapKey]
 	if !ok {
 		var err error
-		srtpContext, err = srtp.CreateContext([]byte(certPair.ServerWriteKey[0:16]), []byte(certPair.ServerWriteKey[16:]), certPair.Profile, packet.SSRC)
+		srtpContext, err = srtp.CreateContext([]byte(certPair.ServerWriteKey[0:16]), []byte(certPair.ServerWriteKey[16:]), certPair.Profile, header.SSRC)
 		if err != nil {
 			fmt.Println("Failed to build SRTP context")
 			return
@@ -56,11 +57,18 @@ func (p *Port) handleSRTP(b BufferTransportGenerator, certPair *dtls.CertPair, b
 	}
 	p.srtpContextsLock.Unlock()
 
-	if ok := srtpContext.DecryptPacket(packet); !ok {
+	decrypted, ok := srtpContext.DecryptRTP(buffer, header)
+	if !ok {
 		fmt.Println("Failed to decrypt packet")
 		return
 	}
 
+	packet := &rtp.Packet{}
+	if err := packet.Unmarshal(decrypted); err != nil {
+		fmt.Println("Failed to unmarshal RTP packet")
+		return
+	}
+
 	bufferTransport := p.bufferTransports[packet.SSRC]
 	if bufferTransport == nil {
 		bufferTransport = b(packet.SSRC, packet.PayloadType)
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"github.com/pions/webrtc/internal/srtp"
@@ -9,29 +10,13 @@ import (
 
 // Send sends a *rtp.Packet if we have a connected peer
 func (p *Port) Send(packet *rtp.Packet) {
-	var err error
-
 	for _, authed := range p.authedConnections {
-
-		contextMapKey := authed.peer.String() + ":" + fmt.Sprint(packet.SSRC)
-		p.srtpContextsLock.Lock()
-		srtpContext, ok := p.srtpContexts[contextMapKey]
+		srtpContext, ok := p.getSendContext(authed, packet.SSRC)
 		if !ok {
-			srtpContext, err = srtp.CreateContext([]byte(authed.pair.ClientWriteKey[0:16]), []byte(authed.pair.ClientWriteKey[16:]), authed.pair.Profile, packet.SSRC)
-			if err != nil {
-				fmt.Println("Failed to build SRTP context")
-				continue
-			}
-
-			p.srtpContexts[contextMapKey] = srtpContext
+			continue
 		}
-		p.srtpContextsLock.Unlock()
 
-		if ok := srtpContext.EncryptPacket(packet); ok {
-			raw, err := packet.Marshal()
-			if err != nil {
-				fmt.Printf("Failed to marshal packet: %s \n", err.Error())
-			}
+		if raw, ok := srtpContext.EncryptRTP(packet); ok {
 			if _, err := p.conn.WriteTo(raw, nil, authed.peer); err != nil {
 				fmt.Printf("Failed to send packet: %s \n", err.Error())
 			}
@@ -42,3 +27,47 @@ func (p *Port) Send(packet *rtp.Packet) {
 
 	}
 }
+
+// SendRTCP sends a marshaled RTCP compound packet if we have a connected peer, it is encrypted with the
+// context of the SSRC of its first packet
+func (p *Port) SendRTCP(packet []byte) {
+	if len(packet) < 8 {
+		fmt.Println("RTCP packet is too short to contain an SSRC")
+		return
+	}
+	ssrc := binary.BigEndian.Uint32(packet[4:])
+
+	for _, authed := range p.authedConnections {
+		srtpContext, ok := p.getSendContext(authed, ssrc)
+		if !ok {
+			continue
+		}
+
+		if raw, ok := srtpContext.EncryptRTCP(packet); ok {
+			if _, err := p.conn.WriteTo(raw, nil, authed.peer); err != nil {
+				fmt.Printf("Failed to send RTCP packet: %s \n", err.Error())
+			}
+		} else {
+			fmt.Println("Failed to encrypt RTCP packet")
+		}
+	}
+}
+
+func (p *Port) getSendContext(authed *authedConnection, ssrc uint32) (*srtp.Context, bool) {
+	p.srtpContextsLock.Lock()
+	defer p.srtpContextsLock.Unlock()
+
+	contextMapKey := authed.peer.String() + ":" + fmt.Sprint(ssrc)
+	srtpContext, ok := p.srtpContexts[contextMapKey]
+	if !ok {
+		var err error
+		srtpContext, err = srtp.CreateContext([]byte(authed.pair.ClientWriteKey[0:16]), []byte(authed.pair.ClientWriteKey[16:]), authed.pair.Profile, ssrc)
+		if err != nil {
+			fmt.Println("Failed to build SRTP context")
+			return nil, false
+		}
+
+		p.srtpContexts[contextMapKey] = srtpContext
+	}
+	return srtpContext, true
+}
@@ -16,8 +16,19 @@ const (
 	labelSalt              = 0x02
 	labelAuthenticationTag = 0x01
 
-	keyLen  = 16
-	saltLen = 14
+	labelSRTCPEncryption        = 0x03
+	labelSRTCPAuthenticationTag = 0x04
+	labelSRTCPSalt              = 0x05
+
+	keyLen      = 16
+	saltLen     = 14
+	authKeyLen  = 20
+	authTagSize = 10
+
+	// srtcpHeaderSize is the part of an RTCP packet that isn't encrypted, srtcpIndexSize the E flag and SRTCP index
+	srtcpHeaderSize = 8
+	srtcpIndexSize  = 4
+	maxSRTCPIndex   = 0x7FFFFFFF
 
 	maxROCDisorder    = 100
 	maxSequenceNumber = 65535
@@ -40,6 +51,13 @@ type Context struct {
 	sessionAuthTag []byte
 
 	block cipher.Block
+
+	// SRTCP has its own session keys, and counts its packets with an index
+	// https://tools.ietf.org/html/rfc3711#section-3.4
+	srtcpIndex          uint32
+	srtcpSessionSalt    []byte
+	srtcpSessionAuthTag []byte
+	srtcpBlock          cipher.Block
 }
 
 /*
@@ -79,81 +97,64 @@ func CreateContext(masterKey, masterSalt []byte, profile string, ssrc uint32) (c
 		return nil, err
 	}
 
-	return c, nil
-}
-
-func (c *Context) generateSessionKey() ([]byte, error) {
-	// https://tools.ietf.org/html/rfc3711#appendix-B.3
-	// The input block for AES-CM is generated by exclusive-oring the master salt with the
-	// concatenation of the encryption key label 0x00 with (index DIV kdr),
-	// - index is 'rollover count' and DIV is 'divided by'
-	sessionKey := make([]byte, len(c.masterSalt))
-	copy(sessionKey, c.masterSalt)
-
-	labelAndIndexOverKdr := []byte{labelEncryption, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
-	for i, j := len(labelAndIndexOverKdr)-1, len(sessionKey)-1; i >= 0; i, j = i-1, j-1 {
-		sessionKey[j] = sessionKey[j] ^ labelAndIndexOverKdr[i]
-	}
-
-	// then padding on the right with two null octets (which implements the multiply-by-2^16 operation, see Section 4.3.3).
-	sessionKey = append(sessionKey, []byte{0x00, 0x00}...)
-
-	//The resulting value is then AES-CM- encrypted using the master key to get the cipher key.
-	block, err := aes.NewCipher(c.masterKey)
+	srtcpSessionKey, err := c.deriveSessionKey(labelSRTCPEncryption, keyLen)
 	if err != nil {
 		return nil, err
 	}
-
-	block.Encrypt(sessionKey, sessionKey)
-	return sessionKey, nil
-}
-
-func (c *Context) generateSessionSalt() ([]byte, error) {
-	// https://tools.ietf.org/html/rfc3711#appendix-B.3
-	// The input block for AES-CM is generated by exclusive-oring the master salt with
-	// the concatenation of the encryption salt label
-	sessionSalt := make([]byte, len(c.masterSalt))
-	copy(sessionSalt, c.masterSalt)
-
-	labelAndIndexOverKdr := []byte{labelSalt, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
-	for i, j := len(labelAndIndexOverKdr)-1, len(sessionSalt)-1; i >= 0; i, j = i-1, j-1 {
-		sessionSalt[j] = byte(sessionSalt[j]) ^ byte(labelAndIndexOverKdr[i])
+	if c.srtcpSessionSalt, err = c.deriveSessionKey(labelSRTCPSalt, saltLen); err != nil {
+		return nil, err
 	}
-
-	// That value is padded and encrypted as above.
-	sessionSalt = append(sessionSalt, []byte{0x00, 0x00}...)
-	block, err := aes.NewCipher(c.masterKey)
-	if err != nil {
+	if c.srtcpSessionAuthTag, err = c.deriveSessionKey(labelSRTCPAuthenticationTag, authKeyLen); err != nil {
 		return nil, err
 	}
+	if c.srtcpBlock, err = aes.NewCipher(srtcpSessionKey); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
 
-	block.Encrypt(sessionSalt, sessionSalt)
-	return sessionSalt[0:saltLen], nil
+func (c *Context) generateSessionKey() ([]byte, error) {
+	return c.deriveSessionKey(labelEncryption, keyLen)
 }
+
+func (c *Context) generateSessionSalt() ([]byte, error) {
+	return c.deriveSessionKey(labelSalt, saltLen)
+}
+
 func (c *Context) generateSessionAuthTag() ([]byte, error) {
-	// https://tools.ietf.org/html/rfc3711#appendix-B.3
-	// We now show how the auth key is generated.  The input block for AES-
-	// CM is generated as above, but using the authentication key label.
-	sessionAuthTag := make([]byte, len(c.masterSalt))
-	copy(sessionAuthTag, c.masterSalt)
-
-	labelAndIndexOverKdr := []byte{labelAuthenticationTag, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
-	for i, j := len(labelAndIndexOverKdr)-1, len(sessionAuthTag)-1; i >= 0; i, j = i-1, j-1 {
-		sessionAuthTag[j] = sessionAuthTag[j] ^ labelAndIndexOverKdr[i]
+	return c.deriveSessionKey(labelAuthenticationTag, authKeyLen)
+}
+
+// deriveSessionKey derives length octets of the session key with the given label
+// https://tools.ietf.org/html/rfc3711#appendix-B.3
+func (c *Context) deriveSessionKey(label byte, length int) ([]byte, error) {
+	// The input block for AES-CM is generated by exclusive-oring the master salt with the
+	// concatenation of the label with (index DIV kdr),
+	// - index is 'rollover count' and DIV is 'divided by', the key derivation rate is 0 so it is always 0
+	input := make([]byte, len(c.masterSalt))
+	copy(input, c.masterSalt)
+
+	labelAndIndexOverKdr := []byte{label, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	for i, j := len(labelAndIndexOverKdr)-1, len(input)-1; i >= 0; i, j = i-1, j-1 {
+		input[j] = input[j] ^ labelAndIndexOverKdr[i]
 	}
 
-	// That value is padded and encrypted as above.
-	// - We need to do multiple runs at key size (20) is larger then source
-	firstRun := append(sessionAuthTag, []byte{0x00, 0x00}...)
-	secondRun := append(sessionAuthTag, []byte{0x00, 0x01}...)
+	//The resulting value is then AES-CM- encrypted using the master key to get the cipher key.
 	block, err := aes.NewCipher(c.masterKey)
 	if err != nil {
 		return nil, err
 	}
 
-	block.Encrypt(firstRun, firstRun)
-	block.Encrypt(secondRun, secondRun)
-	return append(firstRun, secondRun[:4]...), nil
+	// then padding on the right with two null octets (which implements the multiply-by-2^16 operation, see Section 4.3.3).
+	// - Keys longer than a block, like the auth key, take multiple runs with a counter in the padding
+	var key []byte
+	for i := 0; len(key) < length; i++ {
+		run := append(append([]byte{}, input...), byte(i>>8), byte(i))
+		block.Encrypt(run, run)
+		key = append(key, run...)
+	}
+	return key[:length], nil
 }
 
 // Generate IV https://tools.ietf.org/html/rfc3711#section-4.1.1
@@ -201,24 +202,27 @@ func (c *Context) updateRolloverCount(sequenceNumber uint16) {
 	c.lastSequenceNumber = sequenceNumber
 }
 
-// DecryptPacket decrypts a RTP packet with an encrypted payload
-func (c *Context) DecryptPacket(packet *rtp.Packet) bool {
-	c.updateRolloverCount(packet.SequenceNumber)
+// DecryptRTP decrypts a SRTP packet, header must be the unmarshaled header of encrypted.
+// The returned slice is a plaintext RTP packet with the authentication tag removed
+func (c *Context) DecryptRTP(encrypted []byte, header *rtp.Header) ([]byte, bool) {
+	if len(encrypted) < header.PayloadOffset+authTagSize {
+		return nil, false
+	}
 
-	stream := cipher.NewCTR(c.block, c.generateCounter(packet.SequenceNumber))
-	stream.XORKeyStream(packet.Payload, packet.Payload)
+	c.updateRolloverCount(header.SequenceNumber)
 
 	// TODO remove tags, need to assert value
-	packet.Payload = packet.Payload[:len(packet.Payload)-10]
+	decrypted := make([]byte, len(encrypted)-authTagSize)
+	copy(decrypted, encrypted)
 
-	// Replace payload with decrypted
-	packet.Raw = packet.Raw[0:packet.PayloadOffset]
-	packet.Raw = append(packet.Raw, packet.Payload...)
+	// The payload, and the RTP padding that follows it, are encrypted
+	stream := cipher.NewCTR(c.block, c.generateCounter(header.SequenceNumber))
+	stream.XORKeyStream(decrypted[header.PayloadOffset:], decrypted[header.PayloadOffset:])
 
-	return true
+	return decrypted, true
 }
 
-func (c *Context) addAuthTag(packet *rtp.Packet) error {
+func (c *Context) generateAuthTag(buf []byte) ([]byte, error) {
 	// https://tools.ietf.org/html/rfc3711#section-4.2
 	// In the case of SRTP, M SHALL consist of the Authenticated
 	// Portion of the packet (as specified in Figure 1) concatenated with
@@ -235,32 +239,118 @@ func (c *Context) addAuthTag(packet *rtp.Packet) error {
 	// - n_tag is the bit-length of the output authentication tag
 
 	mac := hmac.New(sha1.New, c.sessionAuthTag)
-	fullPkt, err := packet.Marshal()
-	if err != nil {
-		panic(err)
+	if _, err := mac.Write(buf); err != nil {
+		return nil, err
 	}
 
-	fullPkt = append(fullPkt, make([]byte, 4)...)
-	binary.BigEndian.PutUint32(fullPkt[len(fullPkt)-4:], c.rolloverCounter)
-
-	if _, err := mac.Write(fullPkt); err != nil {
-		panic(err)
+	roc := make([]byte, 4)
+	binary.BigEndian.PutUint32(roc, c.rolloverCounter)
+	if _, err := mac.Write(roc); err != nil {
+		return nil, err
 	}
 
-	packet.Payload = append(packet.Payload, mac.Sum(nil)[0:10]...)
-	return nil
+	return mac.Sum(nil)[0:authTagSize], nil
 }
 
-// EncryptPacket Encrypts a SRTP packet in place
-func (c *Context) EncryptPacket(packet *rtp.Packet) bool {
+// EncryptRTP marshals and encrypts a RTP packet, and returns the SRTP packet.
+// The packet itself is not modified, so it can be encrypted for multiple contexts
+func (c *Context) EncryptRTP(packet *rtp.Packet) ([]byte, bool) {
 	c.updateRolloverCount(packet.SequenceNumber)
 
+	raw := make([]byte, packet.MarshalSize())
+	n, err := packet.MarshalTo(raw)
+	if err != nil {
+		return nil, false
+	}
+	raw = raw[:n]
+
+	// The payload, and the RTP padding that follows it, are encrypted
+	payloadOffset := packet.Header.MarshalSize()
 	stream := cipher.NewCTR(c.block, c.generateCounter(packet.SequenceNumber))
-	stream.XORKeyStream(packet.Payload, packet.Payload)
+	stream.XORKeyStream(raw[payloadOffset:], raw[payloadOffset:])
+
+	authTag, err := c.generateAuthTag(raw)
+	if err != nil {
+		return nil, false
+	}
+
+	return append(raw, authTag...), true
+}
+
+// generateSRTCPCounter generates the IV of an SRTCP packet, like generateCounter with the SRTCP index as packet index
+// https://tools.ietf.org/html/rfc3711#section-4.1.1
+func (c *Context) generateSRTCPCounter(ssrc, index uint32) []byte {
+	counter := make([]byte, 16)
+
+	binary.BigEndian.PutUint32(counter[4:], ssrc)
+	binary.BigEndian.PutUint32(counter[10:], index)
 
-	if err := c.addAuthTag(packet); err != nil {
-		return false
+	for i := range c.srtcpSessionSalt {
+		counter[i] = counter[i] ^ c.srtcpSessionSalt[i]
 	}
 
-	return true
+	return counter
+}
+
+func (c *Context) generateSRTCPAuthTag(buf []byte) ([]byte, error) {
+	// https://tools.ietf.org/html/rfc3711#section-4.2
+	// - For SRTCP M is the authenticated portion, which already ends with the E flag and SRTCP index
+	mac := hmac.New(sha1.New, c.srtcpSessionAuthTag)
+	if _, err := mac.Write(buf); err != nil {
+		return nil, err
+	}
+	return mac.Sum(nil)[0:authTagSize], nil
+}
+
+// EncryptRTCP encrypts a marshaled RTCP compound packet, and returns the SRTCP packet. Every packet is
+// sent with the next SRTCP index
+// https://tools.ietf.org/html/rfc3711#section-3.4
+func (c *Context) EncryptRTCP(decrypted []byte) ([]byte, bool) {
+	if len(decrypted) < srtcpHeaderSize {
+		return nil, false
+	}
+
+	index := c.srtcpIndex
+	c.srtcpIndex = (c.srtcpIndex + 1) & maxSRTCPIndex
+
+	// Everything after the SSRC of the first packet is encrypted
+	encrypted := make([]byte, len(decrypted), len(decrypted)+srtcpIndexSize+authTagSize)
+	copy(encrypted, decrypted)
+	ssrc := binary.BigEndian.Uint32(decrypted[4:])
+	stream := cipher.NewCTR(c.srtcpBlock, c.generateSRTCPCounter(ssrc, index))
+	stream.XORKeyStream(encrypted[srtcpHeaderSize:], encrypted[srtcpHeaderSize:])
+
+	// The E flag is the top bit of the index, it is set as the packet is encrypted
+	eAndIndex := make([]byte, srtcpIndexSize)
+	binary.BigEndian.PutUint32(eAndIndex, index|1<<31)
+	encrypted = append(encrypted, eAndIndex...)
+
+	authTag, err := c.generateSRTCPAuthTag(encrypted)
+	if err != nil {
+		return nil, false
+	}
+	return append(encrypted, authTag...), true
+}
+
+// DecryptRTCP authenticates and decrypts an SRTCP packet, and returns the RTCP compound packet
+func (c *Context) DecryptRTCP(encrypted []byte) ([]byte, bool) {
+	if len(encrypted) < srtcpHeaderSize+srtcpIndexSize+authTagSize {
+		return nil, false
+	}
+
+	tagOffset := len(encrypted) - authTagSize
+	authTag, err := c.generateSRTCPAuthTag(encrypted[:tagOffset])
+	if err != nil || !hmac.Equal(authTag, encrypted[tagOffset:]) {
+		return nil, false
+	}
+
+	indexOffset := tagOffset - srtcpIndexSize
+	eAndIndex := binary.BigEndian.Uint32(encrypted[indexOffset:])
+	decrypted := append([]byte{}, encrypted[:indexOffset]...)
+	if eAndIndex>>31 == 1 {
+		ssrc := binary.BigEndian.Uint32(decrypted[4:])
+		stream := cipher.NewCTR(c.srtcpBlock, c.generateSRTCPCounter(ssrc, eAndIndex&maxSRTCPIndex))
+		stream.XORKeyStream(decrypted[srtcpHeaderSize:], decrypted[srtcpHeaderSize:])
+	}
+	return decrypted, true
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/pions/webrtc/pkg/rtp"
 	"github.com/pkg/errors"
 )
 
@@ -112,3 +113,89 @@ func TestRolloverCount(t *testing.T) {
 		t.Errorf("rolloverCounter was improperly updated for non-significant packets")
 	}
 }
+
+func TestEncryptDecryptRTP(t *testing.T) {
+	masterKey := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
+	masterSalt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+
+	encryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo, 5000)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+	decryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo, 5000)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+
+	packet := &rtp.Packet{
+		Header:      rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: 5000, SSRC: 5000},
+		Payload:     []byte{0x01, 0x02, 0x03, 0x04},
+		PaddingSize: 4,
+	}
+
+	encrypted, ok := encryptContext.EncryptRTP(packet)
+	if !ok {
+		t.Fatal("EncryptRTP failed")
+	}
+
+	header := &rtp.Header{}
+	if err := header.Unmarshal(encrypted); err != nil {
+		t.Fatal(errors.Wrap(err, "Unmarshal of SRTP header failed"))
+	}
+
+	decrypted, ok := decryptContext.DecryptRTP(encrypted, header)
+	if !ok {
+		t.Fatal("DecryptRTP failed")
+	}
+
+	decryptedPacket := &rtp.Packet{}
+	if err := decryptedPacket.Unmarshal(decrypted); err != nil {
+		t.Fatal(errors.Wrap(err, "Unmarshal of decrypted packet failed"))
+	} else if !bytes.Equal(decryptedPacket.Payload, packet.Payload) || decryptedPacket.PaddingSize != packet.PaddingSize {
+		t.Errorf("Decrypted packet % 02x does not match % 02x", decryptedPacket.Payload, packet.Payload)
+	}
+}
+
+func TestEncryptDecryptRTCP(t *testing.T) {
+	masterKey := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
+	masterSalt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+
+	encryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo, defaultSsrc)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+	decryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo, defaultSsrc)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+
+	// A Goodbye packet with a reason
+	decrypted := []byte{0x81, 0xcb, 0x00, 0x02, 0x90, 0x2f, 0x9e, 0x2e, 0x02, 'B', 'Y', 0x00}
+	for i := uint32(0); i < 2; i++ {
+		encrypted, ok := encryptContext.EncryptRTCP(decrypted)
+		if !ok {
+			t.Fatal("EncryptRTCP failed")
+		}
+		if len(encrypted) != len(decrypted)+srtcpIndexSize+authTagSize || !bytes.Equal(encrypted[:srtcpHeaderSize], decrypted[:srtcpHeaderSize]) {
+			t.Errorf("SRTCP packet should keep the header and SSRC in the clear, followed by the index and tag: % 02x", encrypted)
+		}
+		if bytes.Equal(encrypted[srtcpHeaderSize:len(decrypted)], decrypted[srtcpHeaderSize:]) {
+			t.Errorf("SRTCP packet was not encrypted")
+		}
+		if index := encrypted[len(decrypted) : len(decrypted)+srtcpIndexSize]; !bytes.Equal(index, []byte{0x80, 0x00, 0x00, byte(i)}) {
+			t.Errorf("expected the E flag and index %d, got % 02x", i, index)
+		}
+
+		actual, ok := decryptContext.DecryptRTCP(encrypted)
+		if !ok {
+			t.Fatal("DecryptRTCP failed")
+		} else if !bytes.Equal(actual, decrypted) {
+			t.Errorf("SRTCP packet did not round trip: % 02x", actual)
+		}
+
+		encrypted[srtcpHeaderSize] ^= 0xFF
+		if _, ok := decryptContext.DecryptRTCP(encrypted); ok {
+			t.Errorf("DecryptRTCP accepted a packet that failed authentication")
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package webrtc
+
+import (
+	"strings"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/rtp/codecs"
+	"github.com/pions/webrtc/pkg/sdp"
+)
+
+// RTCRtcpFeedback is a RTCP feedback mechanism a codec supports, like Type "nack" with Parameter "pli"
+// https://www.w3.org/TR/webrtc/#dom-rtcrtcpfeedback
+type RTCRtcpFeedback struct {
+	Type      string
+	Parameter string
+}
+
+func (f RTCRtcpFeedback) String() string {
+	if f.Parameter == "" {
+		return f.Type
+	}
+	return f.Type + " " + f.Parameter
+}
+
+// RTCRtpCodec is a codec registered in a MediaEngine
+// https://www.w3.org/TR/webrtc/#dom-rtcrtpcodecparameters
+type RTCRtpCodec struct {
+	// Type identifies the codecs pion-WebRTC has a payloader for, it is 0 for other codecs
+	Type TrackType
+
+	// MimeType is the kind and the encoding name of the codec, like "audio/opus", "video/VP8" or
+	// "application/x-telemetry". The kind is the media type of the m= line the codec is negotiated in
+	MimeType  string
+	ClockRate uint32
+
+	// Channels is the number of audio channels, 0 for video codecs
+	Channels     uint16
+	SDPFmtpLine  string
+	RTCPFeedback []RTCRtcpFeedback
+
+	// PayloadType is used when no remote description was negotiated, once it was the payload type
+	// chosen by the remote is used
+	PayloadType uint8
+
+	// NewPayloader creates the payloader of a track sending the codec, every track has its own
+	NewPayloader func() rtp.Payloader
+
+	// NewDepacketizer creates a depacketizer for the payloads of the codec, it is optional and
+	// can be used by Ontrack to reassemble the packets it receives
+	NewDepacketizer func() rtp.Depacketizer
+}
+
+// Name returns the encoding name of the codec, as it appears in the SDP
+func (c *RTCRtpCodec) Name() string {
+	return c.MimeType[strings.Index(c.MimeType, "/")+1:]
+}
+
+// Kind returns the media type of the codec, like "audio", "video" or "application"
+func (c *RTCRtpCodec) Kind() string {
+	if i := strings.Index(c.MimeType, "/"); i != -1 {
+		return c.MimeType[:i]
+	}
+	return ""
+}
+
+func (c *RTCRtpCodec) isAudio() bool {
+	return c.Kind() == "audio"
+}
+
+// matches returns if the codec has the given encoding name, clock rate and channels. Audio codecs omitting
+// their channels have a single one
+func (c *RTCRtpCodec) matches(name string, clockRate uint32, channels uint16) bool {
+	countChannels := func(channels uint16) uint16 {
+		if channels == 0 && c.isAudio() {
+			return 1
+		}
+		return channels
+	}
+	return strings.EqualFold(c.Name(), name) && c.ClockRate == clockRate && countChannels(c.Channels) == countChannels(channels)
+}
+
+func (c *RTCRtpCodec) sdpCodec() *sdp.Codec {
+	codec := &sdp.Codec{
+		PayloadType: c.PayloadType,
+		Name:        c.Name(),
+		ClockRate:   c.ClockRate,
+		Channels:    c.Channels,
+		Fmtp:        c.SDPFmtpLine,
+	}
+	for _, feedback := range c.RTCPFeedback {
+		codec.RTCPFeedback = append(codec.RTCPFeedback, feedback.String())
+	}
+	return codec
+}
+
+// negotiate returns a copy of the codec using the payload type of the remote codec, if both can be used together.
+// H264 must use the same packetization-mode and profile, H265 the same profile and tier, their fmtp is answered
+// with the one of the remote. The RTCP feedback is the one both support
+func (c *RTCRtpCodec) negotiate(remote *sdp.Codec) (*RTCRtpCodec, bool) {
+	if !c.matches(remote.Name, remote.ClockRate, remote.Channels) {
+		return nil, false
+	}
+
+	negotiated := *c
+	negotiated.PayloadType = remote.PayloadType
+	negotiated.RTCPFeedback = nil
+
+	if name := strings.ToUpper(c.Name()); name == "H264" || name == "H265" {
+		if !c.fmtpMatches(remote.Fmtp) {
+			return nil, false
+		}
+		negotiated.SDPFmtpLine = remote.Fmtp
+	}
+
+	for _, feedback := range c.RTCPFeedback {
+		for _, offered := range remote.RTCPFeedback {
+			if strings.EqualFold(feedback.String(), offered) {
+				negotiated.RTCPFeedback = append(negotiated.RTCPFeedback, feedback)
+				break
+			}
+		}
+	}
+	return &negotiated, true
+}
+
+// fmtpMatches returns if the fmtp of the remote has the parameters the payloads of the codec depend on, parameters
+// that are omitted have their default value
+func (c *RTCRtpCodec) fmtpMatches(remoteFmtp string) bool {
+	local, offered := sdp.ParseFmtp(c.SDPFmtpLine), sdp.ParseFmtp(remoteFmtp)
+	parameter := func(fmtp map[string]string, name, defaultValue string) string {
+		if value, ok := fmtp[name]; ok {
+			return strings.ToLower(value)
+		}
+		return defaultValue
+	}
+
+	switch strings.ToUpper(c.Name()) {
+	case "H264":
+		// https://tools.ietf.org/html/rfc6184#section-8.2.2
+		profile := func(fmtp map[string]string) string {
+			if profileLevelID := fmtp["profile-level-id"]; len(profileLevelID) == 6 {
+				return strings.ToLower(profileLevelID[:2])
+			}
+			return "42"
+		}
+		return parameter(local, "packetization-mode", "0") == parameter(offered, "packetization-mode", "0") && profile(local) == profile(offered)
+	case "H265":
+		// https://tools.ietf.org/html/rfc7798#section-7.2.2
+		return parameter(local, "profile-id", "1") == parameter(offered, "profile-id", "1") &&
+			parameter(local, "tier-flag", "0") == parameter(offered, "tier-flag", "0")
+	}
+	return true
+}
+
+// Payload types of the codecs registered by RegisterDefaultCodecs
+const (
+	DefaultPayloadTypeOpus = 111
+	DefaultPayloadTypeG722 = 9
+	DefaultPayloadTypePCMU = 0
+	DefaultPayloadTypePCMA = 8
+	DefaultPayloadTypeVP8  = 96
+	DefaultPayloadTypeVP9  = 98
+	DefaultPayloadTypeH264 = 100
+	DefaultPayloadTypeAV1  = 102
+	DefaultPayloadTypeH265 = 104
+)
+
+// MediaEngine is the set of codecs a RTCPeerConnection can send and receive
+type MediaEngine struct {
+	codecs []*RTCRtpCodec
+}
+
+// RegisterCodec adds a codec to the MediaEngine. When no remote description was negotiated codecs are
+// used in the order they were registered
+func (m *MediaEngine) RegisterCodec(codec *RTCRtpCodec) {
+	m.codecs = append(m.codecs, codec)
+}
+
+// RegisterDefaultCodecs registers every codec pion-WebRTC has a payloader for
+func (m *MediaEngine) RegisterDefaultCodecs() {
+	m.RegisterCodec(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus))
+	m.RegisterCodec(NewRTCRtpG722Codec(DefaultPayloadTypeG722))
+	m.RegisterCodec(NewRTCRtpPCMUCodec(DefaultPayloadTypePCMU))
+	m.RegisterCodec(NewRTCRtpPCMACodec(DefaultPayloadTypePCMA))
+	m.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8))
+	m.RegisterCodec(NewRTCRtpVP9Codec(DefaultPayloadTypeVP9))
+	m.RegisterCodec(NewRTCRtpH264Codec(DefaultPayloadTypeH264))
+	m.RegisterCodec(NewRTCRtpAV1Codec(DefaultPayloadTypeAV1))
+	m.RegisterCodec(NewRTCRtpH265Codec(DefaultPayloadTypeH265))
+}
+
+// negotiate returns the registered codecs the remote offered, with its payload types and in its order of preference.
+// Every codec is used in the order it was registered if there is no remote description
+func (m *MediaEngine) negotiate(remoteDescription *sdp.SessionDescription) (negotiated []*RTCRtpCodec) {
+	if remoteDescription == nil {
+		return append(negotiated, m.codecs...)
+	}
+
+	for _, media := range remoteDescription.MediaDescriptions {
+		for _, codec := range m.negotiateMedia(media) {
+			// Bundled MediaDescriptions of the same kind usually share their payload types
+			duplicate := false
+			for _, n := range negotiated {
+				duplicate = duplicate || (n.PayloadType == codec.PayloadType && n.Kind() == codec.Kind())
+			}
+			if !duplicate {
+				negotiated = append(negotiated, codec)
+			}
+		}
+	}
+	return negotiated
+}
+
+// negotiateMedia returns the registered codecs offered in a MediaDescription, in its order of preference.
+// A MediaDescription offered with port 0 is disabled, no codecs are negotiated for it
+func (m *MediaEngine) negotiateMedia(media *sdp.MediaDescription) (negotiated []*RTCRtpCodec) {
+	if media.MediaName.Port == 0 {
+		return nil
+	}
+
+	for _, remote := range media.Codecs() {
+		for _, codec := range m.codecs {
+			if codec.Kind() != media.MediaName.Media {
+				continue
+			}
+			if c, ok := codec.negotiate(remote); ok {
+				negotiated = append(negotiated, c)
+				break
+			}
+		}
+	}
+	return negotiated
+}
+
+// getCodec returns the registered codec with the name, clock rate and channels of codec, nil if there is none
+func (m *MediaEngine) getCodec(codec *RTCRtpCodec) *RTCRtpCodec {
+	for _, registered := range m.codecs {
+		if registered.Kind() == codec.Kind() && registered.matches(codec.Name(), codec.ClockRate, codec.Channels) {
+			return registered
+		}
+	}
+	return nil
+}
+
+func (m *MediaEngine) hasKind(kind string) bool {
+	for _, codec := range m.codecs {
+		if codec.Kind() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRTCRtpOpusCodec creates an Opus codec with the given payload type
+func NewRTCRtpOpusCodec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            Opus,
+		MimeType:        "audio/opus",
+		ClockRate:       48000,
+		Channels:        2,
+		SDPFmtpLine:     "minptime=10;useinbandfec=1",
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.OpusPayloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.OpusPacket{} },
+	}
+}
+
+// NewRTCRtpG722Codec creates a G722 codec with the given payload type, its RTP clock is 8000Hz even though it samples at 16000Hz
+func NewRTCRtpG722Codec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            G722,
+		MimeType:        "audio/G722",
+		ClockRate:       8000,
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.G722Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.G722Packet{} },
+	}
+}
+
+// NewRTCRtpPCMUCodec creates a PCMU codec with the given payload type
+func NewRTCRtpPCMUCodec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            PCMU,
+		MimeType:        "audio/PCMU",
+		ClockRate:       8000,
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.G711Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.G711Packet{} },
+	}
+}
+
+// NewRTCRtpPCMACodec creates a PCMA codec with the given payload type
+func NewRTCRtpPCMACodec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            PCMA,
+		MimeType:        "audio/PCMA",
+		ClockRate:       8000,
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.G711Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.G711Packet{} },
+	}
+}
+
+// NewRTCRtpVP8Codec creates a VP8 codec with the given payload type
+func NewRTCRtpVP8Codec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            VP8,
+		MimeType:        "video/VP8",
+		ClockRate:       90000,
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.VP8Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.VP8Packet{} },
+	}
+}
+
+// NewRTCRtpVP9Codec creates a VP9 codec with the given payload type
+func NewRTCRtpVP9Codec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            VP9,
+		MimeType:        "video/VP9",
+		ClockRate:       90000,
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.VP9Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.VP9Packet{} },
+	}
+}
+
+// NewRTCRtpH264Codec creates a H264 codec with the given payload type, Constrained Baseline level 3.1 in
+// packetization-mode=1 since the payloader sends STAP-A and FU-A
+func NewRTCRtpH264Codec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            H264,
+		MimeType:        "video/H264",
+		ClockRate:       90000,
+		SDPFmtpLine:     "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.H264Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.H264Packet{} },
+	}
+}
+
+// NewRTCRtpAV1Codec creates an AV1 codec with the given payload type
+func NewRTCRtpAV1Codec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            AV1,
+		MimeType:        "video/AV1",
+		ClockRate:       90000,
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.AV1Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.AV1Packet{} },
+	}
+}
+
+// NewRTCRtpH265Codec creates a H265 codec with the given payload type
+func NewRTCRtpH265Codec(payloadType uint8) *RTCRtpCodec {
+	return &RTCRtpCodec{
+		Type:            H265,
+		MimeType:        "video/H265",
+		ClockRate:       90000,
+		SDPFmtpLine:     "profile-id=1;tier-flag=0;level-id=93",
+		PayloadType:     payloadType,
+		NewPayloader:    func() rtp.Payloader { return &codecs.H265Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &codecs.H265Packet{} },
+	}
+}
@@ -0,0 +1,152 @@
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/rtp/codecs"
+	"github.com/pions/webrtc/pkg/sdp"
+)
+
+func TestMediaEngineNegotiate(t *testing.T) {
+	// The first registered codec that matches is used
+	m := &MediaEngine{}
+	vp8 := NewRTCRtpVP8Codec(96)
+	vp8.RTCPFeedback = []RTCRtcpFeedback{{Type: "nack"}, {Type: "nack", Parameter: "pli"}}
+	m.RegisterCodec(vp8)
+	m.RegisterDefaultCodecs()
+
+	remote := &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "audio", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"109", "9", "0"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "109 opus/48000/2"},
+				{Key: "rtpmap", Value: "9 G722/8000/1"},
+				{Key: "rtpmap", Value: "0 PCMU/8000/2"},
+			}},
+			{MediaName: sdp.MediaName{Media: "video", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"120", "126", "97", "125"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "120 VP8/90000"},
+				{Key: "rtcp-fb", Value: "120 nack pli"},
+				{Key: "rtcp-fb", Value: "120 goog-remb"},
+				{Key: "rtpmap", Value: "126 H264/90000"},
+				{Key: "fmtp", Value: "126 profile-level-id=42e01f;level-asymmetry-allowed=1;packetization-mode=1"},
+				{Key: "rtpmap", Value: "97 H264/90000"},
+				{Key: "fmtp", Value: "97 profile-level-id=42e01f;level-asymmetry-allowed=1"},
+				{Key: "rtpmap", Value: "125 H264/90000"},
+				{Key: "fmtp", Value: "125 profile-level-id=640032;packetization-mode=1"},
+			}},
+		},
+	}
+
+	negotiated := m.negotiate(remote)
+	if len(negotiated) != 4 {
+		t.Fatalf("expected opus, G722, VP8 and H264 to be negotiated, got %d codecs", len(negotiated))
+	}
+
+	for i, expected := range []struct {
+		mediaType   TrackType
+		payloadType uint8
+	}{
+		{Opus, 109}, {G722, 9}, {VP8, 120}, {H264, 126},
+	} {
+		if negotiated[i].Type != expected.mediaType || negotiated[i].PayloadType != expected.payloadType {
+			t.Errorf("expected %s with payload type %d, got %s with %d", expected.mediaType, expected.payloadType, negotiated[i].Type, negotiated[i].PayloadType)
+		}
+	}
+
+	if feedback := negotiated[2].RTCPFeedback; len(feedback) != 1 || feedback[0].String() != "nack pli" {
+		t.Errorf("expected only nack pli to be negotiated, got %v", feedback)
+	}
+	if fmtp := negotiated[3].SDPFmtpLine; fmtp != "profile-level-id=42e01f;level-asymmetry-allowed=1;packetization-mode=1" {
+		t.Errorf("expected the H264 fmtp of the offer to be answered, got %s", fmtp)
+	}
+
+	// Registered codecs keep their payload types until there is a remote description
+	if offered := m.negotiate(nil); len(offered) != 10 || offered[1].PayloadType != 111 {
+		t.Errorf("expected every registered codec to be used without a remote description")
+	}
+}
+
+func TestMediaEngineNegotiateH265(t *testing.T) {
+	h265 := NewRTCRtpH265Codec(DefaultPayloadTypeH265)
+	for _, test := range []struct {
+		fmtp       string
+		negotiated bool
+	}{
+		{"profile-id=1;level-id=120", true},
+		{"level-id=93", true},
+		{"profile-id=2;level-id=93", false},
+		{"profile-id=1;tier-flag=1;level-id=93", false},
+	} {
+		negotiated, ok := h265.negotiate(&sdp.Codec{PayloadType: 110, Name: "H265", ClockRate: 90000, Fmtp: test.fmtp})
+		if ok != test.negotiated {
+			t.Errorf("expected H265 with fmtp %s to be negotiated: %t", test.fmtp, test.negotiated)
+		} else if ok && (negotiated.SDPFmtpLine != test.fmtp || negotiated.sdpCodec().Fmtp != test.fmtp) {
+			t.Errorf("expected the H265 fmtp of the offer to be answered, got %s", negotiated.SDPFmtpLine)
+		}
+	}
+}
+
+func TestMediaEngineNegotiateStaticPayloadTypes(t *testing.T) {
+	m := &MediaEngine{}
+	m.RegisterDefaultCodecs()
+
+	// Static payload types are negotiated without an rtpmap
+	offered := &sdp.MediaDescription{MediaName: sdp.MediaName{Media: "audio", Port: 4000, Protos: []string{"RTP", "AVP"}, Formats: []string{"0", "8", "101"}}}
+	negotiated := m.negotiateMedia(offered)
+	if len(negotiated) != 2 || negotiated[0].Type != PCMU || negotiated[0].PayloadType != 0 || negotiated[1].Type != PCMA || negotiated[1].PayloadType != 8 {
+		t.Errorf("expected PCMU and PCMA to be negotiated with their static payload types, got %d codecs", len(negotiated))
+	}
+}
+
+func TestMediaEngineCustomCodec(t *testing.T) {
+	telemetry := &RTCRtpCodec{
+		MimeType:     "application/x-telemetry",
+		ClockRate:    1000,
+		SDPFmtpLine:  "version=2",
+		PayloadType:  120,
+		NewPayloader: func() rtp.Payloader { return &codecs.G711Payloader{} },
+	}
+	m := &MediaEngine{}
+	m.RegisterCodec(telemetry)
+
+	r, err := New(&RTCConfiguration{MediaEngine: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddTrack(NewRTCRtpVP8Codec(96), "video", "stream"); err == nil {
+		t.Errorf("expected AddTrack to fail for a codec that isn't registered")
+	}
+	if _, err := r.AddTrack(&RTCRtpCodec{MimeType: "application/x-telemetry", ClockRate: 1000}, "telemetry", "stream"); err != nil {
+		t.Errorf("expected AddTrack to accept a descriptor of a registered codec: %v", err)
+	}
+
+	// Without a payloader the samples of a track can't be packetized, its packets have to be written with WriteRTP
+	m.RegisterCodec(&RTCRtpCodec{MimeType: "application/x-raw", ClockRate: 1000})
+	if _, err := r.AddTrack(&RTCRtpCodec{MimeType: "application/x-raw", ClockRate: 1000}, "raw", "stream"); err == nil {
+		t.Errorf("expected AddTrack to fail for a codec without a payloader")
+	}
+	if _, err := r.AddRTPTrack(&RTCRtpCodec{MimeType: "application/x-raw", ClockRate: 1000}, "raw", "stream"); err != nil {
+		t.Errorf("expected AddRTPTrack to accept a codec without a payloader: %v", err)
+	}
+
+	r.remoteDescription = &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "application", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"121"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "121 x-telemetry/1000"},
+				{Key: "fmtp", Value: "121 version=2"},
+			}},
+		},
+	}
+
+	media, _ := r.negotiateMedia()
+	if len(media) != 1 || len(media[0].Codecs) != 1 {
+		t.Fatalf("expected only the telemetry codec to be negotiated")
+	}
+	if c := media[0].Codecs[0]; c.PayloadType != 121 || c.Name != "x-telemetry" || c.ClockRate != 1000 || c.Fmtp != "version=2" {
+		t.Errorf("telemetry was not answered with the offered payload type and its fmtp: %+v", c)
+	}
+
+	if negotiated := r.getCodecForPayloadType(121); negotiated == nil || negotiated.Kind() != "application" || negotiated.Type != 0 {
+		t.Errorf("expected the negotiated codec to be delivered to Ontrack for payload type 121")
+	}
+}
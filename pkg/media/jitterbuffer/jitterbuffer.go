@@ -0,0 +1,335 @@
+// Package jitterbuffer reorders RTP packets and releases them at a steady pace
+package jitterbuffer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+const (
+	// jitterMultiplier is how many times the measured jitter the adaptive delay holds packets for
+	jitterMultiplier = 3
+
+	defaultMinDelay = 10 * time.Millisecond
+	defaultMaxDelay = 500 * time.Millisecond
+
+	// maxDropout is how far ahead and maxMisorder how far behind the highest sequence number a packet can be before
+	// it is a jump of the sequence. A jump only resets the buffer if the next packet follows it, the sender restarted
+	// https://tools.ietf.org/html/rfc3550#appendix-A.1
+	maxDropout  = 3000
+	maxMisorder = 100
+)
+
+// Config configures a JitterBuffer
+type Config struct {
+	// Delay is a fixed playout delay. If it is zero, the delay adapts to the measured jitter,
+	// staying between MinDelay and MaxDelay
+	Delay              time.Duration
+	MinDelay, MaxDelay time.Duration
+
+	// OnLate is called for packets that arrive after packets that follow them have been released
+	OnLate func(packet *rtp.Packet)
+
+	// OnLost is called for every sequence number that is skipped because it never arrived in time
+	OnLost func(ssrc uint32, sequenceNumber uint16)
+}
+
+// Stats are the counters of a JitterBuffer
+type Stats struct {
+	Received  uint64
+	Released  uint64
+	Late      uint64
+	Lost      uint64
+	Duplicate uint64
+
+	// Jitter is the interarrival jitter https://tools.ietf.org/html/rfc3550#section-6.4.1
+	Jitter time.Duration
+
+	// Delay is the current playout delay
+	Delay time.Duration
+}
+
+type entry struct {
+	packet    *rtp.Packet
+	timestamp int64
+}
+
+// probation is the packet a sequence jumped to, it is held until the next packet shows if the sender restarted
+type probation struct {
+	packet  *rtp.Packet
+	arrival time.Time
+}
+
+// JitterBuffer holds RTP packets of a single SSRC, it reorders them by extended sequence number and
+// releases them when their playout time has passed. The playout time of a packet is the time its
+// RTP timestamp maps to, plus the playout delay
+type JitterBuffer struct {
+	lock sync.Mutex
+
+	config    Config
+	clockRate uint32
+
+	started bool
+	packets map[uint64]*entry
+
+	// nextSeq is the extended sequence number of the next packet to release
+	nextSeq    uint64
+	highestSeq uint64
+
+	// highestTimestamp is the extended RTP timestamp of the newest packet
+	highestTimestamp int64
+
+	probation *probation
+
+	// minTransit is the smallest difference between arrival time and RTP timestamp, it maps
+	// RTP timestamps to the local clock
+	minTransit time.Duration
+
+	lastArrival   time.Time
+	lastTimestamp int64
+	jitter        float64
+
+	stats Stats
+}
+
+// New creates a JitterBuffer for a stream with the given RTP clock rate
+func New(clockRate uint32, config Config) *JitterBuffer {
+	if config.MinDelay == 0 {
+		config.MinDelay = defaultMinDelay
+	}
+	if config.MaxDelay == 0 {
+		config.MaxDelay = defaultMaxDelay
+	}
+
+	return &JitterBuffer{
+		config:    config,
+		clockRate: clockRate,
+		packets:   map[uint64]*entry{},
+	}
+}
+
+func (j *JitterBuffer) reset(sequenceNumber uint16, timestamp uint32) {
+	j.started = true
+	j.packets = map[uint64]*entry{}
+
+	// Start one cycle in, so sequence numbers from before the first packet stay positive
+	j.nextSeq = 1<<16 | uint64(sequenceNumber)
+	j.highestSeq = j.nextSeq
+	j.highestTimestamp = 1<<32 | int64(timestamp)
+	j.lastArrival = time.Time{}
+	j.probation = nil
+}
+
+// inSequence returns if a sequence number is within maxDropout ahead or maxMisorder behind the highest one
+func (j *JitterBuffer) inSequence(sequenceNumber uint16) bool {
+	delta := sequenceNumber - uint16(j.highestSeq)
+	return delta < maxDropout || delta > 1<<16-maxMisorder
+}
+
+// extendSequenceNumber returns the extended sequence number, handling wraparound
+func (j *JitterBuffer) extendSequenceNumber(sequenceNumber uint16) uint64 {
+	delta := int16(sequenceNumber - uint16(j.highestSeq))
+	return uint64(int64(j.highestSeq) + int64(delta))
+}
+
+// extendTimestamp returns the extended RTP timestamp, handling wraparound
+func (j *JitterBuffer) extendTimestamp(timestamp uint32) int64 {
+	delta := int32(timestamp - uint32(j.highestTimestamp))
+	return j.highestTimestamp + int64(delta)
+}
+
+func (j *JitterBuffer) toDuration(timestamp int64) time.Duration {
+	clockRate := int64(j.clockRate)
+	return time.Duration(timestamp/clockRate)*time.Second + time.Duration(timestamp%clockRate*int64(time.Second)/clockRate)
+}
+
+func (j *JitterBuffer) delay() time.Duration {
+	if j.config.Delay != 0 {
+		return j.config.Delay
+	}
+
+	delay := time.Duration(j.jitter * jitterMultiplier * float64(time.Second) / float64(j.clockRate))
+	if delay < j.config.MinDelay {
+		return j.config.MinDelay
+	} else if delay > j.config.MaxDelay {
+		return j.config.MaxDelay
+	}
+	return delay
+}
+
+func (j *JitterBuffer) playoutTime(e *entry) time.Time {
+	return time.Unix(0, 0).Add(j.toDuration(e.timestamp) + j.minTransit + j.delay())
+}
+
+// Push adds a packet that arrived at the given time. A packet the sequence numbers jump to is held until the next
+// packet follows it, which means the sender restarted and the buffer starts over with both
+func (j *JitterBuffer) Push(packet *rtp.Packet, arrival time.Time) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.stats.Received++
+	if !j.started {
+		j.reset(packet.SequenceNumber, packet.Timestamp)
+	} else if !j.inSequence(packet.SequenceNumber) {
+		// The sender restarted if the packet follows the one the sequence jumped to, everything we hold is stale
+		held := j.probation
+		if held == nil || packet.SequenceNumber != held.packet.SequenceNumber+1 {
+			j.probation = &probation{packet: packet, arrival: arrival}
+			return
+		}
+		j.reset(held.packet.SequenceNumber, held.packet.Timestamp)
+		j.push(held.packet, held.arrival)
+	}
+	j.push(packet, arrival)
+}
+
+func (j *JitterBuffer) push(packet *rtp.Packet, arrival time.Time) {
+	seq := j.extendSequenceNumber(packet.SequenceNumber)
+	if seq < j.nextSeq {
+		j.stats.Late++
+		if j.config.OnLate != nil {
+			j.config.OnLate(packet)
+		}
+		return
+	} else if _, ok := j.packets[seq]; ok {
+		j.stats.Duplicate++
+		return
+	}
+
+	timestamp := j.extendTimestamp(packet.Timestamp)
+	if seq > j.highestSeq {
+		j.highestSeq = seq
+		j.highestTimestamp = timestamp
+	}
+
+	// https://tools.ietf.org/html/rfc3550#section-6.4.1
+	// D(i,j) = (Rj - Ri) - (Sj - Si) = (Rj - Sj) - (Ri - Si)
+	// J(i) = J(i-1) + (|D(i-1,i)| - J(i-1))/16
+	transit := time.Duration(arrival.UnixNano()) - j.toDuration(timestamp)
+	if !j.lastArrival.IsZero() {
+		d := float64(arrival.Sub(j.lastArrival))*float64(j.clockRate)/float64(time.Second) - float64(timestamp-j.lastTimestamp)
+		if d < 0 {
+			d = -d
+		}
+		j.jitter += (d - j.jitter) / 16
+	}
+	if j.lastArrival.IsZero() || transit < j.minTransit {
+		j.minTransit = transit
+	}
+	j.lastArrival = arrival
+	j.lastTimestamp = timestamp
+
+	j.packets[seq] = &entry{packet: packet, timestamp: timestamp}
+}
+
+// next returns the extended sequence number of the oldest packet held
+func (j *JitterBuffer) next() (seq uint64, ok bool) {
+	for s := range j.packets {
+		if !ok || s < seq {
+			seq, ok = s, true
+		}
+	}
+	return seq, ok
+}
+
+// Pop returns the next packet in sequence order if its playout time has passed, packets
+// that are missing when a later packet is due are reported as lost and skipped
+func (j *JitterBuffer) Pop(now time.Time) *rtp.Packet {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if e, ok := j.packets[j.nextSeq]; ok {
+		if now.Before(j.playoutTime(e)) {
+			return nil
+		}
+
+		delete(j.packets, j.nextSeq)
+		j.nextSeq++
+		j.stats.Released++
+		return e.packet
+	}
+
+	seq, ok := j.next()
+	if !ok || now.Before(j.playoutTime(j.packets[seq])) {
+		return nil
+	}
+
+	// The packets before seq are lost, release seq in their place
+	for ; j.nextSeq < seq; j.nextSeq++ {
+		j.stats.Lost++
+		if j.config.OnLost != nil {
+			j.config.OnLost(j.packets[seq].packet.SSRC, uint16(j.nextSeq))
+		}
+	}
+
+	e := j.packets[seq]
+	delete(j.packets, seq)
+	j.nextSeq++
+	j.stats.Released++
+	return e.packet
+}
+
+// NextRelease returns when the next packet is due, ok is false if the buffer is empty
+func (j *JitterBuffer) NextRelease() (release time.Time, ok bool) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	seq, ok := j.next()
+	if !ok {
+		return release, false
+	}
+	return j.playoutTime(j.packets[seq]), true
+}
+
+// Stats returns the counters of the JitterBuffer
+func (j *JitterBuffer) Stats() Stats {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	stats := j.stats
+	stats.Jitter = time.Duration(j.jitter * float64(time.Second) / float64(j.clockRate))
+	stats.Delay = j.delay()
+	return stats
+}
+
+// Run pushes the packets received on in, and writes them to out once they are released.
+// When in is closed the remaining packets are released immediately and out is closed
+func (j *JitterBuffer) Run(in <-chan *rtp.Packet, out chan<- *rtp.Packet) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		select {
+		case packet, ok := <-in:
+			if !ok {
+				drain := time.Unix(1<<62, 0)
+				for packet := j.Pop(drain); packet != nil; packet = j.Pop(drain) {
+					out <- packet
+				}
+				close(out)
+				return
+			}
+			j.Push(packet, time.Now())
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for packet := j.Pop(now); packet != nil; packet = j.Pop(now) {
+			out <- packet
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if release, ok := j.NextRelease(); ok {
+			timer.Reset(release.Sub(time.Now()))
+		} else {
+			timer.Reset(time.Hour)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package jitterbuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func packet(seq uint16, timestamp uint32) *rtp.Packet {
+	return &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: seq, Timestamp: timestamp}}
+}
+
+func TestJitterBufferReorder(t *testing.T) {
+	start := time.Unix(100, 0)
+	j := New(90000, Config{Delay: 50 * time.Millisecond})
+
+	// 65535 and 1 arrive before 0, across the sequence number wrap
+	j.Push(packet(65534, 0), start)
+	j.Push(packet(65535, 3000), start.Add(33*time.Millisecond))
+	j.Push(packet(1, 9000), start.Add(100*time.Millisecond))
+	j.Push(packet(0, 6000), start.Add(110*time.Millisecond))
+
+	if p := j.Pop(start.Add(49 * time.Millisecond)); p != nil {
+		t.Fatalf("packet %d was released before its playout delay", p.SequenceNumber)
+	}
+
+	var released []uint16
+	for p := j.Pop(start.Add(time.Second)); p != nil; p = j.Pop(start.Add(time.Second)) {
+		released = append(released, p.SequenceNumber)
+	}
+	if len(released) != 4 || released[0] != 65534 || released[1] != 65535 || released[2] != 0 || released[3] != 1 {
+		t.Errorf("packets were not released in order: %v", released)
+	}
+}
+
+func TestJitterBufferLostAndLate(t *testing.T) {
+	start := time.Unix(100, 0)
+
+	var lost []uint16
+	var late []uint16
+	j := New(90000, Config{
+		Delay:  20 * time.Millisecond,
+		OnLost: func(ssrc uint32, seq uint16) { lost = append(lost, seq) },
+		OnLate: func(p *rtp.Packet) { late = append(late, p.SequenceNumber) },
+	})
+
+	j.Push(packet(10, 0), start)
+	j.Push(packet(13, 9000), start.Add(100*time.Millisecond))
+
+	if p := j.Pop(start.Add(30 * time.Millisecond)); p == nil || p.SequenceNumber != 10 {
+		t.Fatalf("expected packet 10 to be released")
+	}
+	if p := j.Pop(start.Add(30 * time.Millisecond)); p != nil {
+		t.Fatalf("packet %d was released before the gap was due", p.SequenceNumber)
+	}
+	if p := j.Pop(start.Add(130 * time.Millisecond)); p == nil || p.SequenceNumber != 13 {
+		t.Fatalf("expected packet 13 to be released after the gap")
+	}
+
+	j.Push(packet(11, 3000), start.Add(140*time.Millisecond))
+
+	stats := j.Stats()
+	if len(lost) != 2 || lost[0] != 11 || lost[1] != 12 || stats.Lost != 2 {
+		t.Errorf("expected 11 and 12 to be lost, got %v", lost)
+	}
+	if len(late) != 1 || late[0] != 11 || stats.Late != 1 {
+		t.Errorf("expected 11 to be late, got %v", late)
+	}
+}
+
+func TestJitterBufferRestart(t *testing.T) {
+	start := time.Unix(100, 0)
+	j := New(90000, Config{Delay: 50 * time.Millisecond})
+
+	j.Push(packet(20000, 0), start)
+	j.Push(packet(20001, 3000), start.Add(33*time.Millisecond))
+
+	// A single packet far behind is dropped, the sender restarts once two packets follow each other
+	j.Push(packet(5000, 900000), start.Add(40*time.Millisecond))
+	j.Push(packet(10000, 600000), start.Add(50*time.Millisecond))
+	j.Push(packet(10001, 603000), start.Add(83*time.Millisecond))
+	j.Push(packet(10002, 606000), start.Add(116*time.Millisecond))
+
+	var released []uint16
+	for p := j.Pop(start.Add(time.Second)); p != nil; p = j.Pop(start.Add(time.Second)) {
+		released = append(released, p.SequenceNumber)
+	}
+	if len(released) != 3 || released[0] != 10000 || released[1] != 10001 || released[2] != 10002 {
+		t.Errorf("expected the buffer to restart with the new sequence numbers, got %v", released)
+	}
+	if stats := j.Stats(); stats.Received != 6 || stats.Late != 0 || stats.Lost != 0 {
+		t.Errorf("unexpected stats after the restart: %+v", stats)
+	}
+}
@@ -0,0 +1,9 @@
+// Package media provides media writer and filters
+package media
+
+// Sample contains media, and the amount of samples in it
+// Samples is the duration of the media in units of the RTP clock rate of its codec
+type Sample struct {
+	Data    []byte
+	Samples uint32
+}
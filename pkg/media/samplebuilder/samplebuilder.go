@@ -0,0 +1,158 @@
+// Package samplebuilder builds media frames from RTP packets
+package samplebuilder
+
+import (
+	"github.com/pions/webrtc/pkg/media"
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+// SampleBuilder contains all packets
+// maxLate determines how long we should wait until we get a valid RTCSample
+// The larger the value the less packet loss you will see, but higher latency
+type SampleBuilder struct {
+	maxLate      uint16
+	buffer       [65536]*rtp.Packet
+	depacketizer rtp.Depacketizer
+
+	// head is the sequence number of the first packet of the next frame
+	hasHead bool
+	head    uint16
+
+	// lastPush is the newest sequence number we have been given
+	lastPush uint16
+
+	// lastSamples is used when the timestamp of the frame after the one being popped is unknown
+	lastSamples uint32
+}
+
+// New constructs a new SampleBuilder
+// maxLate is how many packets past a gap we wait before giving up on it
+func New(maxLate uint16, depacketizer rtp.Depacketizer) *SampleBuilder {
+	return &SampleBuilder{maxLate: maxLate, depacketizer: depacketizer}
+}
+
+// seqnumDistance returns how far b is ahead of a, handling wraparound
+func seqnumDistance(a, b uint16) uint16 {
+	return b - a
+}
+
+// isNewer reports if b is after a, assuming they are less than half the sequence space apart
+func isNewer(a, b uint16) bool {
+	return a != b && seqnumDistance(a, b) < 0x8000
+}
+
+// Push adds a RTP Packet to the sample builder
+func (s *SampleBuilder) Push(p *rtp.Packet) {
+	if !s.hasHead {
+		s.hasHead = true
+		s.head = p.SequenceNumber
+		s.lastPush = p.SequenceNumber
+	} else if isNewer(p.SequenceNumber, s.head) {
+		// We already emitted or dropped the frame this packet belongs to
+		return
+	}
+
+	if isNewer(s.lastPush, p.SequenceNumber) {
+		s.lastPush = p.SequenceNumber
+	}
+
+	// Packets that are further than the buffer can hold make everything before them late
+	if seqnumDistance(s.head, s.lastPush) >= 0x8000 {
+		s.clear(s.head, s.lastPush-s.maxLate)
+		s.head = s.lastPush - s.maxLate
+	}
+
+	s.buffer[p.SequenceNumber] = p
+}
+
+// clear drops all buffered packets from start up to, but not including, end
+func (s *SampleBuilder) clear(start, end uint16) {
+	for i := start; i != end; i++ {
+		s.buffer[i] = nil
+	}
+}
+
+// isLate checks if we have waited long enough for the packet with the given sequence number
+func (s *SampleBuilder) isLate(seqnum uint16) bool {
+	return seqnumDistance(seqnum, s.lastPush) >= s.maxLate && isNewer(seqnum, s.lastPush)
+}
+
+// frameEnd finds the last packet of the frame that starts at head, the last contiguous packet with
+// the same timestamp. ready is false if more packets are needed, the frame is dropped if they are late
+func (s *SampleBuilder) frameEnd() (end uint16, ready bool) {
+	first := s.buffer[s.head]
+	for end = s.head; !s.buffer[end].Marker; end++ {
+		next := s.buffer[end+1]
+		if next == nil {
+			if s.isLate(end + 1) {
+				s.clear(s.head, end+1)
+				s.head = end + 1
+			}
+			return end, false
+		} else if next.Timestamp != first.Timestamp {
+			break
+		}
+	}
+	return end, true
+}
+
+// Pop scans the buffer for a complete frame, frames that are missing packets and have
+// waited more than maxLate are dropped. nil is returned if no frame is ready yet
+func (s *SampleBuilder) Pop() *media.Sample {
+	for s.hasHead {
+		first := s.buffer[s.head]
+		if first == nil {
+			if !s.isLate(s.head) {
+				return nil
+			}
+
+			// Give up on the missing packet
+			s.head++
+			continue
+		}
+
+		if !s.depacketizer.IsPartitionHead(first.Payload) {
+			// The start of this frame was lost, drop what we have of it
+			s.buffer[s.head] = nil
+			s.head++
+			continue
+		}
+
+		end, ready := s.frameEnd()
+		if !ready {
+			if s.buffer[s.head] == nil { // frameEnd dropped the frame
+				continue
+			}
+			return nil
+		}
+
+		// The duration is known once the first packet of the next frame is here
+		samples := s.lastSamples
+		if next := s.buffer[end+1]; next != nil && next.Timestamp != first.Timestamp {
+			samples = next.Timestamp - first.Timestamp
+		} else if !s.isLate(end + 1) {
+			return nil
+		}
+
+		var data []byte
+		valid := true
+		for i := s.head; i != end+1; i++ {
+			payload, err := s.depacketizer.Unmarshal(s.buffer[i])
+			if err != nil {
+				valid = false
+				break
+			}
+			data = append(data, payload...)
+		}
+
+		s.clear(s.head, end+1)
+		s.head = end + 1
+		if !valid {
+			continue
+		}
+
+		s.lastSamples = samples
+		return &media.Sample{Data: data, Samples: samples}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package samplebuilder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/media"
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+type sampleBuilderTest struct {
+	message string
+	packets []*rtp.Packet
+	samples []*media.Sample
+	maxLate uint16
+}
+
+// fakeDepacketizer treats a payload starting with 0xFF as a continuation of the previous packet
+type fakeDepacketizer struct{}
+
+func (f *fakeDepacketizer) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	return packet.Payload, nil
+}
+
+func (f *fakeDepacketizer) IsPartitionHead(payload []byte) bool {
+	return len(payload) != 0 && payload[0] != 0xFF
+}
+
+func packet(seq uint16, timestamp uint32, marker bool, payload ...byte) *rtp.Packet {
+	return &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, Timestamp: timestamp, Marker: marker}, Payload: payload}
+}
+
+var testCases = []sampleBuilderTest{
+	{
+		message: "SampleBuilder shouldn't emit anything if only one RTP packet has been pushed",
+		packets: []*rtp.Packet{packet(5000, 5, true, 0x01)},
+		samples: []*media.Sample{},
+		maxLate: 50,
+	},
+	{
+		message: "SampleBuilder should emit one frame once the next frame starts",
+		packets: []*rtp.Packet{packet(5000, 5, true, 0x01), packet(5001, 6, true, 0x02)},
+		samples: []*media.Sample{{Data: []byte{0x01}, Samples: 1}},
+		maxLate: 50,
+	},
+	{
+		message: "SampleBuilder should join the packets of a frame, and reorder them",
+		packets: []*rtp.Packet{
+			packet(5000, 5, false, 0x01),
+			packet(5002, 5, true, 0xFF, 0x03),
+			packet(5001, 5, false, 0xFF, 0x02),
+			packet(5003, 8, true, 0x04),
+		},
+		samples: []*media.Sample{{Data: []byte{0x01, 0xFF, 0x02, 0xFF, 0x03}, Samples: 3}},
+		maxLate: 50,
+	},
+	{
+		message: "SampleBuilder should wrap around the sequence number",
+		packets: []*rtp.Packet{
+			packet(65534, 5, true, 0x01),
+			packet(65535, 6, false, 0x02),
+			packet(0, 6, true, 0xFF, 0x03),
+			packet(1, 7, true, 0x04),
+		},
+		samples: []*media.Sample{{Data: []byte{0x01}, Samples: 1}, {Data: []byte{0x02, 0xFF, 0x03}, Samples: 1}},
+		maxLate: 50,
+	},
+	{
+		message: "SampleBuilder should drop a frame with a missing packet once it is late",
+		packets: []*rtp.Packet{
+			packet(5000, 5, false, 0x01),
+			packet(5002, 5, true, 0xFF, 0x03),
+			packet(5003, 6, true, 0x04),
+			packet(5004, 7, true, 0x05),
+			packet(5005, 8, true, 0x06),
+		},
+		samples: []*media.Sample{{Data: []byte{0x04}, Samples: 1}, {Data: []byte{0x05}, Samples: 1}},
+		maxLate: 2,
+	},
+	{
+		message: "SampleBuilder should drop a frame that lost its head",
+		packets: []*rtp.Packet{
+			packet(5001, 5, true, 0xFF, 0x02),
+			packet(5002, 6, true, 0x03),
+			packet(5003, 7, true, 0x04),
+		},
+		samples: []*media.Sample{{Data: []byte{0x03}, Samples: 1}},
+		maxLate: 50,
+	},
+}
+
+func TestSampleBuilder(t *testing.T) {
+	for _, test := range testCases {
+		s := New(test.maxLate, &fakeDepacketizer{})
+		samples := []*media.Sample{}
+
+		for _, p := range test.packets {
+			s.Push(p)
+			for sample := s.Pop(); sample != nil; sample = s.Pop() {
+				samples = append(samples, sample)
+			}
+		}
+
+		if !reflect.DeepEqual(samples, test.samples) {
+			t.Errorf("%s\nexpected %v\ngot %v", test.message, test.samples, samples)
+		}
+	}
+}
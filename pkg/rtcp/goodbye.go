@@ -0,0 +1,88 @@
+package rtcp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	headerLength = 4
+	versionShift = 6
+	countMask    = 0x1F
+	ssrcLength   = 4
+
+	// TypeGoodbye is the packet type of a Goodbye packet
+	TypeGoodbye = 203
+)
+
+// Goodbye is an RTCP BYE packet, it tells the remote that the sources are no longer sent
+// https://tools.ietf.org/html/rfc3550#section-6.6
+type Goodbye struct {
+	Sources []uint32
+
+	// Reason is optional, it is left out if empty
+	Reason string
+}
+
+// Marshal encodes the Goodbye packet, padding the reason to a multiple of 4 octets
+func (g *Goodbye) Marshal() ([]byte, error) {
+	if len(g.Sources) > countMask {
+		return nil, errors.Errorf("a Goodbye packet can have at most %d sources, got %d", countMask, len(g.Sources))
+	} else if len(g.Reason) > 0xFF {
+		return nil, errors.Errorf("a Goodbye reason can be at most 255 octets, got %d", len(g.Reason))
+	}
+
+	length := headerLength + len(g.Sources)*ssrcLength
+	if g.Reason != "" {
+		length += (1 + len(g.Reason) + 3) / 4 * 4
+	}
+
+	raw := make([]byte, length)
+	raw[0] = 2<<versionShift | uint8(len(g.Sources))
+	raw[1] = TypeGoodbye
+	// The length is in 32-bit words minus one
+	binary.BigEndian.PutUint16(raw[2:], uint16(length/4-1))
+
+	for i, ssrc := range g.Sources {
+		binary.BigEndian.PutUint32(raw[headerLength+i*ssrcLength:], ssrc)
+	}
+	if g.Reason != "" {
+		reasonOffset := headerLength + len(g.Sources)*ssrcLength
+		raw[reasonOffset] = uint8(len(g.Reason))
+		copy(raw[reasonOffset+1:], g.Reason)
+	}
+	return raw, nil
+}
+
+// Unmarshal decodes a Goodbye packet
+func (g *Goodbye) Unmarshal(raw []byte) error {
+	if len(raw) < headerLength {
+		return errors.Errorf("RTCP packet is not large enough to contain a header: %d", len(raw))
+	} else if raw[0]>>versionShift != 2 {
+		return errors.Errorf("RTCP version must be 2, got %d", raw[0]>>versionShift)
+	} else if raw[1] != TypeGoodbye {
+		return errors.Errorf("packet type %d is not a Goodbye packet", raw[1])
+	}
+
+	length := (int(binary.BigEndian.Uint16(raw[2:])) + 1) * 4
+	count := int(raw[0] & countMask)
+	if len(raw) < length || length < headerLength+count*ssrcLength {
+		return errors.Errorf("Goodbye packet is not large enough to contain %d sources", count)
+	}
+
+	g.Sources = make([]uint32, count)
+	for i := range g.Sources {
+		g.Sources[i] = binary.BigEndian.Uint32(raw[headerLength+i*ssrcLength:])
+	}
+
+	g.Reason = ""
+	if reasonOffset := headerLength + count*ssrcLength; reasonOffset < length {
+		reasonLength := int(raw[reasonOffset])
+		if reasonOffset+1+reasonLength > length {
+			return errors.Errorf("Goodbye reason is longer than the packet")
+		}
+		g.Reason = string(raw[reasonOffset+1 : reasonOffset+1+reasonLength])
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package rtcp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestGoodbyeRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		goodbye  Goodbye
+		expected []byte
+	}{
+		{
+			Goodbye{Sources: []uint32{0x902f9e2e}},
+			[]byte{0x81, 0xcb, 0x00, 0x01, 0x90, 0x2f, 0x9e, 0x2e},
+		},
+		{
+			Goodbye{Sources: []uint32{0x01020304, 0x05060708}, Reason: "FOO"},
+			[]byte{0x82, 0xcb, 0x00, 0x03, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x03, 'F', 'O', 'O'},
+		},
+		{
+			Goodbye{Sources: []uint32{0x01020304}, Reason: "BY"},
+			[]byte{0x81, 0xcb, 0x00, 0x02, 0x01, 0x02, 0x03, 0x04, 0x02, 'B', 'Y', 0x00},
+		},
+	} {
+		raw, err := test.goodbye.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(raw, test.expected) {
+			t.Errorf("expected %+v to marshal to %v, got %v", test.goodbye, test.expected, raw)
+		}
+
+		var g Goodbye
+		if err := g.Unmarshal(raw); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(g, test.goodbye) {
+			t.Errorf("expected %v to unmarshal to %+v, got %+v", raw, test.goodbye, g)
+		}
+	}
+
+	for _, raw := range [][]byte{
+		{0x81, 0xcb},
+		{0x81, 0xc8, 0x00, 0x01, 0x90, 0x2f, 0x9e, 0x2e},
+		{0x82, 0xcb, 0x00, 0x01, 0x90, 0x2f, 0x9e, 0x2e},
+		{0x81, 0xcb, 0x00, 0x02, 0x01, 0x02, 0x03, 0x04, 0x09, 'B', 'Y', 0x00},
+	} {
+		if err := (&Goodbye{}).Unmarshal(raw); err == nil {
+			t.Errorf("expected an error unmarshalling %v", raw)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package rtp
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	absSendTimeExtensionSize = 3
+)
+
+// AbsSendTimeExtension is a 24-bit, 6.18 fixed point NTP timestamp of when the packet was sent
+// https://webrtc.org/experiments/rtp-hdrext/abs-send-time/
+type AbsSendTimeExtension struct {
+	Timestamp uint32
+}
+
+// NewAbsSendTimeExtension builds an AbsSendTimeExtension for the given send time
+func NewAbsSendTimeExtension(sendTime time.Time) *AbsSendTimeExtension {
+	return &AbsSendTimeExtension{
+		Timestamp: uint32(toNtpTime(sendTime)>>14) & 0xFFFFFF,
+	}
+}
+
+// Marshal serializes the members to buffer
+func (a *AbsSendTimeExtension) Marshal() ([]byte, error) {
+	return []byte{
+		byte(a.Timestamp >> 16),
+		byte(a.Timestamp >> 8),
+		byte(a.Timestamp),
+	}, nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the members
+func (a *AbsSendTimeExtension) Unmarshal(rawExtension []byte) error {
+	if len(rawExtension) < absSendTimeExtensionSize {
+		return errors.Errorf("abs-send-time extension size insufficient; %d < %d", len(rawExtension), absSendTimeExtensionSize)
+	}
+	a.Timestamp = uint32(rawExtension[0])<<16 | uint32(rawExtension[1])<<8 | uint32(rawExtension[2])
+	return nil
+}
+
+// Estimate returns the send time, assuming the packet was received at receiveTime and spent less
+// than 64 seconds (the wrap around of the 6 bits of seconds) in flight
+func (a *AbsSendTimeExtension) Estimate(receiveTime time.Time) time.Time {
+	receiveNTP := toNtpTime(receiveTime) >> 14
+	sendNTP := receiveNTP&^0xFFFFFF | uint64(a.Timestamp)
+	if sendNTP > receiveNTP {
+		sendNTP -= 0x1000000
+	}
+	return toTime(sendNTP << 14)
+}
+
+// NTP epoch is January 1st 1900, 2208988800 seconds before the Unix epoch
+const ntpEpochOffset = 2208988800
+
+func toNtpTime(t time.Time) uint64 {
+	u := uint64(t.UnixNano()) + ntpEpochOffset*uint64(time.Second)
+	seconds := u / uint64(time.Second)
+	fraction := ((u % uint64(time.Second)) << 32) / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+func toTime(ntp uint64) time.Time {
+	seconds := ntp >> 32
+	nanos := ((ntp & 0xFFFFFFFF) * uint64(time.Second)) >> 32
+	return time.Unix(int64(seconds)-ntpEpochOffset, int64(nanos))
+}
@@ -0,0 +1,48 @@
+package rtp
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	audioLevelExtensionSize = 1
+	audioLevelMax           = 127
+)
+
+// AudioLevelExtension is the level of the audio in the packet, and if the sender detected voice in it
+// https://tools.ietf.org/html/rfc6464#section-3
+type AudioLevelExtension struct {
+	// Level is expressed in -dBov, 0 is the loudest and 127 the quietest
+	Level uint8
+	Voice bool
+}
+
+// Marshal serializes the members to buffer
+func (a *AudioLevelExtension) Marshal() ([]byte, error) {
+	if a.Level > audioLevelMax {
+		return nil, errors.Errorf("audio level %d overflows 7 bits", a.Level)
+	}
+
+	raw := a.Level
+	if a.Voice {
+		raw |= 0x80
+	}
+	return []byte{raw}, nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the members
+func (a *AudioLevelExtension) Unmarshal(rawExtension []byte) error {
+	if len(rawExtension) < audioLevelExtensionSize {
+		return errors.Errorf("audio level extension size insufficient; %d < %d", len(rawExtension), audioLevelExtensionSize)
+	}
+
+	/*
+	 *  0 1 2 3 4 5 6 7
+	 * +-+-+-+-+-+-+-+-+
+	 * |V|    level    |
+	 * +-+-+-+-+-+-+-+-+
+	 */
+	a.Level = rawExtension[0] & audioLevelMax
+	a.Voice = rawExtension[0]&0x80 != 0
+	return nil
+}
@@ -0,0 +1,304 @@
+package codecs
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// AV1Payloader payloads AV1 packets
+// https://aomediacodec.github.io/av1-rtp-spec/
+type AV1Payloader struct{}
+
+const (
+	av1AggregationHeaderSize = 1
+
+	av1ZBitmask = 0x80
+	av1YBitmask = 0x40
+	av1WBitmask = 0x30
+	av1NBitmask = 0x08
+
+	obuTypeBitmask         = 0x78
+	obuExtensionBitmask    = 0x04
+	obuHasSizeFieldBitmask = 0x02
+
+	obuTypeSequenceHeader    = 1
+	obuTypeTemporalDelimiter = 2
+	obuTypeTileList          = 8
+)
+
+var av1TemporalDelimiter = []byte{obuTypeTemporalDelimiter<<3 | obuHasSizeFieldBitmask, 0x00}
+
+// Payload fragments an AV1 temporal unit across one or more byte arrays. The temporal unit is
+// expected in the low overhead bitstream format, a sequence of OBUs that carry their size
+func (p *AV1Payloader) Payload(mtu int, payload []byte) [][]byte {
+	/*
+	 * https://aomediacodec.github.io/av1-rtp-spec/#44-av1-aggregation-header
+	 *
+	 *  0 1 2 3 4 5 6 7
+	 * +-+-+-+-+-+-+-+-+
+	 * |Z|Y| W |N|-|-|-|
+	 * +-+-+-+-+-+-+-+-+
+	 *
+	 * Z: the first OBU element is the continuation of an OBU from the previous packet
+	 * Y: the last OBU element continues in the next packet
+	 * W: the number of OBU elements, 0 means every element is preceded by its length
+	 * N: the packet is the first packet of a coded video sequence
+	 */
+
+	// Room for the aggregation header, a length and at least one byte of an OBU
+	if mtu < av1AggregationHeaderSize+2 {
+		return nil
+	}
+
+	obus, hasSequenceHeader, err := av1ParseOBUs(payload)
+	if err != nil || len(obus) == 0 {
+		return nil
+	}
+
+	var payloads [][]byte
+	out := []byte{0}
+	if hasSequenceHeader {
+		out[0] |= av1NBitmask
+	}
+
+	for _, obu := range obus {
+		for len(obu) > 0 {
+			size := av1ElementSize(mtu-len(out), len(obu))
+
+			// The packet is full, the OBU starts in the next one. If not even its length and a byte of it fit in
+			// an empty packet the MTU is too small
+			if size == 0 {
+				if len(out) == av1AggregationHeaderSize {
+					return nil
+				}
+				payloads = append(payloads, out)
+				out = []byte{0}
+				continue
+			}
+
+			out = appendLeb128(out, uint(size))
+			out = append(out, obu[:size]...)
+			obu = obu[size:]
+
+			if len(obu) > 0 {
+				out[0] |= av1YBitmask
+				payloads = append(payloads, out)
+				out = []byte{av1ZBitmask}
+			}
+		}
+	}
+
+	if len(out) > av1AggregationHeaderSize {
+		payloads = append(payloads, out)
+	}
+	return payloads
+}
+
+// av1ParseOBUs splits a temporal unit into OBU elements, OBUs without their size field. Temporal
+// delimiters and tile lists are not sent over RTP and are dropped
+func av1ParseOBUs(payload []byte) (obus [][]byte, hasSequenceHeader bool, err error) {
+	for len(payload) > 0 {
+		headerSize := 1
+		if payload[0]&obuExtensionBitmask != 0 {
+			headerSize++
+		}
+		if len(payload) < headerSize {
+			return nil, false, errors.Errorf("AV1 OBU header is truncated")
+		}
+
+		obuSize := len(payload) - headerSize
+		sizeFieldSize := 0
+		if payload[0]&obuHasSizeFieldBitmask != 0 {
+			size, n, err := readLeb128(payload[headerSize:])
+			if err != nil {
+				return nil, false, err
+			} else if size > uint(len(payload)-headerSize-n) {
+				return nil, false, errors.Errorf("AV1 OBU size %d is larger than the remaining %d bytes", size, len(payload)-headerSize-n)
+			}
+			obuSize, sizeFieldSize = int(size), n
+		}
+
+		obuType := (payload[0] & obuTypeBitmask) >> 3
+		if obuType != obuTypeTemporalDelimiter && obuType != obuTypeTileList {
+			obu := make([]byte, 0, headerSize+obuSize)
+			obu = append(obu, payload[0]&^obuHasSizeFieldBitmask)
+			obu = append(obu, payload[1:headerSize]...)
+			obu = append(obu, payload[headerSize+sizeFieldSize:headerSize+sizeFieldSize+obuSize]...)
+			obus = append(obus, obu)
+		}
+		if obuType == obuTypeSequenceHeader {
+			hasSequenceHeader = true
+		}
+
+		payload = payload[headerSize+sizeFieldSize+obuSize:]
+	}
+	return obus, hasSequenceHeader, nil
+}
+
+// readLeb128 decodes an unsigned LEB128 value, it returns the value and how many bytes it used
+func readLeb128(in []byte) (value uint, n int, err error) {
+	for n = 0; n < len(in) && n < 8; n++ {
+		value |= uint(in[n]&0x7F) << (7 * uint(n))
+		if in[n]&0x80 == 0 {
+			return value, n + 1, nil
+		}
+	}
+	return 0, 0, errors.Errorf("AV1 LEB128 value is truncated")
+}
+
+// av1ElementSize returns how many bytes of an OBU fit in the available bytes of a packet, after their length
+func av1ElementSize(available, obuSize int) int {
+	if obuSize+leb128Size(uint(obuSize)) <= available {
+		return obuSize
+	}
+
+	// The fragment is smaller than the OBU so its length is at most as long, it may be short enough to fit more
+	size := available - leb128Size(uint(obuSize))
+	if size <= 0 {
+		return 0
+	}
+	if larger := available - leb128Size(uint(size)); larger > size && larger+leb128Size(uint(larger)) <= available {
+		size = larger
+	}
+	return size
+}
+
+func leb128Size(value uint) (n int) {
+	for n = 1; value >= 0x80; n++ {
+		value >>= 7
+	}
+	return n
+}
+
+func appendLeb128(out []byte, value uint) []byte {
+	for value >= 0x80 {
+		out = append(out, byte(value&0x7F)|0x80)
+		value >>= 7
+	}
+	return append(out, byte(value))
+}
+
+// AV1Packet represents the AV1 aggregation header that is stored in the payload of an RTP Packet
+// It keeps OBUs that are fragmented across packets until they are complete, so the packets of a
+// temporal unit must be passed to Unmarshal in order
+type AV1Packet struct {
+	// Aggregation header
+	Z bool  // The first OBU element is the continuation of an OBU from the previous packet
+	Y bool  // The last OBU element continues in the next packet
+	W uint8 // Number of OBU elements, 0 if every element is preceded by its length
+	N bool  // First packet of a coded video sequence
+
+	// OBUElements are the OBU elements of the packet, OBUs or fragments of OBUs without their size field
+	OBUElements [][]byte
+
+	// fragment is the start of an OBU that continues in the packet with fragmentSequenceNumber
+	fragment               []byte
+	fragmentSequenceNumber uint16
+
+	hasTimestamp bool
+	timestamp    uint32
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the AV1Packet this method is called upon
+// It returns the complete OBUs of the packet with their size field, starting with a temporal delimiter
+// for the first packet of a temporal unit
+func (p *AV1Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	payload := packet.Payload
+	if len(payload) < av1AggregationHeaderSize {
+		return nil, errors.Errorf("AV1 payload is too short; %d < %d", len(payload), av1AggregationHeaderSize)
+	}
+
+	p.Z = payload[0]&av1ZBitmask != 0
+	p.Y = payload[0]&av1YBitmask != 0
+	p.W = (payload[0] & av1WBitmask) >> 4
+	p.N = payload[0]&av1NBitmask != 0
+
+	p.OBUElements = nil
+	for index := av1AggregationHeaderSize; index < len(payload); {
+		size := uint(len(payload) - index)
+		if p.W == 0 || len(p.OBUElements) < int(p.W)-1 {
+			length, n, err := readLeb128(payload[index:])
+			if err != nil {
+				return nil, err
+			}
+			index += n
+			if length > uint(len(payload)-index) {
+				return nil, errors.Errorf("AV1 OBU element length %d is larger than the remaining %d bytes", length, len(payload)-index)
+			}
+			size = length
+		}
+
+		p.OBUElements = append(p.OBUElements, payload[index:index+int(size)])
+		index += int(size)
+	}
+
+	if p.W != 0 && len(p.OBUElements) != int(p.W) {
+		return nil, errors.Errorf("AV1 aggregation header has %d OBU elements, found %d", p.W, len(p.OBUElements))
+	}
+
+	var out []byte
+	if !p.hasTimestamp || packet.Timestamp != p.timestamp {
+		p.hasTimestamp = true
+		p.timestamp = packet.Timestamp
+		p.fragment = nil
+		out = append(out, av1TemporalDelimiter...)
+	}
+
+	// A fragment is only continued by the next packet, a gap in the sequence numbers means a part of it was lost
+	fragment := p.fragment
+	if packet.SequenceNumber != p.fragmentSequenceNumber {
+		fragment = nil
+	}
+	p.fragment = nil
+
+	for i, obu := range p.OBUElements {
+		if i == 0 && p.Z {
+			if fragment == nil {
+				continue
+			}
+			obu = append(fragment, obu...)
+		}
+
+		if i == len(p.OBUElements)-1 && p.Y {
+			p.fragment = append([]byte{}, obu...)
+			p.fragmentSequenceNumber = packet.SequenceNumber + 1
+			continue
+		}
+
+		out = appendOBU(out, obu)
+	}
+
+	return out, nil
+}
+
+// appendOBU appends an OBU element with its size field, dropping the OBUs that don't belong in a temporal unit
+func appendOBU(out, obu []byte) []byte {
+	if len(obu) == 0 {
+		return out
+	}
+
+	headerSize := 1
+	if obu[0]&obuExtensionBitmask != 0 {
+		headerSize++
+	}
+	if len(obu) < headerSize {
+		return out
+	}
+
+	if obuType := (obu[0] & obuTypeBitmask) >> 3; obuType == obuTypeTemporalDelimiter || obuType == obuTypeTileList {
+		return out
+	}
+
+	out = append(out, obu[0]|obuHasSizeFieldBitmask)
+	out = append(out, obu[1:headerSize]...)
+	out = appendLeb128(out, uint(len(obu)-headerSize))
+	return append(out, obu[headerSize:]...)
+}
+
+// IsPartitionHead checks if this is the first packet of an AV1 temporal unit, one that doesn't continue an OBU
+func (p *AV1Packet) IsPartitionHead(payload []byte) bool {
+	if len(payload) < av1AggregationHeaderSize {
+		return false
+	}
+	return payload[0]&av1ZBitmask == 0
+}
@@ -0,0 +1,124 @@
+package codecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestAV1PayloadRoundTrip(t *testing.T) {
+	temporalUnit := append([]byte{}, av1TemporalDelimiter...)
+	temporalUnit = append(temporalUnit, 0x0A, 0x03, 0x01, 0x02, 0x03) // Sequence header
+	temporalUnit = append(temporalUnit, 0x32, 0x0A)                   // Frame
+	temporalUnit = append(temporalUnit, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19)
+
+	for _, mtu := range []int{3, 8, 1200} {
+		payloads := (&AV1Payloader{}).Payload(mtu, temporalUnit)
+		if len(payloads) == 0 {
+			t.Fatalf("MTU %d: no payloads", mtu)
+		}
+		if payloads[0][0]&av1NBitmask == 0 {
+			t.Errorf("MTU %d: N is not set on a temporal unit with a sequence header", mtu)
+		}
+
+		var out []byte
+		depacketizer := &AV1Packet{}
+		for i, payload := range payloads {
+			if len(payload) > mtu {
+				t.Errorf("MTU %d: payload %d is %d bytes", mtu, i, len(payload))
+			}
+			if depacketizer.IsPartitionHead(payload) != (i == 0 || payloads[i-1][0]&av1YBitmask == 0) {
+				t.Errorf("MTU %d: IsPartitionHead is wrong for payload %d", mtu, i)
+			}
+
+			data, err := depacketizer.Unmarshal(&rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i), Timestamp: 3000}, Payload: payload})
+			if err != nil {
+				t.Fatalf("MTU %d: failed to unmarshal payload %d: %v", mtu, i, err)
+			}
+			out = append(out, data...)
+		}
+
+		if !bytes.Equal(out, temporalUnit) {
+			t.Errorf("MTU %d: rebuilt temporal unit %x does not match %x", mtu, out, temporalUnit)
+		}
+	}
+}
+
+func TestAV1PayloadSmallMTU(t *testing.T) {
+	// A frame OBU of 200 bytes needs a two byte length, which leaves no room for the OBU in a packet of 3 bytes
+	frame := append([]byte{0x32, 0xC8, 0x01}, bytes.Repeat([]byte{0x10}, 200)...)
+	if payloads := (&AV1Payloader{}).Payload(3, frame); payloads != nil {
+		t.Errorf("expected no payloads, got %d", len(payloads))
+	}
+	if payloads := (&AV1Payloader{}).Payload(4, frame); len(payloads) != 101 {
+		t.Errorf("expected a payload for every two bytes of the 201 byte OBU, got %d", len(payloads))
+	}
+}
+
+func TestAV1PacketUnmarshalLostFragment(t *testing.T) {
+	frame := append([]byte{0x32, 0xAC, 0x02}, bytes.Repeat([]byte{0x10}, 300)...)
+	payloads := (&AV1Payloader{}).Payload(100, frame)
+	if len(payloads) != 4 {
+		t.Fatalf("expected the OBU to be fragmented into 4 payloads, got %d", len(payloads))
+	}
+
+	// The OBU is dropped when a fragment in the middle is lost
+	depacketizer := &AV1Packet{}
+	for i, payload := range payloads {
+		if i == 1 {
+			continue
+		}
+		out, err := depacketizer.Unmarshal(&rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i), Timestamp: 3000}, Payload: payload})
+		if err != nil {
+			t.Fatal(err)
+		} else if i != 0 && len(out) != 0 {
+			t.Errorf("expected the OBU with a lost fragment to be dropped, got %d bytes", len(out))
+		}
+	}
+}
+
+func TestAV1PacketUnmarshal(t *testing.T) {
+	depacketizer := &AV1Packet{}
+
+	// W=2, the first element has a length and the second takes the rest
+	out, err := depacketizer.Unmarshal(&rtp.Packet{Payload: []byte{0x20, 0x02, 0x30, 0x01, 0x30, 0x02}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(append([]byte{}, av1TemporalDelimiter...), 0x32, 0x01, 0x01, 0x32, 0x01, 0x02)
+	if !bytes.Equal(out, expected) {
+		t.Errorf("expected %x, got %x", expected, out)
+	}
+
+	// A continuation without its start is dropped
+	out, err = depacketizer.Unmarshal(&rtp.Packet{Payload: []byte{0x80, 0x01, 0x05, 0x02, 0x30, 0x03}})
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(out, []byte{0x32, 0x01, 0x03}) {
+		t.Errorf("expected the fragment to be dropped, got %x", out)
+	}
+
+	for _, payload := range [][]byte{{}, {0x00, 0x05, 0x30}, {0x00, 0x80}, {0x30, 0x01, 0x30}} {
+		if _, err := depacketizer.Unmarshal(&rtp.Packet{Payload: payload}); err == nil {
+			t.Errorf("expected an error unmarshaling %x", payload)
+		}
+	}
+}
+
+func TestAV1ElementSize(t *testing.T) {
+	for _, test := range []struct {
+		available, obuSize, expected int
+	}{
+		{10, 5, 5},
+		{1, 5, 0},
+		{129, 1000, 127},
+		{130, 1000, 128},
+		{16386, 100000, 16383},
+		{16387, 100000, 16384},
+	} {
+		if size := av1ElementSize(test.available, test.obuSize); size != test.expected {
+			t.Errorf("expected %d bytes of a %d byte OBU to fit in %d bytes, got %d", test.expected, test.obuSize, test.available, size)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package codecs
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// G711Payloader payloads G.711 PCMU and PCMA packets
+// https://tools.ietf.org/html/rfc3551#section-4.5.14
+type G711Payloader struct{}
+
+const (
+	// audioClockRate is the RTP clock rate of G.711 and G.722
+	audioClockRate = 8000
+
+	// audioFrameSamples is the default 20ms ptime of G.711 and G.722 at their RTP clock rate
+	// https://tools.ietf.org/html/rfc3551#section-4.5
+	audioFrameSamples = audioClockRate * 20 / 1000
+)
+
+// payloadAudioFrames splits a payload into frames of frameSize bytes, the last one may be shorter
+func payloadAudioFrames(mtu, frameSize int, payload []byte) [][]byte {
+	if mtu < frameSize {
+		frameSize = mtu
+	}
+	if frameSize <= 0 {
+		return nil
+	}
+
+	var payloads [][]byte
+	for len(payload) > 0 {
+		currentFrameSize := min(frameSize, len(payload))
+		out := make([]byte, currentFrameSize)
+		copy(out, payload)
+		payloads = append(payloads, out)
+		payload = payload[currentFrameSize:]
+	}
+	return payloads
+}
+
+// Payload splits G.711 audio into 20ms frames, one byte is one sample at 8000Hz
+func (p *G711Payloader) Payload(mtu int, payload []byte) [][]byte {
+	return payloadAudioFrames(mtu, audioFrameSamples, payload)
+}
+
+// FrameSamples returns the duration of a G.711 frame, every byte is one sample
+func (p *G711Payloader) FrameSamples(payload []byte) uint32 {
+	return uint32(len(payload))
+}
+
+// G711Packet represents a G.711 packet, the payload carries no header
+type G711Packet struct {
+	Payload []byte
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the G711Packet this method is called upon
+func (p *G711Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if len(packet.Payload) == 0 {
+		return nil, errors.Errorf("G711 payload is empty")
+	}
+	p.Payload = packet.Payload
+	return p.Payload, nil
+}
+
+// IsPartitionHead checks if this is the first packet of a G.711 frame, every G.711 packet is a complete frame
+func (p *G711Packet) IsPartitionHead(payload []byte) bool {
+	return true
+}
@@ -0,0 +1,33 @@
+package codecs
+
+import (
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestG711Packetize(t *testing.T) {
+	// 50ms of audio is two full 20ms frames and a 10ms one
+	payload := make([]byte, 400)
+	packetizer := rtp.NewPacketizer(1200, 0, 1234, &G711Payloader{}, rtp.NewRandomSequencer(), 8000)
+
+	packets := packetizer.Packetize(payload, 0)
+	if len(packets) != 3 {
+		t.Fatalf("expected 3 packets, got %d", len(packets))
+	}
+
+	start := packets[0].Timestamp
+	for i, expected := range []struct {
+		size      int
+		timestamp uint32
+	}{{160, 0}, {160, 160}, {80, 320}} {
+		if len(packets[i].Payload) != expected.size || packets[i].Timestamp-start != expected.timestamp {
+			t.Errorf("packet %d has %d bytes at %d, expected %d bytes at %d", i, len(packets[i].Payload), packets[i].Timestamp-start, expected.size, expected.timestamp)
+		}
+	}
+
+	// The timestamp follows the audio that was sent, not the samples given
+	if next := packetizer.Packetize(payload[:160], 320); next[0].Timestamp-start != 400 {
+		t.Errorf("expected the next packet at 400, got %d", next[0].Timestamp-start)
+	}
+}
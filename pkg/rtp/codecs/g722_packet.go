@@ -0,0 +1,41 @@
+package codecs
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// G722Payloader payloads G.722 packets
+// G.722 samples audio at 16000Hz, but its RTP clock rate is 8000Hz for historical reasons, so a
+// byte of G.722, two samples of audio, is one tick of the RTP clock
+// https://tools.ietf.org/html/rfc3551#section-4.5.2
+type G722Payloader struct{}
+
+// Payload splits G.722 audio into 20ms frames
+func (p *G722Payloader) Payload(mtu int, payload []byte) [][]byte {
+	return payloadAudioFrames(mtu, audioFrameSamples, payload)
+}
+
+// FrameSamples returns the duration of a G.722 frame in the 8000Hz RTP clock, every byte is one tick
+func (p *G722Payloader) FrameSamples(payload []byte) uint32 {
+	return uint32(len(payload))
+}
+
+// G722Packet represents a G.722 packet, the payload carries no header
+type G722Packet struct {
+	Payload []byte
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the G722Packet this method is called upon
+func (p *G722Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if len(packet.Payload) == 0 {
+		return nil, errors.Errorf("G722 payload is empty")
+	}
+	p.Payload = packet.Payload
+	return p.Payload, nil
+}
+
+// IsPartitionHead checks if this is the first packet of a G.722 frame, every G.722 packet is a complete frame
+func (p *G722Packet) IsPartitionHead(payload []byte) bool {
+	return true
+}
@@ -1,12 +1,32 @@
 package codecs
 
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
 // H264Payloader payloads H264 packets
 type H264Payloader struct{}
 
 const (
-	fuaHeaderSize = 2
+	spsNALUType   = 7
+	ppsNALUType   = 8
+	stapaNALUType = 24
+	fuaNALUType   = 28
+
+	fuaHeaderSize       = 2
+	stapaHeaderSize     = 1
+	stapaNALULengthSize = 2
+
+	naluTypeBitmask      = 0x1F
+	naluRefIdcBitmask    = 0x60
+	naluForbiddenBitmask = 0x80
+	fuStartBitmask       = 0x80
+	fuEndBitmask         = 0x40
 )
 
+var annexbNALUStartCode = []byte{0x00, 0x00, 0x00, 0x01}
+
 func emitNalus(nals []byte, emit func([]byte)) {
 	nextInd := func(nalu []byte, start int) (indStart int, indLen int) {
 		zeroCount := 0
@@ -43,17 +63,14 @@ func emitNalus(nals []byte, emit func([]byte)) {
 }
 
 // Payload fragments a H264 packet across one or more byte arrays
+// SPS and PPS NAL units are aggregated into a single STAP-A packet
 func (p *H264Payloader) Payload(mtu int, payload []byte) [][]byte {
 
 	var payloads [][]byte
 
-	emitNalus(payload, func(nalu []byte) {
-		naluType := nalu[0] & 0x1F
-		naluRefIdc := nalu[0] & 0x60
-
-		if naluType == 9 || naluType == 12 {
-			return
-		}
+	emitNalu := func(nalu []byte) {
+		naluType := nalu[0] & naluTypeBitmask
+		naluRefIdc := nalu[0] & naluRefIdcBitmask
 
 		// Single NALU
 		if len(nalu) <= mtu {
@@ -92,7 +109,7 @@ func (p *H264Payloader) Payload(mtu int, payload []byte) [][]byte {
 			// +-+-+-+-+-+-+-+-+
 			// |F|NRI|  Type   |
 			// +---------------+
-			out[0] = 28
+			out[0] = fuaNALUType
 			out[0] |= naluRefIdc
 
 			// +---------------+
@@ -116,8 +133,168 @@ func (p *H264Payloader) Payload(mtu int, payload []byte) [][]byte {
 			naluDataRemaining -= currentFragmentSize
 			naluDataIndex += currentFragmentSize
 		}
+	}
+
+	// Parameter sets are held back until the NAL unit that uses them, so they can be sent together
+	var parameterSets [][]byte
+	flushParameterSets := func() {
+		if len(parameterSets) == 0 {
+			return
+		}
+
+		stapaSize := stapaHeaderSize
+		for _, nalu := range parameterSets {
+			stapaSize += stapaNALULengthSize + len(nalu)
+		}
+
+		nalus := parameterSets
+		parameterSets = nil
+		if len(nalus) == 1 || stapaSize > mtu {
+			for _, nalu := range nalus {
+				emitNalu(nalu)
+			}
+			return
+		}
+
+		/*
+		 * https://tools.ietf.org/html/rfc6184#section-5.7.1
+		 *
+		 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 * |STAP-A NAL HDR |         NALU 1 Size           |
+		 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 * |         NALU 1 HDR & Data     |  NALU 2 Size  |
+		 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 * |    NALU 2 Size    |    NALU 2 HDR & Data      |
+		 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 *
+		 * The F bit is set if any aggregated F bit is, and NRI is the highest aggregated NRI
+		 */
+		out := make([]byte, stapaHeaderSize, stapaSize)
+		out[0] = stapaNALUType
+		for _, nalu := range nalus {
+			out[0] |= nalu[0] & naluForbiddenBitmask
+			if nalu[0]&naluRefIdcBitmask > out[0]&naluRefIdcBitmask {
+				out[0] = out[0]&^naluRefIdcBitmask | nalu[0]&naluRefIdcBitmask
+			}
+
+			out = append(out, byte(len(nalu)>>8), byte(len(nalu)))
+			out = append(out, nalu...)
+		}
+		payloads = append(payloads, out)
+	}
+
+	emitNalus(payload, func(nalu []byte) {
+		if len(nalu) == 0 {
+			return
+		}
+
+		naluType := nalu[0] & naluTypeBitmask
+		if naluType == 9 || naluType == 12 {
+			return
+		}
 
+		if naluType == spsNALUType || naluType == ppsNALUType {
+			out := make([]byte, len(nalu))
+			copy(out, nalu)
+			parameterSets = append(parameterSets, out)
+			return
+		}
+
+		flushParameterSets()
+		emitNalu(nalu)
 	})
+	flushParameterSets()
 
 	return payloads
 }
+
+// H264Packet represents the H264 header that is stored in the payload of an RTP Packet
+// It keeps the fragments of a FU-A NAL unit until the last one arrives, so the packets of a frame
+// must be passed to Unmarshal in order
+type H264Packet struct {
+	fuaBuffer []byte
+
+	// fuaSequenceNumber is the sequence number the next fragment of fuaBuffer is expected with
+	fuaSequenceNumber uint16
+}
+
+// Unmarshal parses the passed byte slice and returns the NAL units it carries in Annex-B format.
+// STAP-A packets are split into their NAL units, and FU-A fragments are reassembled, nothing is
+// returned until the last fragment of a NAL unit. A NAL unit with lost fragments, a gap in the sequence numbers
+// of its fragments, is dropped
+// https://tools.ietf.org/html/rfc6184#section-5.2
+func (p *H264Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	payload := packet.Payload
+	if len(payload) < 1 {
+		return nil, errors.Errorf("H264 payload is empty")
+	}
+
+	naluType := payload[0] & naluTypeBitmask
+	if naluType != fuaNALUType {
+		p.fuaBuffer = nil
+	}
+
+	switch {
+	case naluType > 0 && naluType < stapaNALUType:
+		return append(append([]byte{}, annexbNALUStartCode...), payload...), nil
+
+	case naluType == stapaNALUType:
+		var out []byte
+		currOffset := stapaHeaderSize
+		for currOffset < len(payload) {
+			if currOffset+stapaNALULengthSize > len(payload) {
+				return nil, errors.Errorf("STAP-A declared size is larger than buffer")
+			}
+			naluSize := int(payload[currOffset])<<8 | int(payload[currOffset+1])
+			currOffset += stapaNALULengthSize
+
+			if currOffset+naluSize > len(payload) {
+				return nil, errors.Errorf("STAP-A declared size(%d) is larger than buffer(%d)", naluSize, len(payload)-currOffset)
+			}
+			out = append(out, annexbNALUStartCode...)
+			out = append(out, payload[currOffset:currOffset+naluSize]...)
+			currOffset += naluSize
+		}
+		return out, nil
+
+	case naluType == fuaNALUType:
+		if len(payload) < fuaHeaderSize {
+			return nil, errors.Errorf("FU-A payload is too short; %d < %d", len(payload), fuaHeaderSize)
+		}
+
+		if payload[1]&fuStartBitmask != 0 {
+			// Rebuild the NAL header from the F and NRI bits of the FU indicator and the type of the FU header
+			naluHeader := (payload[0] &^ naluTypeBitmask) | (payload[1] & naluTypeBitmask)
+			p.fuaBuffer = append(append([]byte{}, annexbNALUStartCode...), naluHeader)
+		} else if p.fuaBuffer == nil || packet.SequenceNumber != p.fuaSequenceNumber {
+			// The start of this NAL unit or a fragment before this one was lost
+			p.fuaBuffer = nil
+			return []byte{}, nil
+		}
+		p.fuaBuffer = append(p.fuaBuffer, payload[fuaHeaderSize:]...)
+		p.fuaSequenceNumber = packet.SequenceNumber + 1
+
+		if payload[1]&fuEndBitmask == 0 {
+			return []byte{}, nil
+		}
+
+		out := p.fuaBuffer
+		p.fuaBuffer = nil
+		return out, nil
+	}
+
+	return nil, errors.Errorf("H264 NAL unit type %d is not handled", naluType)
+}
+
+// IsPartitionHead checks if this payload starts a NAL unit, every NAL unit except a continued
+// fragmentation unit can start a frame
+func (p *H264Packet) IsPartitionHead(payload []byte) bool {
+	if len(payload) < fuaHeaderSize {
+		return len(payload) != 0
+	}
+
+	if payload[0]&naluTypeBitmask == fuaNALUType {
+		return payload[1]&fuStartBitmask != 0
+	}
+	return true
+}
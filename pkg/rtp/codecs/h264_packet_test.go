@@ -0,0 +1,89 @@
+package codecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestH264PayloadRoundTrip(t *testing.T) {
+	sps := []byte{0x67, 0x42, 0xC0, 0x1F, 0xDA}
+	pps := []byte{0x68, 0xCE, 0x3C, 0x80}
+	idr := []byte{0x65, 0x88, 0x84, 0x00, 0x33, 0xFF, 0x2A, 0x61, 0x0E, 0x7C, 0x91, 0x05, 0x3B, 0xD2, 0x47, 0x19, 0xA0, 0x5E, 0x03, 0xB8, 0x6D, 0x22, 0xF1, 0x94, 0x0C, 0x7A, 0x35, 0xE6, 0x58, 0x1B}
+
+	var frame []byte
+	for _, nalu := range [][]byte{sps, pps, idr} {
+		frame = append(frame, annexbNALUStartCode...)
+		frame = append(frame, nalu...)
+	}
+
+	payloads := (&H264Payloader{}).Payload(16, frame)
+	if len(payloads) < 3 {
+		t.Fatalf("expected a STAP-A and FU-As, got %d payloads", len(payloads))
+	}
+	if payloads[0][0] != stapaNALUType|0x60 {
+		t.Errorf("SPS and PPS were not aggregated into a STAP-A, got %x", payloads[0])
+	}
+
+	var out []byte
+	depacketizer := &H264Packet{}
+	for i, payload := range payloads {
+		if len(payload) > 16 {
+			t.Errorf("payload %d is %d bytes", i, len(payload))
+		}
+		if depacketizer.IsPartitionHead(payload) != (i < 2) {
+			t.Errorf("IsPartitionHead is wrong for payload %d", i)
+		}
+
+		data, err := depacketizer.Unmarshal(&rtp.Packet{Header: rtp.Header{SequenceNumber: uint16(i)}, Payload: payload})
+		if err != nil {
+			t.Fatalf("failed to unmarshal payload %d: %v", i, err)
+		}
+		if i != 0 && i != len(payloads)-1 && len(data) != 0 {
+			t.Errorf("a FU-A fragment was returned before the NAL unit was complete")
+		}
+		out = append(out, data...)
+	}
+
+	if !bytes.Equal(out, frame) {
+		t.Errorf("depacketized frame %x does not match %x", out, frame)
+	}
+}
+
+func TestH264PacketUnmarshalLostFragment(t *testing.T) {
+	depacketizer := &H264Packet{}
+
+	// A FU-A without its start fragment is dropped
+	if out, err := depacketizer.Unmarshal(&rtp.Packet{Payload: []byte{0x7C, 0x45, 0x01, 0x02}}); err != nil || len(out) != 0 {
+		t.Errorf("expected nothing for a continued fragment, got %x %v", out, err)
+	}
+
+	// The start of a NAL unit is dropped when a different NAL unit follows it
+	if _, err := depacketizer.Unmarshal(&rtp.Packet{Payload: []byte{0x7C, 0x85, 0x01}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := depacketizer.Unmarshal(&rtp.Packet{Payload: []byte{0x61, 0x02}}); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := depacketizer.Unmarshal(&rtp.Packet{Payload: []byte{0x7C, 0x45, 0x03}}); err != nil || len(out) != 0 {
+		t.Errorf("expected the incomplete NAL unit to be dropped, got %x %v", out, err)
+	}
+
+	// A NAL unit is dropped when a fragment in the middle is lost
+	for _, p := range []*rtp.Packet{
+		{Header: rtp.Header{SequenceNumber: 65535}, Payload: []byte{0x7C, 0x85, 0x01}},
+		{Header: rtp.Header{SequenceNumber: 0}, Payload: []byte{0x7C, 0x05, 0x02}},
+		{Header: rtp.Header{SequenceNumber: 2}, Payload: []byte{0x7C, 0x45, 0x04}},
+	} {
+		if out, err := depacketizer.Unmarshal(p); err != nil || len(out) != 0 {
+			t.Errorf("expected the NAL unit with a lost fragment to be dropped, got %x %v", out, err)
+		}
+	}
+
+	for _, payload := range [][]byte{{}, {0x1C}, {0x18, 0x00}, {0x18, 0x00, 0x05, 0x67}} {
+		if _, err := depacketizer.Unmarshal(&rtp.Packet{Payload: payload}); err == nil {
+			t.Errorf("expected an error unmarshaling %x", payload)
+		}
+	}
+}
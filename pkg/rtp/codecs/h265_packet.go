@@ -0,0 +1,227 @@
+package codecs
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// H265Payloader payloads H265 packets
+// https://tools.ietf.org/html/rfc7798
+type H265Payloader struct{}
+
+const (
+	h265APNALUType = 48
+	h265FUNALUType = 49
+
+	h265AUDNALUType = 35
+	h265FDNALUType  = 38
+
+	h265NALUHeaderSize     = 2
+	h265FUHeaderSize       = 1
+	h265APNALULengthSize   = 2
+	h265NALUTypeBitmask    = 0x7E
+	h265NALULayerIDBitmask = 0x01F8
+	h265NALUTIDBitmask     = 0x07
+	h265FUStartBitmask     = 0x80
+	h265FUEndBitmask       = 0x40
+	h265FUTypeBitmask      = 0x3F
+)
+
+func h265NALUType(nalu []byte) uint8 {
+	return (nalu[0] & h265NALUTypeBitmask) >> 1
+}
+
+// Payload fragments a H265 packet across one or more byte arrays
+func (p *H265Payloader) Payload(mtu int, payload []byte) [][]byte {
+	/*
+	 * https://tools.ietf.org/html/rfc7798#section-1.1.4
+	 *
+	 * +---------------+---------------+
+	 * |0|1|2|3|4|5|6|7|0|1|2|3|4|5|6|7|
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |F|   Type    |  LayerId  | TID |
+	 * +-------------+-----------------+
+	 */
+
+	// Room for the payload header, the FU header and at least one byte of a fragment
+	if mtu < h265NALUHeaderSize+h265FUHeaderSize+1 {
+		return nil
+	}
+
+	var payloads [][]byte
+
+	// Small NAL units are held back, and sent together in an aggregation packet if more follow that fit
+	var aggregated [][]byte
+	aggregatedSize := h265NALUHeaderSize
+	flushAggregated := func() {
+		switch len(aggregated) {
+		case 0:
+			return
+		case 1:
+			payloads = append(payloads, aggregated[0])
+		default:
+			payloads = append(payloads, h265AggregationPacket(aggregated, aggregatedSize))
+		}
+		aggregated = nil
+		aggregatedSize = h265NALUHeaderSize
+	}
+
+	emitNalus(payload, func(nalu []byte) {
+		if len(nalu) < h265NALUHeaderSize {
+			return
+		}
+
+		naluType := h265NALUType(nalu)
+		if naluType == h265AUDNALUType || naluType == h265FDNALUType {
+			return
+		}
+
+		// Single NALU, possibly aggregated
+		if len(nalu) <= mtu {
+			if aggregatedSize+h265APNALULengthSize+len(nalu) > mtu {
+				flushAggregated()
+			}
+
+			out := make([]byte, len(nalu))
+			copy(out, nalu)
+			aggregated = append(aggregated, out)
+			aggregatedSize += h265APNALULengthSize + len(nalu)
+			return
+		}
+		flushAggregated()
+
+		// FU
+		maxFragmentSize := mtu - h265NALUHeaderSize - h265FUHeaderSize
+
+		// The NAL unit header of the fragmented NAL unit is not included in the FU payload, the
+		// payload header carries its F, LayerId and TID, and the FU header carries its type
+		naluDataIndex := h265NALUHeaderSize
+		naluDataLength := len(nalu) - naluDataIndex
+		naluDataRemaining := naluDataLength
+
+		for naluDataRemaining > 0 {
+			currentFragmentSize := min(maxFragmentSize, naluDataRemaining)
+			out := make([]byte, h265NALUHeaderSize+h265FUHeaderSize+currentFragmentSize)
+
+			out[0] = (nalu[0] &^ h265NALUTypeBitmask) | h265FUNALUType<<1
+			out[1] = nalu[1]
+
+			// +---------------+
+			// |0|1|2|3|4|5|6|7|
+			// +-+-+-+-+-+-+-+-+
+			// |S|E|  FuType   |
+			// +---------------+
+			out[2] = naluType
+			if naluDataRemaining == naluDataLength {
+				out[2] |= h265FUStartBitmask
+			} else if naluDataRemaining-currentFragmentSize == 0 {
+				out[2] |= h265FUEndBitmask
+			}
+
+			copy(out[h265NALUHeaderSize+h265FUHeaderSize:], nalu[naluDataIndex:naluDataIndex+currentFragmentSize])
+			payloads = append(payloads, out)
+
+			naluDataRemaining -= currentFragmentSize
+			naluDataIndex += currentFragmentSize
+		}
+	})
+	flushAggregated()
+
+	return payloads
+}
+
+// h265AggregationPacket builds an AP, the payload header uses the lowest LayerId and TID of the aggregated NAL units
+// https://tools.ietf.org/html/rfc7798#section-4.4.2
+func h265AggregationPacket(nalus [][]byte, size int) []byte {
+	out := make([]byte, h265NALUHeaderSize, size)
+
+	var forbidden uint8
+	layerID := uint16(h265NALULayerIDBitmask)
+	tid := uint8(h265NALUTIDBitmask)
+	for _, nalu := range nalus {
+		forbidden |= nalu[0] & 0x80
+		if l := (uint16(nalu[0])<<8 | uint16(nalu[1])) & h265NALULayerIDBitmask; l < layerID {
+			layerID = l
+		}
+		if t := nalu[1] & h265NALUTIDBitmask; t < tid {
+			tid = t
+		}
+
+		out = append(out, byte(len(nalu)>>8), byte(len(nalu)))
+		out = append(out, nalu...)
+	}
+
+	out[0] = forbidden | h265APNALUType<<1 | byte(layerID>>8)
+	out[1] = byte(layerID) | tid
+	return out
+}
+
+// H265Packet represents the H265 header that is stored in the payload of an RTP Packet
+type H265Packet struct{}
+
+// Unmarshal parses the passed byte slice and returns the NAL units it carries in Annex-B format.
+// Fragmentation units return the fragment only, with the start code and reconstructed NAL header
+// prepended to the first one, so the payloads of a frame can be concatenated
+// https://tools.ietf.org/html/rfc7798#section-4.4
+func (p *H265Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	payload := packet.Payload
+	if len(payload) < h265NALUHeaderSize {
+		return nil, errors.Errorf("H265 payload is too short; %d < %d", len(payload), h265NALUHeaderSize)
+	}
+
+	naluType := h265NALUType(payload)
+	switch {
+	case naluType < h265APNALUType:
+		return append(append([]byte{}, annexbNALUStartCode...), payload...), nil
+
+	case naluType == h265APNALUType:
+		var out []byte
+		currOffset := h265NALUHeaderSize
+		for currOffset < len(payload) {
+			if currOffset+h265APNALULengthSize > len(payload) {
+				return nil, errors.Errorf("AP declared size is larger than buffer")
+			}
+			naluSize := int(payload[currOffset])<<8 | int(payload[currOffset+1])
+			currOffset += h265APNALULengthSize
+
+			if currOffset+naluSize > len(payload) {
+				return nil, errors.Errorf("AP declared size(%d) is larger than buffer(%d)", naluSize, len(payload)-currOffset)
+			}
+			out = append(out, annexbNALUStartCode...)
+			out = append(out, payload[currOffset:currOffset+naluSize]...)
+			currOffset += naluSize
+		}
+		return out, nil
+
+	case naluType == h265FUNALUType:
+		if len(payload) < h265NALUHeaderSize+h265FUHeaderSize {
+			return nil, errors.Errorf("FU payload is too short; %d < %d", len(payload), h265NALUHeaderSize+h265FUHeaderSize)
+		}
+
+		if payload[2]&h265FUStartBitmask == 0 {
+			return append([]byte{}, payload[h265NALUHeaderSize+h265FUHeaderSize:]...), nil
+		}
+
+		// Rebuild the NAL header from the F, LayerId and TID of the payload header and the type of the FU header
+		out := append(append([]byte{}, annexbNALUStartCode...),
+			(payload[0]&^h265NALUTypeBitmask)|(payload[2]&h265FUTypeBitmask)<<1,
+			payload[1],
+		)
+		return append(out, payload[h265NALUHeaderSize+h265FUHeaderSize:]...), nil
+	}
+
+	return nil, errors.Errorf("H265 NAL unit type %d is not handled", naluType)
+}
+
+// IsPartitionHead checks if this payload starts a NAL unit, every NAL unit except a continued
+// fragmentation unit can start a frame
+func (p *H265Packet) IsPartitionHead(payload []byte) bool {
+	if len(payload) < h265NALUHeaderSize+h265FUHeaderSize {
+		return len(payload) >= h265NALUHeaderSize
+	}
+
+	if h265NALUType(payload) == h265FUNALUType {
+		return payload[2]&h265FUStartBitmask != 0
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package codecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestH265PayloadRoundTrip(t *testing.T) {
+	vps := []byte{0x40, 0x01, 0x0C, 0x01}
+	sps := []byte{0x42, 0x01, 0x01, 0x01, 0x60}
+	pps := []byte{0x44, 0x01, 0xC1, 0x72}
+	idr := []byte{0x26, 0x01, 0xAF, 0x06, 0xB8, 0x63, 0xEF, 0x3A, 0x7F, 0x3E, 0x53, 0xE8, 0x8A, 0x10, 0x23, 0x5D, 0x16, 0xB1, 0x0F, 0xA9, 0x04, 0xC2, 0x7E, 0x55, 0x81, 0x3D, 0xE0, 0x94, 0x4B, 0x01}
+
+	var frame []byte
+	for _, nalu := range [][]byte{vps, sps, pps, idr} {
+		frame = append(frame, annexbNALUStartCode...)
+		frame = append(frame, nalu...)
+	}
+
+	payloads := (&H265Payloader{}).Payload(22, frame)
+	if len(payloads) != 3 {
+		t.Fatalf("expected an AP and two FUs, got %d payloads", len(payloads))
+	}
+	if h265NALUType(payloads[0]) != h265APNALUType || h265NALUType(payloads[1]) != h265FUNALUType {
+		t.Fatalf("unexpected payload types %d %d", h265NALUType(payloads[0]), h265NALUType(payloads[1]))
+	}
+
+	var out []byte
+	depacketizer := &H265Packet{}
+	for i, payload := range payloads {
+		if len(payload) > 22 {
+			t.Errorf("payload %d is %d bytes", i, len(payload))
+		}
+		if depacketizer.IsPartitionHead(payload) != (i != 2) {
+			t.Errorf("IsPartitionHead is wrong for payload %d", i)
+		}
+
+		data, err := depacketizer.Unmarshal(&rtp.Packet{Payload: payload})
+		if err != nil {
+			t.Fatalf("failed to unmarshal payload %d: %v", i, err)
+		}
+		out = append(out, data...)
+	}
+
+	if !bytes.Equal(out, frame) {
+		t.Errorf("depacketized frame %x does not match %x", out, frame)
+	}
+}
+
+func TestH265PayloadSmallMTU(t *testing.T) {
+	frame := append(append([]byte{}, annexbNALUStartCode...), 0x26, 0x01, 0xAF, 0x06, 0xB8)
+	for _, mtu := range []int{0, 1, 3} {
+		if payloads := (&H265Payloader{}).Payload(mtu, frame); payloads != nil {
+			t.Errorf("MTU %d: expected no payloads, got %d", mtu, len(payloads))
+		}
+	}
+	if payloads := (&H265Payloader{}).Payload(4, frame); len(payloads) != 3 {
+		t.Errorf("MTU 4: expected a FU for every byte, got %d payloads", len(payloads))
+	}
+}
+
+func TestH265PacketUnmarshalShort(t *testing.T) {
+	for _, payload := range [][]byte{
+		{0x40},
+		{0x60, 0x01, 0x00},
+		{0x60, 0x01, 0x00, 0x05, 0x40},
+		{0x62, 0x01},
+		{0x64, 0x01, 0x00},
+	} {
+		if _, err := (&H265Packet{}).Unmarshal(&rtp.Packet{Payload: payload}); err == nil {
+			t.Errorf("expected an error unmarshaling %x", payload)
+		}
+	}
+}
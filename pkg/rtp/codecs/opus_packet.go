@@ -1,6 +1,9 @@
 package codecs
 
-import "github.com/pions/webrtc/pkg/rtp"
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
 
 // OpusPayloader payloads Opus packets
 type OpusPayloader struct{}
@@ -10,13 +13,51 @@ func (p *OpusPayloader) Payload(mtu int, payload []byte) [][]byte {
 	return [][]byte{payload}
 }
 
-// OpusPacket represents the VP8 header that is stored in the payload of an RTP Packet
+// OpusPacket represents the Opus header that is stored in the payload of an RTP Packet
 type OpusPacket struct {
 	Payload []byte
 }
 
 // Unmarshal parses the passed byte slice and stores the result in the OpusPacket this method is called upon
-func (p *OpusPacket) Unmarshal(packet *rtp.Packet) error {
+func (p *OpusPacket) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if len(packet.Payload) == 0 {
+		return nil, errors.Errorf("Opus payload is empty")
+	}
 	p.Payload = packet.Payload
-	return nil
+	return p.Payload, nil
+}
+
+// IsPartitionHead checks if this is the first packet of an Opus frame, every Opus packet is a complete frame
+func (p *OpusPacket) IsPartitionHead(payload []byte) bool {
+	return true
+}
+
+// opusPacketSamples returns the duration of an Opus packet at 48000Hz, from the configuration and frame count of its TOC byte
+// https://tools.ietf.org/html/rfc6716#section-3.1
+func opusPacketSamples(payload []byte) (samples uint32, ok bool) {
+	if len(payload) < 1 {
+		return 0, false
+	}
+
+	var frameSamples uint32
+	switch config := payload[0] >> 3; {
+	case config < 12: // SILK, 10, 20, 40 or 60ms
+		frameSamples = []uint32{480, 960, 1920, 2880}[config%4]
+	case config < 16: // Hybrid, 10 or 20ms
+		frameSamples = []uint32{480, 960}[config%2]
+	default: // CELT, 2.5, 5, 10 or 20ms
+		frameSamples = []uint32{120, 240, 480, 960}[config%4]
+	}
+
+	switch payload[0] & 0x03 {
+	case 0:
+		return frameSamples, true
+	case 1, 2:
+		return 2 * frameSamples, true
+	default:
+		if len(payload) < 2 {
+			return 0, false
+		}
+		return uint32(payload[1]&0x3F) * frameSamples, true
+	}
 }
@@ -0,0 +1,278 @@
+package codecs
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// REDPayloader payloads Opus frames as redundant audio data, every frame is sent with the frames before it
+// https://tools.ietf.org/html/rfc2198
+type REDPayloader struct {
+	// PrimaryPayloadType is the payload type of the Opus frames inside the RED blocks
+	PrimaryPayloadType uint8
+
+	// Distance is how many previous frames are sent with every frame
+	Distance int
+
+	history []redFrame
+}
+
+type redFrame struct {
+	payload []byte
+	samples uint32
+}
+
+const (
+	redHeaderSize        = 4
+	redPrimaryHeaderSize = 1
+
+	redFollowsBitmask      = 0x80
+	redPayloadTypeBitmask  = 0x7F
+	redMaxTimestampOffset  = 0x3FFF
+	redMaxBlockLength      = 0x3FF
+	redTimestampOffsetBits = 10
+)
+
+// Payload wraps an Opus frame and the previous frames in a single RED payload, previous frames are left out
+// if they don't fit in the MTU
+func (p *REDPayloader) Payload(mtu int, payload []byte) [][]byte {
+	/*
+	 * https://tools.ietf.org/html/rfc2198#section-3
+	 *
+	 *  0                   1                    2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |F|   block PT  |  timestamp offset         |   block length    |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *
+	 *  0 1 2 3 4 5 6 7
+	 * +-+-+-+-+-+-+-+-+
+	 * |0|   Block PT  |
+	 * +-+-+-+-+-+-+-+-+
+	 *
+	 * The headers of all blocks come first, then their data, oldest first and the primary last
+	 */
+	if len(payload) == 0 {
+		return nil
+	}
+
+	// The timestamp offset of a previous frame is the duration of all frames after it
+	offsets := make([]uint32, len(p.history))
+	var offset uint32
+	for i := len(p.history) - 1; i >= 0; i-- {
+		offset += p.history[i].samples
+		offsets[i] = offset
+	}
+
+	first := 0
+	size := redPrimaryHeaderSize + len(payload)
+	for i, frame := range p.history {
+		size += redHeaderSize + len(frame.payload)
+		if offsets[i] > redMaxTimestampOffset || len(frame.payload) > redMaxBlockLength {
+			first = i + 1
+			size = redPrimaryHeaderSize + len(payload)
+		}
+	}
+	for ; first < len(p.history) && size > mtu; first++ {
+		size -= redHeaderSize + len(p.history[first].payload)
+	}
+
+	out := make([]byte, 0, size)
+	for i := first; i < len(p.history); i++ {
+		header := offsets[i]<<redTimestampOffsetBits | uint32(len(p.history[i].payload))
+		out = append(out, redFollowsBitmask|p.PrimaryPayloadType, byte(header>>16), byte(header>>8), byte(header))
+	}
+	out = append(out, p.PrimaryPayloadType&redPayloadTypeBitmask)
+	for i := first; i < len(p.history); i++ {
+		out = append(out, p.history[i].payload...)
+	}
+	out = append(out, payload...)
+
+	// Frames of unknown duration can't be given a timestamp offset, so the history starts over
+	if samples, ok := opusPacketSamples(payload); ok {
+		p.history = append(p.history, redFrame{payload: append([]byte{}, payload...), samples: samples})
+	} else {
+		p.history = nil
+	}
+	if len(p.history) > p.Distance {
+		p.history = p.history[len(p.history)-p.Distance:]
+	}
+
+	return [][]byte{out}
+}
+
+// REDBlock is a single encoding carried in a RED payload
+type REDBlock struct {
+	PayloadType     uint8
+	TimestampOffset uint16
+	Payload         []byte
+}
+
+// REDPacket represents the RED blocks that are stored in the payload of an RTP Packet, the last block is the primary encoding
+type REDPacket struct {
+	Blocks []REDBlock
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the REDPacket this method is called upon
+// It returns the payload of the primary encoding
+func (p *REDPacket) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	payload := packet.Payload
+
+	p.Blocks = nil
+	payloadIndex := 0
+	dataSize := 0
+	for {
+		if len(payload) <= payloadIndex {
+			return nil, errors.Errorf("RED payload is too short to contain its block headers")
+		}
+
+		if payload[payloadIndex]&redFollowsBitmask == 0 {
+			p.Blocks = append(p.Blocks, REDBlock{PayloadType: payload[payloadIndex] & redPayloadTypeBitmask})
+			payloadIndex += redPrimaryHeaderSize
+			break
+		}
+
+		if len(payload) < payloadIndex+redHeaderSize {
+			return nil, errors.Errorf("RED payload is too short to contain its block headers")
+		}
+		header := uint32(payload[payloadIndex+1])<<16 | uint32(payload[payloadIndex+2])<<8 | uint32(payload[payloadIndex+3])
+		length := int(header & redMaxBlockLength)
+		p.Blocks = append(p.Blocks, REDBlock{
+			PayloadType:     payload[payloadIndex] & redPayloadTypeBitmask,
+			TimestampOffset: uint16(header >> redTimestampOffsetBits),
+			Payload:         make([]byte, length),
+		})
+		dataSize += length
+		payloadIndex += redHeaderSize
+	}
+
+	if len(payload) < payloadIndex+dataSize {
+		return nil, errors.Errorf("RED block lengths(%d) are larger than buffer(%d)", dataSize, len(payload)-payloadIndex)
+	}
+	for i := range p.Blocks[:len(p.Blocks)-1] {
+		p.Blocks[i].Payload = payload[payloadIndex : payloadIndex+len(p.Blocks[i].Payload)]
+		payloadIndex += len(p.Blocks[i].Payload)
+	}
+	p.Blocks[len(p.Blocks)-1].Payload = payload[payloadIndex:]
+
+	return p.Blocks[len(p.Blocks)-1].Payload, nil
+}
+
+// IsPartitionHead checks if this is the first packet of a RED frame, every RED packet is a complete frame
+func (p *REDPacket) IsPartitionHead(payload []byte) bool {
+	return true
+}
+
+// redReceivedWindow is how many sequence numbers a REDDecoder remembers receiving
+const redReceivedWindow = 64
+
+// REDDecoder unwraps RED packets of a single SSRC into packets of their primary encoding, and recovers
+// lost packets from their redundant blocks. A redundant block repeats a lost packet if no packet with its
+// timestamp was received, as packets of other payload types like telephone events share the sequence numbers.
+// The recovered packet gets the sequence number of its position, one per sequence number before the packet
+type REDDecoder struct {
+	started    bool
+	highestSeq uint16
+
+	// received has a bit set for every sequence number received, bit 0 is highestSeq
+	received uint64
+
+	// timestamps are the timestamps of the received sequence numbers, at their sequence number modulo
+	// redReceivedWindow
+	timestamps [redReceivedWindow]uint32
+}
+
+func (d *REDDecoder) isReceived(seq uint16) bool {
+	if !d.started {
+		return false
+	}
+
+	delta := int16(d.highestSeq - seq)
+	switch {
+	case delta < 0:
+		return false
+	case delta >= redReceivedWindow:
+		// Too old to be worth recovering
+		return true
+	default:
+		return d.received&(1<<uint(delta)) != 0
+	}
+}
+
+func (d *REDDecoder) isTimestampReceived(timestamp uint32) bool {
+	for delta := uint16(0); delta < redReceivedWindow; delta++ {
+		if d.received&(1<<delta) != 0 && d.timestamps[(d.highestSeq-delta)%redReceivedWindow] == timestamp {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *REDDecoder) markReceived(seq uint16, timestamp uint32) {
+	if !d.started {
+		d.started = true
+		d.highestSeq = seq
+		d.received = 1
+	} else if delta := int16(seq - d.highestSeq); delta > 0 {
+		if delta >= redReceivedWindow {
+			d.received = 0
+		} else {
+			d.received <<= uint(delta)
+		}
+		d.received |= 1
+		d.highestSeq = seq
+	} else if -delta < redReceivedWindow {
+		d.received |= 1 << uint(-delta)
+	} else {
+		return
+	}
+	d.timestamps[seq%redReceivedWindow] = timestamp
+}
+
+// Decode unwraps a RED packet, it returns the lost packets it recovered followed by its primary
+// encoding, in sequence number order. Packets that were already received are not returned again
+func (d *REDDecoder) Decode(packet *rtp.Packet) ([]*rtp.Packet, error) {
+	red := &REDPacket{}
+	if _, err := red.Unmarshal(packet); err != nil {
+		return nil, err
+	}
+
+	newPacket := func(block REDBlock, seq uint16, marker bool) (*rtp.Packet, error) {
+		p := &rtp.Packet{Header: packet.Header, Payload: block.Payload}
+		p.PayloadType = block.PayloadType
+		p.SequenceNumber = seq
+		p.Timestamp -= uint32(block.TimestampOffset)
+		p.Marker = marker
+		if _, err := p.Marshal(); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	var packets []*rtp.Packet
+	redundant := red.Blocks[:len(red.Blocks)-1]
+	for i, block := range redundant {
+		seq := packet.SequenceNumber - uint16(len(redundant)-i)
+		// Nothing before the first packet is recovered
+		if !d.started || d.isReceived(seq) || d.isTimestampReceived(packet.Timestamp-uint32(block.TimestampOffset)) {
+			continue
+		}
+
+		p, err := newPacket(block, seq, false)
+		if err != nil {
+			return nil, err
+		}
+		d.markReceived(seq, p.Timestamp)
+		packets = append(packets, p)
+	}
+
+	if d.isReceived(packet.SequenceNumber) {
+		return packets, nil
+	}
+	p, err := newPacket(red.Blocks[len(red.Blocks)-1], packet.SequenceNumber, packet.Marker)
+	if err != nil {
+		return nil, err
+	}
+	d.markReceived(packet.SequenceNumber, p.Timestamp)
+	return append(packets, p), nil
+}
@@ -0,0 +1,99 @@
+package codecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestREDRecovery(t *testing.T) {
+	// 20ms CELT frames, 960 samples each
+	frames := [][]byte{{0xF8, 0x01}, {0xF8, 0x02, 0x02}, {0xF8, 0x03}, {0xF8, 0x04, 0x04, 0x04}}
+	payloader := &REDPayloader{PrimaryPayloadType: 111, Distance: 2}
+
+	var packets []*rtp.Packet
+	for i, frame := range frames {
+		payloads := payloader.Payload(1200, frame)
+		if len(payloads) != 1 {
+			t.Fatalf("expected a single RED payload, got %d", len(payloads))
+		}
+		packets = append(packets, &rtp.Packet{
+			Header:  rtp.Header{PayloadType: 63, SequenceNumber: uint16(100 + i), Timestamp: uint32(5000 + 960*i)},
+			Payload: payloads[0],
+		})
+	}
+
+	red := &REDPacket{}
+	if _, err := red.Unmarshal(packets[3]); err != nil {
+		t.Fatal(err)
+	}
+	if len(red.Blocks) != 3 || red.Blocks[0].TimestampOffset != 1920 || red.Blocks[1].TimestampOffset != 960 {
+		t.Fatalf("unexpected RED blocks %+v", red.Blocks)
+	}
+
+	// Packets 101 and 102 are lost, and recovered from 103
+	decoder := &REDDecoder{}
+	var received []*rtp.Packet
+	for _, i := range []int{0, 3} {
+		decoded, err := decoder.Decode(packets[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, decoded...)
+	}
+
+	if len(received) != len(frames) {
+		t.Fatalf("expected %d packets, got %d", len(frames), len(received))
+	}
+	for i, p := range received {
+		if p.SequenceNumber != uint16(100+i) || p.Timestamp != uint32(5000+960*i) || p.PayloadType != 111 || !bytes.Equal(p.Payload, frames[i]) {
+			t.Errorf("packet %d was not recovered correctly, got seq %d ts %d pt %d payload %x", i, p.SequenceNumber, p.Timestamp, p.PayloadType, p.Payload)
+		}
+	}
+
+	// A packet that was recovered isn't returned again when it arrives late
+	if decoded, err := decoder.Decode(packets[2]); err != nil || len(decoded) != 0 {
+		t.Errorf("expected nothing for a recovered packet, got %d packets %v", len(decoded), err)
+	}
+}
+
+func TestREDRecoveryTelephoneEvent(t *testing.T) {
+	frames := [][]byte{{0xF8, 0x01}, {0xF8, 0x02}}
+	payloader := &REDPayloader{PrimaryPayloadType: 111, Distance: 1}
+
+	// A telephone event with sequence number 11 is sent between the RED packets, it isn't given to the decoder
+	decoder := &REDDecoder{}
+	var received []*rtp.Packet
+	for i, seq := range []uint16{10, 12} {
+		payloads := payloader.Payload(1200, frames[i])
+		decoded, err := decoder.Decode(&rtp.Packet{
+			Header:  rtp.Header{PayloadType: 63, SequenceNumber: seq, Timestamp: uint32(1000 + 960*i)},
+			Payload: payloads[0],
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, decoded...)
+	}
+
+	// The redundant block of 12 repeats 10, which was received, so nothing is recovered in place of the event
+	if len(received) != 2 || received[0].SequenceNumber != 10 || received[1].SequenceNumber != 12 {
+		for _, p := range received {
+			t.Errorf("received seq %d ts %d payload %x", p.SequenceNumber, p.Timestamp, p.Payload)
+		}
+		t.Fatalf("expected only the primary encodings of 10 and 12, got %d packets", len(received))
+	}
+}
+
+func TestREDPacketUnmarshalShort(t *testing.T) {
+	for _, payload := range [][]byte{
+		{},
+		{0xEF, 0x00},
+		{0xEF, 0x00, 0x3C, 0x05, 0x6F},
+	} {
+		if _, err := (&REDPacket{}).Unmarshal(&rtp.Packet{Payload: payload}); err == nil {
+			t.Errorf("expected an error unmarshaling %x", payload)
+		}
+	}
+}
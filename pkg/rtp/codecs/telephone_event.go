@@ -0,0 +1,83 @@
+package codecs
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	telephoneEventSize = 4
+
+	telephoneEventEndBitmask    = 0x80
+	telephoneEventVolumeBitmask = 0x3F
+
+	// TelephoneEventClockRate is the RTP clock rate of telephone-event/8000
+	TelephoneEventClockRate = 8000
+)
+
+// dtmfEvents are the DTMF tones in the order of their event codes
+// https://tools.ietf.org/html/rfc4733#section-3.2
+const dtmfEvents = "0123456789*#ABCD"
+
+// TelephoneEvent is a named telephone event, like a DTMF tone, stored in the payload of an RTP Packet
+// https://tools.ietf.org/html/rfc4733#section-2.3
+type TelephoneEvent struct {
+	Event      uint8
+	EndOfEvent bool
+
+	// Volume is the power level of the tone in -dBm0, from 0 to 63
+	Volume uint8
+
+	// Duration is the duration of the event so far, in RTP clock units since the timestamp of the packet
+	Duration uint16
+}
+
+// DTMFEvent returns the event code of a DTMF tone, one of 0-9, *, # and A-D
+func DTMFEvent(tone rune) (event uint8, ok bool) {
+	for i, t := range dtmfEvents {
+		if t == tone || (tone >= 'a' && tone <= 'd' && t == tone-'a'+'A') {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
+
+// DTMFTone returns the DTMF tone of the event, if it is a DTMF event
+func (e *TelephoneEvent) DTMFTone() (tone rune, ok bool) {
+	if int(e.Event) >= len(dtmfEvents) {
+		return 0, false
+	}
+	return rune(dtmfEvents[e.Event]), true
+}
+
+// Marshal serializes the members to buffer
+func (e *TelephoneEvent) Marshal() ([]byte, error) {
+	if e.Volume > telephoneEventVolumeBitmask {
+		return nil, errors.Errorf("telephone event volume %d overflows 6 bits", e.Volume)
+	}
+
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |     event     |E|R| volume    |          duration             |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	raw := []byte{e.Event, e.Volume, byte(e.Duration >> 8), byte(e.Duration)}
+	if e.EndOfEvent {
+		raw[1] |= telephoneEventEndBitmask
+	}
+	return raw, nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the members
+func (e *TelephoneEvent) Unmarshal(payload []byte) error {
+	if len(payload) < telephoneEventSize {
+		return errors.Errorf("telephone event size insufficient; %d < %d", len(payload), telephoneEventSize)
+	}
+
+	e.Event = payload[0]
+	e.EndOfEvent = payload[1]&telephoneEventEndBitmask != 0
+	e.Volume = payload[1] & telephoneEventVolumeBitmask
+	e.Duration = uint16(payload[2])<<8 | uint16(payload[3])
+	return nil
+}
@@ -0,0 +1,46 @@
+package codecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTelephoneEventMarshal(t *testing.T) {
+	event := &TelephoneEvent{Event: 11, EndOfEvent: true, Volume: 10, Duration: 800}
+	raw, err := event.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []byte{0x0B, 0x8A, 0x03, 0x20}; !reflect.DeepEqual(raw, expected) {
+		t.Fatalf("telephone event marshaled to %v, expected %v", raw, expected)
+	}
+
+	parsed := &TelephoneEvent{}
+	if err := parsed.Unmarshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, event) {
+		t.Errorf("telephone event unmarshaled to %+v, expected %+v", parsed, event)
+	}
+	if tone, ok := parsed.DTMFTone(); !ok || tone != '#' {
+		t.Errorf("expected event 11 to be #, got %q", tone)
+	}
+
+	if _, err := (&TelephoneEvent{Volume: 64}).Marshal(); err == nil {
+		t.Errorf("a volume over 6 bits should not marshal")
+	}
+	if err := parsed.Unmarshal([]byte{0x00, 0x00}); err == nil {
+		t.Errorf("a short telephone event should not unmarshal")
+	}
+}
+
+func TestDTMFEvent(t *testing.T) {
+	for tone, expected := range map[rune]uint8{'0': 0, '9': 9, '*': 10, '#': 11, 'A': 12, 'd': 15} {
+		if event, ok := DTMFEvent(tone); !ok || event != expected {
+			t.Errorf("expected %q to be event %d, got %d", tone, expected, event)
+		}
+	}
+	if _, ok := DTMFEvent('E'); ok {
+		t.Errorf("E is not a DTMF tone")
+	}
+}
@@ -1,12 +1,30 @@
 package codecs
 
-import "github.com/pions/webrtc/pkg/rtp"
+import (
+	"math/rand"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
 
 // VP8Payloader payloads VP8 packets
-type VP8Payloader struct{}
+// Every packet carries a 15-bit PictureID, that increments with every frame
+type VP8Payloader struct {
+	// TemporalLayers adds TL0PICIDX and TID to every packet. TemporalLayerID and LayerSync describe the
+	// next frame, TL0PICIDX is incremented for every frame of temporal layer 0
+	TemporalLayers  bool
+	TemporalLayerID uint8
+	LayerSync       bool
+
+	pictureID   uint16
+	tl0PicIdx   uint8
+	initialized bool
+}
 
 const (
 	vp8HeaderSize = 1
+
+	vp8MaxPictureID = 0x7FFF
 )
 
 // Payload fragments a VP8 packet across one or more byte arrays
@@ -33,7 +51,28 @@ func (p *VP8Payloader) Payload(mtu int, payload []byte) [][]byte {
 	 *     first packet of each encoded frame.
 	 */
 
-	maxFragmentSize := mtu - vp8HeaderSize
+	if !p.initialized {
+		p.pictureID = uint16(rand.Intn(vp8MaxPictureID))
+		p.initialized = true
+	} else if p.TemporalLayers && p.TemporalLayerID == 0 {
+		p.tl0PicIdx++
+	}
+
+	// X with I, and a 15-bit PictureID, M is set
+	descriptor := []byte{0x80, 0x80, 0x80 | byte(p.pictureID>>8), byte(p.pictureID)}
+	if p.TemporalLayers {
+		descriptor[1] |= 0x60 // L and T
+		tid := (p.TemporalLayerID & 0x03) << 6
+		if p.LayerSync {
+			tid |= 0x20
+		}
+		descriptor = append(descriptor, p.tl0PicIdx, tid)
+	}
+
+	maxFragmentSize := mtu - len(descriptor)
+	if maxFragmentSize <= 0 {
+		return nil
+	}
 
 	payloadData := payload
 	payloadDataRemaining := len(payload)
@@ -41,18 +80,20 @@ func (p *VP8Payloader) Payload(mtu int, payload []byte) [][]byte {
 	var payloads [][]byte
 	for payloadDataRemaining > 0 {
 		currentFragmentSize := min(maxFragmentSize, payloadDataRemaining)
-		out := make([]byte, vp8HeaderSize+currentFragmentSize)
+		out := make([]byte, len(descriptor)+currentFragmentSize)
+		copy(out, descriptor)
 		if payloadDataRemaining == len(payload) {
-			out[0] = 0x10
+			out[0] |= 0x10
 		}
 
-		copy(out[vp8HeaderSize:], payloadData[payloadDataIndex:payloadDataIndex+currentFragmentSize])
+		copy(out[len(descriptor):], payloadData[payloadDataIndex:payloadDataIndex+currentFragmentSize])
 		payloads = append(payloads, out)
 
 		payloadDataRemaining -= currentFragmentSize
 		payloadDataIndex += currentFragmentSize
 	}
 
+	p.pictureID = (p.pictureID + 1) & vp8MaxPictureID
 	return payloads
 }
 
@@ -69,15 +110,28 @@ type VP8Packet struct {
 	L         uint8  /* 1 if TL0PICIDX is present */
 	T         uint8  /* 1 if TID is present */
 	K         uint8  /* 1 if KEYIDX is present */
-	PictureID uint16 /* 8 or 16 bits, picture ID */
+	PictureID uint16 /* 7 or 15 bits, picture ID */
 	TL0PICIDX uint8  /* 8 bits temporal level zero index */
+	TID       uint8  /* 2 bits temporal layer index */
+	Y         uint8  /* 1 if this frame only depends on temporal layer 0 */
+	KEYIDX    uint8  /* 5 bits temporal key frame index */
 
 	Payload []byte
 }
 
 // Unmarshal parses the passed byte slice and stores the result in the VP8Packet this method is called upon
-func (p *VP8Packet) Unmarshal(packet *rtp.Packet) error {
+func (p *VP8Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
 	payload := packet.Payload
+	if len(payload) < vp8HeaderSize {
+		return nil, errors.Errorf("VP8 payload is too short; %d < %d", len(payload), vp8HeaderSize)
+	}
+
+	checkLength := func(payloadIndex int, field string) error {
+		if len(payload) <= payloadIndex {
+			return errors.Errorf("VP8 payload is too short to contain %s", field)
+		}
+		return nil
+	}
 
 	payloadIndex := 0
 
@@ -88,7 +142,12 @@ func (p *VP8Packet) Unmarshal(packet *rtp.Packet) error {
 
 	payloadIndex++
 
+	p.I, p.L, p.T, p.K = 0, 0, 0, 0
+	p.PictureID, p.TL0PICIDX, p.TID, p.Y, p.KEYIDX = 0, 0, 0, 0, 0
 	if p.X == 1 {
+		if err := checkLength(payloadIndex, "the extended control bits"); err != nil {
+			return nil, err
+		}
 		p.I = (payload[payloadIndex] & 0x80) >> 7
 		p.L = (payload[payloadIndex] & 0x40) >> 6
 		p.T = (payload[payloadIndex] & 0x20) >> 5
@@ -97,22 +156,59 @@ func (p *VP8Packet) Unmarshal(packet *rtp.Packet) error {
 	}
 
 	if p.I == 1 { // PID present?
-		if payload[payloadIndex]&0x80 > 0 { // M == 1, PID is 16bit
+		if err := checkLength(payloadIndex, "PictureID"); err != nil {
+			return nil, err
+		}
+		if payload[payloadIndex]&0x80 > 0 { // M == 1, PID is 15bit
+			if err := checkLength(payloadIndex+1, "PictureID"); err != nil {
+				return nil, err
+			}
+			p.PictureID = uint16(payload[payloadIndex]&0x7F)<<8 | uint16(payload[payloadIndex+1])
 			payloadIndex += 2
 		} else {
+			p.PictureID = uint16(payload[payloadIndex])
 			payloadIndex++
 		}
 	}
 
 	if p.L == 1 {
+		if err := checkLength(payloadIndex, "TL0PICIDX"); err != nil {
+			return nil, err
+		}
+		p.TL0PICIDX = payload[payloadIndex]
 		payloadIndex++
 	}
 
 	if p.T == 1 || p.K == 1 {
+		if err := checkLength(payloadIndex, "TID/KEYIDX"); err != nil {
+			return nil, err
+		}
+		if p.T == 1 {
+			p.TID = payload[payloadIndex] >> 6
+			p.Y = (payload[payloadIndex] & 0x20) >> 5
+		}
+		if p.K == 1 {
+			p.KEYIDX = payload[payloadIndex] & 0x1F
+		}
 		payloadIndex++
 	}
 
 	p.Payload = payload[payloadIndex:]
 
-	return nil
+	return p.Payload, nil
+}
+
+// IsKeyFrame checks if the last unmarshaled packet starts a VP8 key frame. Only the first packet of a frame
+// carries the frame header, where the P bit is 0 for key frames
+// https://tools.ietf.org/html/rfc6386#section-9.1
+func (p *VP8Packet) IsKeyFrame() bool {
+	return p.S == 1 && p.PID == 0 && len(p.Payload) > 0 && p.Payload[0]&0x01 == 0
+}
+
+// IsPartitionHead checks if this is the first packet of a VP8 frame, the start of partition 0
+func (p *VP8Packet) IsPartitionHead(payload []byte) bool {
+	if len(payload) < vp8HeaderSize {
+		return false
+	}
+	return payload[0]&0x10 != 0 && payload[0]&0x07 == 0
 }
@@ -0,0 +1,73 @@
+package codecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestVP8PayloadRoundTrip(t *testing.T) {
+	// Key frame, the P bit of the frame header is 0
+	keyFrame := []byte{0x50, 0x0A, 0x00, 0x9D, 0x01, 0x2A, 0x40, 0x01, 0xF0, 0x00}
+	p := &VP8Payloader{TemporalLayers: true}
+
+	payloads := p.Payload(10, keyFrame)
+	if len(payloads) != 3 {
+		t.Fatalf("expected 3 payloads, got %d", len(payloads))
+	}
+
+	var frame []byte
+	depacketizer := &VP8Packet{}
+	for i, payload := range payloads {
+		data, err := depacketizer.Unmarshal(&rtp.Packet{Payload: payload})
+		if err != nil {
+			t.Fatalf("failed to unmarshal payload %d: %v", i, err)
+		}
+		if depacketizer.IsPartitionHead(payload) != (i == 0) || depacketizer.IsKeyFrame() != (i == 0) {
+			t.Errorf("payload %d has the wrong S bit", i)
+		}
+		if depacketizer.I != 1 || depacketizer.L != 1 || depacketizer.T != 1 || depacketizer.TID != 0 {
+			t.Errorf("payload %d is missing its PictureID or temporal layer info", i)
+		}
+		frame = append(frame, data...)
+	}
+	if !bytes.Equal(frame, keyFrame) {
+		t.Errorf("reassembled frame %x does not match %x", frame, keyFrame)
+	}
+
+	pictureID, tl0PicIdx := depacketizer.PictureID, depacketizer.TL0PICIDX
+	p.TemporalLayerID, p.LayerSync = 1, true
+	if _, err := depacketizer.Unmarshal(&rtp.Packet{Payload: p.Payload(1200, []byte{0x31, 0x02})[0]}); err != nil {
+		t.Fatal(err)
+	}
+	if depacketizer.IsKeyFrame() || depacketizer.PictureID != (pictureID+1)&vp8MaxPictureID {
+		t.Errorf("expected an inter frame with PictureID %d, got %d", pictureID+1, depacketizer.PictureID)
+	}
+	if depacketizer.TL0PICIDX != tl0PicIdx || depacketizer.TID != 1 || depacketizer.Y != 1 {
+		t.Errorf("unexpected temporal layer info TL0PICIDX=%d TID=%d Y=%d", depacketizer.TL0PICIDX, depacketizer.TID, depacketizer.Y)
+	}
+
+	p.TemporalLayerID, p.LayerSync = 0, false
+	if _, err := depacketizer.Unmarshal(&rtp.Packet{Payload: p.Payload(1200, []byte{0x31, 0x02})[0]}); err != nil {
+		t.Fatal(err)
+	}
+	if depacketizer.TL0PICIDX != tl0PicIdx+1 {
+		t.Errorf("expected TL0PICIDX %d for a frame of temporal layer 0, got %d", tl0PicIdx+1, depacketizer.TL0PICIDX)
+	}
+}
+
+func TestVP8PacketUnmarshalShort(t *testing.T) {
+	for _, payload := range [][]byte{
+		{},
+		{0x80},
+		{0x80, 0x80},
+		{0x80, 0x80, 0x80},
+		{0x80, 0x40},
+		{0x80, 0x10},
+	} {
+		if _, err := (&VP8Packet{}).Unmarshal(&rtp.Packet{Payload: payload}); err == nil {
+			t.Errorf("expected an error unmarshaling %x", payload)
+		}
+	}
+}
@@ -0,0 +1,447 @@
+package codecs
+
+import (
+	"math/rand"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// VP9Payloader payloads VP9 packets
+// https://tools.ietf.org/html/draft-ietf-payload-vp9-06
+type VP9Payloader struct {
+	// FlexibleMode selects flexible mode, where every picture carries the P_DIFF of its reference
+	// pictures. In non-flexible mode keyframes carry a scalability structure instead, that the
+	// references of the following pictures are described by
+	FlexibleMode bool
+
+	pictureID   uint16
+	tl0PicIdx   uint8
+	initialized bool
+}
+
+const (
+	vp9MaxPictureID   = 0x7FFF
+	vp9MaxRefPictures = 3
+
+	vp9FrameSyncCode = 0x498342
+	vp9ColorSpaceRGB = 7
+)
+
+// Payload fragments a VP9 frame across one or more byte arrays
+func (p *VP9Payloader) Payload(mtu int, payload []byte) [][]byte {
+	/*
+	 * https://tools.ietf.org/html/draft-ietf-payload-vp9-06#section-4.2
+	 *
+	 *       0 1 2 3 4 5 6 7
+	 *      +-+-+-+-+-+-+-+-+
+	 *      |I|P|L|F|B|E|V|-| (REQUIRED)
+	 *      +-+-+-+-+-+-+-+-+
+	 * I:   |M| PICTURE ID  | (REQUIRED)
+	 *      +-+-+-+-+-+-+-+-+
+	 * M:   | EXTENDED PID  | (RECOMMENDED)
+	 *      +-+-+-+-+-+-+-+-+
+	 * L:   |  T  |U|  S  |D| (CONDITIONALLY RECOMMENDED)
+	 *      +-+-+-+-+-+-+-+-+
+	 *      |   TL0PICIDX   | (CONDITIONALLY REQUIRED, non-flexible mode only)
+	 *      +-+-+-+-+-+-+-+-+
+	 * P,F: | P_DIFF      |N| (CONDITIONALLY REQUIRED, flexible mode only) - up to 3 times
+	 *      +-+-+-+-+-+-+-+-+
+	 * V:   | SS            |
+	 *      | ..            |
+	 *      +-+-+-+-+-+-+-+-+
+	 */
+
+	if len(payload) == 0 || mtu <= 0 {
+		return nil
+	}
+
+	if !p.initialized {
+		p.pictureID = uint16(rand.Intn(vp9MaxPictureID))
+		p.initialized = true
+	}
+
+	width, height, isKeyFrame := vp9KeyFrameSize(payload)
+
+	var descriptor []byte
+	flags := byte(0xA0) // I and L are always set
+	if !isKeyFrame {
+		flags |= 0x40 // P, this picture references the previous one
+	}
+
+	descriptor = append(descriptor, 0, 0x80|byte(p.pictureID>>8), byte(p.pictureID))
+
+	// Single spatial and temporal layer, T=0 U=0 S=0 D=0
+	var ss []byte
+	if p.FlexibleMode {
+		flags |= 0x10
+		descriptor = append(descriptor, 0)
+		if !isKeyFrame {
+			descriptor = append(descriptor, 1<<1) // P_DIFF=1 N=0
+		}
+	} else {
+		descriptor = append(descriptor, 0, p.tl0PicIdx)
+		if isKeyFrame {
+			ss = vp9ScalabilityStructure(width, height)
+		}
+	}
+
+	var payloads [][]byte
+	for payloadDataIndex := 0; payloadDataIndex < len(payload); {
+		// The scalability structure is only sent with the start of a frame
+		headerSize := len(descriptor)
+		if payloadDataIndex == 0 {
+			headerSize += len(ss)
+		}
+
+		maxFragmentSize := mtu - headerSize
+		if maxFragmentSize <= 0 {
+			return nil
+		}
+
+		currentFragmentSize := min(maxFragmentSize, len(payload)-payloadDataIndex)
+		out := make([]byte, headerSize+currentFragmentSize)
+		copy(out, descriptor)
+
+		out[0] = flags
+		if payloadDataIndex == 0 {
+			out[0] |= 0x08 // B, start of a frame
+			if len(ss) != 0 {
+				out[0] |= 0x02 // V
+				copy(out[len(descriptor):], ss)
+			}
+		}
+		if payloadDataIndex+currentFragmentSize == len(payload) {
+			out[0] |= 0x04 // E, end of a frame
+		}
+
+		copy(out[headerSize:], payload[payloadDataIndex:payloadDataIndex+currentFragmentSize])
+		payloads = append(payloads, out)
+		payloadDataIndex += currentFragmentSize
+	}
+
+	p.pictureID = (p.pictureID + 1) & vp9MaxPictureID
+	p.tl0PicIdx++
+
+	return payloads
+}
+
+// vp9ScalabilityStructure builds the SS for a single layer stream, with one picture in its group
+// that references the picture before it
+func vp9ScalabilityStructure(width, height uint16) []byte {
+	/*
+	 *      +-+-+-+-+-+-+-+-+
+	 * V:   | N_S |Y|G|-|-|-|
+	 *      +-+-+-+-+-+-+-+-+              -\
+	 * Y:   |     WIDTH     | (OPTIONAL)    .
+	 *      +               +               .
+	 *      |               | (OPTIONAL)    .
+	 *      +-+-+-+-+-+-+-+-+               . - N_S + 1 times
+	 *      |     HEIGHT    | (OPTIONAL)    .
+	 *      +               +               .
+	 *      |               | (OPTIONAL)    .
+	 *      +-+-+-+-+-+-+-+-+              -/
+	 * G:   |      N_G      | (OPTIONAL)
+	 *      +-+-+-+-+-+-+-+-+                           -\
+	 * N_G: |  T  |U| R |-|-| (OPTIONAL)                 .
+	 *      +-+-+-+-+-+-+-+-+              -\            . - N_G times
+	 *      |    P_DIFF     | (OPTIONAL)    . - R times  .
+	 *      +-+-+-+-+-+-+-+-+              -/            -/
+	 */
+	ss := []byte{0x08} // N_S=0 G=1
+	if width != 0 && height != 0 {
+		ss[0] |= 0x10 // Y
+		ss = append(ss, byte(width>>8), byte(width), byte(height>>8), byte(height))
+	}
+	return append(ss, 1, 1<<2, 1) // N_G=1, T=0 U=0 R=1, P_DIFF=1
+}
+
+// vp9KeyFrameSize parses the uncompressed header of a VP9 frame, and returns its size if it is a keyframe
+// VP9 Bitstream & Decoding Process Specification Section 6.2
+func vp9KeyFrameSize(frame []byte) (width, height uint16, isKeyFrame bool) {
+	r := &bitReader{buf: frame}
+
+	if r.read(2) != 2 { // frame_marker
+		return 0, 0, false
+	}
+	profile := r.read(1) | r.read(1)<<1
+	if profile == 3 {
+		r.read(1) // reserved_zero
+	}
+	if r.read(1) == 1 { // show_existing_frame
+		return 0, 0, false
+	}
+	if r.read(1) != 0 { // frame_type, 0 is KEY_FRAME
+		return 0, 0, false
+	}
+	r.read(2) // show_frame, error_resilient_mode
+
+	if r.read(24) != vp9FrameSyncCode {
+		return 0, 0, false
+	}
+
+	// color_config
+	if profile >= 2 {
+		r.read(1) // ten_or_twelve_bit
+	}
+	if r.read(3) != vp9ColorSpaceRGB {
+		r.read(1) // color_range
+		if profile == 1 || profile == 3 {
+			r.read(3) // subsampling_x, subsampling_y, reserved_zero
+		}
+	} else if profile == 1 || profile == 3 {
+		r.read(1) // reserved_zero
+	}
+
+	// frame_size
+	width = uint16(r.read(16) + 1)
+	height = uint16(r.read(16) + 1)
+	if r.overflow {
+		return 0, 0, true
+	}
+	return width, height, true
+}
+
+// bitReader reads big endian bit fields, reads past the end of buf return zeros
+type bitReader struct {
+	buf      []byte
+	offset   int
+	overflow bool
+}
+
+func (b *bitReader) read(bits int) (v uint32) {
+	for i := 0; i < bits; i++ {
+		v <<= 1
+		if b.offset/8 >= len(b.buf) {
+			b.overflow = true
+			continue
+		}
+		v |= uint32(b.buf[b.offset/8]>>(7-uint(b.offset%8))) & 1
+		b.offset++
+	}
+	return v
+}
+
+// VP9Packet represents the VP9 header that is stored in the payload of an RTP Packet
+type VP9Packet struct {
+	// Required Header
+	I bool // PictureID is present
+	P bool // Inter-picture predicted frame
+	L bool // Layer indices is present
+	F bool // Flexible mode
+	B bool // Start of a frame
+	E bool // End of a frame
+	V bool // Scalability structure (SS) data present
+
+	// Recommended headers
+	PictureID uint16 // 7 or 16 bits, picture ID
+
+	// Conditionally recommended headers
+	TID uint8 // Temporal layer ID
+	U   bool  // Switching up point
+	SID uint8 // Spatial layer ID
+	D   bool  // Inter-layer dependency used
+
+	// Conditionally required headers
+	PDiff     []uint8 // Reference index (F=1)
+	TL0PICIDX uint8   // Temporal layer zero index (F=0)
+
+	// Scalability structure headers
+	NS      uint8 // N_S + 1 indicates the number of spatial layers present in the VP9 stream
+	Y       bool  // Each spatial layer's frame resolution present
+	G       bool  // PG description present flag
+	NG      uint8 // N_G indicates the number of pictures in a Picture Group (PG)
+	Width   []uint16
+	Height  []uint16
+	PGTID   []uint8   // Temporal layer ID of pictures in a Picture Group
+	PGU     []bool    // Switching up point of pictures in a Picture Group
+	PGPDiff [][]uint8 // Reference indices of pictures in a Picture Group
+
+	Payload []byte
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the VP9Packet this method is called upon
+func (p *VP9Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	payload := packet.Payload
+	if len(payload) < 1 {
+		return nil, errors.Errorf("VP9 payload is empty")
+	}
+
+	p.I = payload[0]&0x80 != 0
+	p.P = payload[0]&0x40 != 0
+	p.L = payload[0]&0x20 != 0
+	p.F = payload[0]&0x10 != 0
+	p.B = payload[0]&0x08 != 0
+	p.E = payload[0]&0x04 != 0
+	p.V = payload[0]&0x02 != 0
+
+	payloadIndex := 1
+	var err error
+
+	if p.I {
+		if payloadIndex, err = p.parsePictureID(payload, payloadIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.L {
+		if payloadIndex, err = p.parseLayerInfo(payload, payloadIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	p.PDiff = nil
+	if p.F && p.P {
+		if payloadIndex, err = p.parseRefIndices(payload, payloadIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.V {
+		if payloadIndex, err = p.parseSSData(payload, payloadIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	p.Payload = payload[payloadIndex:]
+	return p.Payload, nil
+}
+
+func errVP9ShortPacket(field string) error {
+	return errors.Errorf("VP9 payload is too short to contain %s", field)
+}
+
+func (p *VP9Packet) parsePictureID(payload []byte, payloadIndex int) (int, error) {
+	/*
+	 *      +-+-+-+-+-+-+-+-+
+	 * I:   |M| PICTURE ID  |   M:0 => picture id is 7 bits.
+	 *      +-+-+-+-+-+-+-+-+   M:1 => picture id is 15 bits.
+	 * M:   | EXTENDED PID  |
+	 *      +-+-+-+-+-+-+-+-+
+	 */
+	if len(payload) <= payloadIndex {
+		return payloadIndex, errVP9ShortPacket("PictureID")
+	}
+
+	p.PictureID = uint16(payload[payloadIndex] & 0x7F)
+	if payload[payloadIndex]&0x80 != 0 {
+		payloadIndex++
+		if len(payload) <= payloadIndex {
+			return payloadIndex, errVP9ShortPacket("extended PictureID")
+		}
+		p.PictureID = p.PictureID<<8 | uint16(payload[payloadIndex])
+	}
+	return payloadIndex + 1, nil
+}
+
+func (p *VP9Packet) parseLayerInfo(payload []byte, payloadIndex int) (int, error) {
+	/*
+	 *      +-+-+-+-+-+-+-+-+
+	 * L:   |  T  |U|  S  |D|
+	 *      +-+-+-+-+-+-+-+-+
+	 *      |   TL0PICIDX   |  (non-flexible mode only)
+	 *      +-+-+-+-+-+-+-+-+
+	 */
+	if len(payload) <= payloadIndex {
+		return payloadIndex, errVP9ShortPacket("layer indices")
+	}
+
+	p.TID = payload[payloadIndex] >> 5
+	p.U = payload[payloadIndex]&0x10 != 0
+	p.SID = (payload[payloadIndex] >> 1) & 0x7
+	p.D = payload[payloadIndex]&0x01 != 0
+	payloadIndex++
+
+	if p.F {
+		return payloadIndex, nil
+	}
+
+	if len(payload) <= payloadIndex {
+		return payloadIndex, errVP9ShortPacket("TL0PICIDX")
+	}
+	p.TL0PICIDX = payload[payloadIndex]
+	return payloadIndex + 1, nil
+}
+
+func (p *VP9Packet) parseRefIndices(payload []byte, payloadIndex int) (int, error) {
+	/*
+	 *      +-+-+-+-+-+-+-+-+                    -\
+	 * P,F: | P_DIFF      |N|  up to 3 times      . - only if F=1 and P=1
+	 *      +-+-+-+-+-+-+-+-+                    -/
+	 */
+	for {
+		if len(payload) <= payloadIndex {
+			return payloadIndex, errVP9ShortPacket("P_DIFF")
+		}
+
+		p.PDiff = append(p.PDiff, payload[payloadIndex]>>1)
+		if payload[payloadIndex]&0x01 == 0 {
+			return payloadIndex + 1, nil
+		} else if len(p.PDiff) >= vp9MaxRefPictures {
+			return payloadIndex, errors.Errorf("VP9 payload has more than %d reference indices", vp9MaxRefPictures)
+		}
+		payloadIndex++
+	}
+}
+
+func (p *VP9Packet) parseSSData(payload []byte, payloadIndex int) (int, error) {
+	if len(payload) <= payloadIndex {
+		return payloadIndex, errVP9ShortPacket("scalability structure")
+	}
+
+	p.NS = payload[payloadIndex] >> 5
+	p.Y = payload[payloadIndex]&0x10 != 0
+	p.G = payload[payloadIndex]&0x08 != 0
+	payloadIndex++
+
+	spatialLayers := int(p.NS) + 1
+	p.Width, p.Height = nil, nil
+	if p.Y {
+		if len(payload) < payloadIndex+4*spatialLayers {
+			return payloadIndex, errVP9ShortPacket("spatial layer resolutions")
+		}
+		for i := 0; i < spatialLayers; i++ {
+			p.Width = append(p.Width, uint16(payload[payloadIndex])<<8|uint16(payload[payloadIndex+1]))
+			p.Height = append(p.Height, uint16(payload[payloadIndex+2])<<8|uint16(payload[payloadIndex+3]))
+			payloadIndex += 4
+		}
+	}
+
+	p.NG = 0
+	p.PGTID, p.PGU, p.PGPDiff = nil, nil, nil
+	if !p.G {
+		return payloadIndex, nil
+	}
+
+	if len(payload) <= payloadIndex {
+		return payloadIndex, errVP9ShortPacket("N_G")
+	}
+	p.NG = payload[payloadIndex]
+	payloadIndex++
+
+	for i := 0; i < int(p.NG); i++ {
+		if len(payload) <= payloadIndex {
+			return payloadIndex, errVP9ShortPacket("picture group")
+		}
+		p.PGTID = append(p.PGTID, payload[payloadIndex]>>5)
+		p.PGU = append(p.PGU, payload[payloadIndex]&0x10 != 0)
+		refs := int((payload[payloadIndex] >> 2) & 0x3)
+		payloadIndex++
+
+		if len(payload) < payloadIndex+refs {
+			return payloadIndex, errVP9ShortPacket("picture group P_DIFF")
+		}
+		p.PGPDiff = append(p.PGPDiff, append([]uint8{}, payload[payloadIndex:payloadIndex+refs]...))
+		payloadIndex += refs
+	}
+
+	return payloadIndex, nil
+}
+
+// IsPartitionHead checks if this is the first packet of a VP9 frame, the B bit is set
+func (p *VP9Packet) IsPartitionHead(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+	return payload[0]&0x08 != 0
+}
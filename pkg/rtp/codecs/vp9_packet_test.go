@@ -0,0 +1,73 @@
+package codecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestVP9PayloadRoundTrip(t *testing.T) {
+	// Profile 0 keyframe, 320x240
+	keyFrame := []byte{0x82, 0x49, 0x83, 0x42, 0x00, 0x13, 0xF0, 0x0E, 0xF6, 0x00, 0x00, 0x00}
+	interFrame := []byte{0x86, 0x00, 0x40, 0x92, 0x88, 0x2C}
+
+	for _, flexible := range []bool{false, true} {
+		p := &VP9Payloader{FlexibleMode: flexible}
+
+		payloads := p.Payload(14, keyFrame)
+		if len(payloads) < 2 {
+			t.Fatalf("expected the keyframe to be fragmented, got %d payloads", len(payloads))
+		}
+
+		var frame []byte
+		depacketizer := &VP9Packet{}
+		for i, payload := range payloads {
+			if depacketizer.IsPartitionHead(payload) != (i == 0) {
+				t.Errorf("IsPartitionHead is wrong for fragment %d", i)
+			}
+
+			data, err := depacketizer.Unmarshal(&rtp.Packet{Payload: payload})
+			if err != nil {
+				t.Fatalf("failed to unmarshal fragment %d: %v", i, err)
+			}
+			if depacketizer.E != (i == len(payloads)-1) {
+				t.Errorf("E is wrong for fragment %d", i)
+			}
+			if i == 0 && !flexible && (!depacketizer.V || len(depacketizer.Width) != 1 || depacketizer.Width[0] != 320 || depacketizer.Height[0] != 240) {
+				t.Errorf("keyframe is missing its scalability structure")
+			}
+			frame = append(frame, data...)
+		}
+		if !bytes.Equal(frame, keyFrame) {
+			t.Errorf("reassembled frame %x does not match %x", frame, keyFrame)
+		}
+
+		pictureID := depacketizer.PictureID
+		payloads = p.Payload(1200, interFrame)
+		if _, err := depacketizer.Unmarshal(&rtp.Packet{Payload: payloads[0]}); err != nil {
+			t.Fatal(err)
+		}
+		if !depacketizer.P || depacketizer.PictureID != (pictureID+1)&vp9MaxPictureID {
+			t.Errorf("inter frame has P=%v PictureID=%d after %d", depacketizer.P, depacketizer.PictureID, pictureID)
+		}
+		if flexible && (len(depacketizer.PDiff) != 1 || depacketizer.PDiff[0] != 1) {
+			t.Errorf("inter frame has reference indices %v", depacketizer.PDiff)
+		}
+	}
+}
+
+func TestVP9PacketUnmarshalShort(t *testing.T) {
+	for _, payload := range [][]byte{
+		{},
+		{0x80},
+		{0x80, 0x80},
+		{0x20},
+		{0x02},
+		{0x50, 0x03, 0x03, 0x03},
+	} {
+		if _, err := (&VP9Packet{}).Unmarshal(&rtp.Packet{Payload: payload}); err == nil {
+			t.Errorf("expected an error unmarshaling %x", payload)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package rtp
+
+// Depacketizer depacketizes a RTP payload, removing any RTP specific data from the payload
+type Depacketizer interface {
+	// Unmarshal parses the RTP payload of packet and returns the media it carries
+	Unmarshal(packet *Packet) ([]byte, error)
+
+	// IsPartitionHead checks if this RTP payload can be the first packet of a frame. A frame that
+	// doesn't start with a partition head is missing its beginning and can't be decoded
+	IsPartitionHead(payload []byte) bool
+}
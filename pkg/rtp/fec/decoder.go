@@ -0,0 +1,155 @@
+package fec
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// decoderWindow is how many sequence numbers behind the newest media packet are kept for recovery
+const decoderWindow = 1024
+
+// Decoder recovers lost media packets of a single SSRC from the FEC packets protecting them
+type Decoder struct {
+	started    bool
+	highestSeq uint16
+
+	media map[uint16][]byte
+	fec   []*fecPacket
+}
+
+// NewDecoder creates a Decoder
+func NewDecoder() *Decoder {
+	return &Decoder{
+		media: map[uint16][]byte{},
+	}
+}
+
+// PushMedia adds a received media packet. It returns the packet followed by the packets that could be
+// recovered now that it arrived, or nothing if the packet had already been recovered
+func (d *Decoder) PushMedia(packet *rtp.Packet) ([]*rtp.Packet, error) {
+	if _, ok := d.media[packet.SequenceNumber]; ok {
+		return nil, nil
+	}
+
+	raw, err := rawPacket(packet)
+	if err != nil {
+		return nil, err
+	}
+	d.addMedia(packet.SequenceNumber, raw)
+
+	return append([]*rtp.Packet{packet}, d.recover()...), nil
+}
+
+// PushFEC adds a received FEC packet, and returns the media packets it allowed to recover
+func (d *Decoder) PushFEC(format Format, packet *rtp.Packet) ([]*rtp.Packet, error) {
+	var f *fecPacket
+	var err error
+	switch format {
+	case ULPFEC:
+		f, err = unmarshalULPFEC(packet.SSRC, packet.Payload)
+	case FlexFEC03:
+		f, err = unmarshalFlexFEC03(packet.Payload)
+	default:
+		err = errors.Errorf("FEC format %d is not supported", format)
+	}
+	if err != nil {
+		return nil, err
+	} else if len(f.sequenceNumbers) == 0 {
+		return nil, nil
+	}
+
+	d.fec = append(d.fec, f)
+	return d.recover(), nil
+}
+
+func (d *Decoder) addMedia(seq uint16, raw []byte) {
+	d.media[seq] = raw
+	if d.started && int16(seq-d.highestSeq) <= 0 {
+		return
+	}
+
+	d.started = true
+	d.highestSeq = seq
+	for s := range d.media {
+		if d.isExpired(s) {
+			delete(d.media, s)
+		}
+	}
+}
+
+func (d *Decoder) isExpired(seq uint16) bool {
+	return d.started && int16(d.highestSeq-seq) >= decoderWindow
+}
+
+// recover repeats passes over the FEC packets while they recover a packet, as every recovered
+// packet can leave another FEC packet with a single missing packet
+func (d *Decoder) recover() (recovered []*rtp.Packet) {
+	for progress := true; progress; {
+		progress = false
+
+		pending := d.fec[:0]
+		for _, f := range d.fec {
+			missing := -1
+			missingCount := 0
+			expired := true
+			for i, seq := range f.sequenceNumbers {
+				if !d.isExpired(seq) {
+					expired = false
+				}
+				if _, ok := d.media[seq]; !ok {
+					missing = i
+					missingCount++
+				}
+			}
+
+			switch {
+			case missingCount == 0 || expired:
+				// Nothing left to recover
+			case missingCount == 1:
+				if p := d.recoverPacket(f, f.sequenceNumbers[missing]); p != nil {
+					recovered = append(recovered, p)
+					progress = true
+				}
+			default:
+				pending = append(pending, f)
+			}
+		}
+		d.fec = pending
+	}
+	return recovered
+}
+
+// recoverPacket rebuilds the missing packet as the XOR of the FEC bit string and the bit strings of the other protected packets
+// https://tools.ietf.org/html/rfc5109#section-8.2
+func (d *Decoder) recoverPacket(f *fecPacket, seq uint16) *rtp.Packet {
+	recovery := bitString{
+		pxcc:      f.recovery.pxcc,
+		mpt:       f.recovery.mpt,
+		timestamp: f.recovery.timestamp,
+		length:    f.recovery.length,
+		data:      append([]byte{}, f.recovery.data...),
+	}
+	for _, s := range f.sequenceNumbers {
+		if s != seq {
+			recovery.xor(d.media[s])
+		}
+	}
+	if int(recovery.length) > len(recovery.data) {
+		return nil
+	}
+
+	raw := make([]byte, rtpHeaderSize, rtpHeaderSize+int(recovery.length))
+	raw[0] = 0x80 | recovery.pxcc
+	raw[1] = recovery.mpt
+	raw[2], raw[3] = byte(seq>>8), byte(seq)
+	raw[4], raw[5], raw[6], raw[7] = byte(recovery.timestamp>>24), byte(recovery.timestamp>>16), byte(recovery.timestamp>>8), byte(recovery.timestamp)
+	raw[8], raw[9], raw[10], raw[11] = byte(f.ssrc>>24), byte(f.ssrc>>16), byte(f.ssrc>>8), byte(f.ssrc)
+	raw = append(raw, recovery.data[:recovery.length]...)
+
+	packet := &rtp.Packet{}
+	if err := packet.Unmarshal(raw); err != nil {
+		return nil
+	}
+	d.addMedia(seq, raw)
+	return packet
+}
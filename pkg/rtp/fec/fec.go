@@ -0,0 +1,189 @@
+// Package fec generates and recovers XOR based forward error correction packets for RTP, in the
+// ULPFEC (RFC 5109) and FlexFEC-03 (draft-ietf-payload-flexible-fec-scheme-03) formats
+package fec
+
+import (
+	"math"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// Format is the packet format of FEC packets
+type Format int
+
+// List of supported Formats
+const (
+	// ULPFEC packets share the SSRC and sequence numbers of the media they protect
+	// https://tools.ietf.org/html/rfc5109
+	ULPFEC Format = iota + 1
+
+	// FlexFEC03 packets are sent with their own SSRC and sequence numbers
+	// https://tools.ietf.org/html/draft-ietf-payload-flexible-fec-scheme-03
+	FlexFEC03
+)
+
+func (f Format) String() string {
+	switch f {
+	case ULPFEC:
+		return "ULPFEC"
+	case FlexFEC03:
+		return "FlexFEC-03"
+	default:
+		return "Unknown"
+	}
+}
+
+const (
+	rtpHeaderSize = 12
+
+	// maxProtectedPackets is how many media packets a single FEC packet can protect, the long ULPFEC mask
+	maxProtectedPackets = 48
+)
+
+// bitString holds the XOR of the fields of RTP packets that FEC protects
+// https://tools.ietf.org/html/rfc5109#section-7.3
+type bitString struct {
+	// pxcc and mpt are the first two octets of the RTP header, without the version
+	pxcc      uint8
+	mpt       uint8
+	timestamp uint32
+
+	// length is the length of everything after the fixed RTP header
+	length uint16
+	data   []byte
+}
+
+func (b *bitString) xor(raw []byte) {
+	b.pxcc ^= raw[0] & 0x3F
+	b.mpt ^= raw[1]
+	b.timestamp ^= uint32(raw[4])<<24 | uint32(raw[5])<<16 | uint32(raw[6])<<8 | uint32(raw[7])
+
+	data := raw[rtpHeaderSize:]
+	b.length ^= uint16(len(data))
+	if len(data) > len(b.data) {
+		b.data = append(b.data, make([]byte, len(data)-len(b.data))...)
+	}
+	for i := range data {
+		b.data[i] ^= data[i]
+	}
+}
+
+// rawPacket returns the packet as it was sent, marshaling it if it has not been
+func rawPacket(p *rtp.Packet) ([]byte, error) {
+	if p.Raw != nil {
+		return p.Raw, nil
+	}
+	return p.Marshal()
+}
+
+// fecPacket is a parsed FEC packet, the media packets it protects and the XOR of their bit strings
+type fecPacket struct {
+	ssrc            uint32
+	sequenceNumbers []uint16
+	recovery        bitString
+}
+
+// Encoder generates FEC packets for the media packets of a single SSRC
+type Encoder struct {
+	format          Format
+	payloadType     uint8
+	ssrc            uint32
+	sequencer       rtp.Sequencer
+	protectionRatio float64
+
+	media []*rtp.Packet
+}
+
+// NewEncoder creates an Encoder that protects media with the given ratio of FEC packets to media packets.
+// FEC packets are sent with the given payload type, SSRC and sequencer. For ULPFEC these are shared with
+// the media, so the FEC packets fill the sequence numbers after the frame they protect
+func NewEncoder(format Format, payloadType uint8, ssrc uint32, sequencer rtp.Sequencer, protectionRatio float64) *Encoder {
+	return &Encoder{
+		format:          format,
+		payloadType:     payloadType,
+		ssrc:            ssrc,
+		sequencer:       sequencer,
+		protectionRatio: protectionRatio,
+	}
+}
+
+// Push adds a media packet, when it ends a frame the FEC packets protecting the frame are returned
+func (e *Encoder) Push(packet *rtp.Packet) ([]*rtp.Packet, error) {
+	e.media = append(e.media, packet)
+	if !packet.Marker && len(e.media) < maxProtectedPackets {
+		return nil, nil
+	}
+
+	media := e.media
+	e.media = nil
+	return e.encode(media)
+}
+
+// encode interleaves the media packets over ceil(len(media) * protectionRatio) FEC packets, so losing a
+// burst of packets still leaves one lost packet per FEC packet
+func (e *Encoder) encode(media []*rtp.Packet) ([]*rtp.Packet, error) {
+	if e.protectionRatio <= 0 || len(media) == 0 {
+		return nil, nil
+	}
+
+	fecCount := int(math.Ceil(float64(len(media)) * e.protectionRatio))
+	if fecCount > len(media) {
+		fecCount = len(media)
+	}
+
+	fecPackets := make([]*rtp.Packet, 0, fecCount)
+	for i := 0; i < fecCount; i++ {
+		var protected []*rtp.Packet
+		for j := i; j < len(media); j += fecCount {
+			protected = append(protected, media[j])
+		}
+
+		payload, err := e.marshalFEC(media[0].SequenceNumber, protected)
+		if err != nil {
+			return nil, err
+		}
+		fecPackets = append(fecPackets, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    e.payloadType,
+				SequenceNumber: e.sequencer.NextSequenceNumber(),
+				Timestamp:      media[len(media)-1].Timestamp,
+				SSRC:           e.ssrc,
+			},
+			Payload: payload,
+		})
+	}
+	return fecPackets, nil
+}
+
+func (e *Encoder) marshalFEC(sequenceNumberBase uint16, protected []*rtp.Packet) ([]byte, error) {
+	recovery := bitString{}
+	mask := uint64(0)
+	for _, p := range protected {
+		raw, err := rawPacket(p)
+		if err != nil {
+			return nil, err
+		}
+		recovery.xor(raw)
+		mask |= 1 << (63 - uint(p.SequenceNumber-sequenceNumberBase))
+	}
+
+	switch e.format {
+	case ULPFEC:
+		return marshalULPFEC(sequenceNumberBase, mask, &recovery), nil
+	case FlexFEC03:
+		return marshalFlexFEC03(protected[0].SSRC, sequenceNumberBase, mask, &recovery), nil
+	default:
+		return nil, errors.Errorf("FEC format %d is not supported", e.format)
+	}
+}
+
+func maskSequenceNumbers(sequenceNumberBase uint16, mask uint64) (sequenceNumbers []uint16) {
+	for i := uint(0); i < 64; i++ {
+		if mask&(1<<(63-i)) != 0 {
+			sequenceNumbers = append(sequenceNumbers, sequenceNumberBase+uint16(i))
+		}
+	}
+	return sequenceNumbers
+}
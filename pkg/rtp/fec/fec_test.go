@@ -0,0 +1,137 @@
+package fec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func mediaPackets(count int) []*rtp.Packet {
+	packets := make([]*rtp.Packet, count)
+	for i := range packets {
+		packets[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         i == count-1,
+				PayloadType:    96,
+				SequenceNumber: uint16(65530 + i),
+				Timestamp:      3000,
+				SSRC:           5000,
+			},
+			Payload: bytes.Repeat([]byte{byte(i)}, 10+i),
+		}
+	}
+	return packets
+}
+
+func TestFECRecovery(t *testing.T) {
+	for _, test := range []struct {
+		name            string
+		format          Format
+		count           int
+		protectionRatio float64
+		lost            []int
+	}{
+		{"ULPFEC", ULPFEC, 6, 0.5, []int{1, 2}},
+		{"ULPFECFirstAndLast", ULPFEC, 30, 0.1, []int{0, 29}},
+		{"FlexFEC03", FlexFEC03, 6, 0.5, []int{1, 2}},
+		{"FlexFEC03FirstAndLast", FlexFEC03, 30, 0.1, []int{0, 29}},
+		{"FlexFEC03MinimumRatio", FlexFEC03, 48, 0.01, []int{47}},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			media := mediaPackets(test.count)
+			fecPackets := encodeFEC(t, test.format, test.protectionRatio, media)
+			if expected := int(float64(test.count)*test.protectionRatio + 0.99); len(fecPackets) != expected {
+				t.Fatalf("expected %d FEC packets, got %d", expected, len(fecPackets))
+			}
+			assertFECRecovery(t, test.format, media, fecPackets, test.lost)
+		})
+	}
+}
+
+// encodeFEC protects media with the given format and returns the generated FEC packets
+func encodeFEC(t *testing.T, format Format, protectionRatio float64, media []*rtp.Packet) []*rtp.Packet {
+	ssrc := uint32(5000)
+	if format == FlexFEC03 {
+		ssrc = 6000
+	}
+	encoder := NewEncoder(format, 127, ssrc, rtp.NewRandomSequencer(), protectionRatio)
+
+	var fecPackets []*rtp.Packet
+	for _, p := range media {
+		out, err := encoder.Push(p)
+		if err != nil {
+			t.Fatalf("failed to generate FEC: %v", err)
+		}
+		fecPackets = append(fecPackets, out...)
+	}
+	return fecPackets
+}
+
+// assertFECRecovery drops the lost media packets and checks that the FEC packets recover them
+func assertFECRecovery(t *testing.T, format Format, media, fecPackets []*rtp.Packet, lost []int) {
+	isLost := map[int]bool{}
+	for _, i := range lost {
+		isLost[i] = true
+	}
+
+	decoder := NewDecoder()
+	for i, p := range media {
+		if isLost[i] {
+			continue
+		}
+		if _, err := decoder.PushMedia(p); err != nil {
+			t.Fatalf("failed to push media: %v", err)
+		}
+	}
+
+	var recovered []*rtp.Packet
+	for _, p := range fecPackets {
+		out, err := decoder.PushFEC(format, p)
+		if err != nil {
+			t.Fatalf("failed to push FEC: %v", err)
+		}
+		recovered = append(recovered, out...)
+	}
+
+	if len(recovered) != len(lost) {
+		t.Fatalf("expected %d recovered packets, got %d", len(lost), len(recovered))
+	}
+	for i, p := range recovered {
+		expected := media[lost[i]]
+		if p.SequenceNumber != expected.SequenceNumber || p.Timestamp != expected.Timestamp || p.SSRC != expected.SSRC ||
+			p.PayloadType != expected.PayloadType || p.Marker != expected.Marker || !bytes.Equal(p.Payload, expected.Payload) {
+			t.Errorf("recovered packet %d does not match the lost packet %d", p.SequenceNumber, expected.SequenceNumber)
+		}
+	}
+}
+
+func TestFECDecoderDuplicate(t *testing.T) {
+	media := mediaPackets(2)
+	encoder := NewEncoder(ULPFEC, 127, 5000, rtp.NewFixedSequencer(0), 1)
+
+	var fecPackets []*rtp.Packet
+	for _, p := range media {
+		out, err := encoder.Push(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fecPackets = append(fecPackets, out...)
+	}
+
+	// Each FEC packet protects a single media packet, so it recovers the packet on its own
+	decoder := NewDecoder()
+	if out, err := decoder.PushFEC(ULPFEC, fecPackets[0]); err != nil || len(out) != 1 {
+		t.Fatalf("expected a packet to be recovered, got %d packets", len(out))
+	}
+
+	// The late original of a recovered packet is dropped
+	if out, err := decoder.PushMedia(media[0]); err != nil || len(out) != 0 {
+		t.Errorf("expected a duplicate to be dropped, got %d packets", len(out))
+	}
+	if out, err := decoder.PushMedia(media[1]); err != nil || len(out) != 1 {
+		t.Errorf("expected the media packet to be returned, got %d packets", len(out))
+	}
+}
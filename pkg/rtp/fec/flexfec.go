@@ -0,0 +1,138 @@
+package fec
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	flexFECHeaderSize = 12
+	flexFECSSRCSize   = 4
+	flexFECSNBaseSize = 2
+
+	flexFECRetransmissionBitmask = 0x80
+	flexFECFlexibleMaskBitmask   = 0x40
+	flexFECPXCCBitmask           = 0x3F
+	flexFECKBitmask              = 0x80
+
+	// The mask grows in chunks of 15, 31 and 64 bits, the first two start with a K bit that ends the mask
+	flexFECMask0Size = 2
+	flexFECMask1Size = 4
+	flexFECMask2Size = 8
+)
+
+// FlexFECProtectedSSRC returns the SSRC of the media protected by a FlexFEC-03 packet
+func FlexFECProtectedSSRC(payload []byte) (uint32, error) {
+	if len(payload) < flexFECHeaderSize+flexFECSSRCSize {
+		return 0, errors.Errorf("FlexFEC payload is too short; %d < %d", len(payload), flexFECHeaderSize+flexFECSSRCSize)
+	}
+	return uint32(payload[12])<<24 | uint32(payload[13])<<16 | uint32(payload[14])<<8 | uint32(payload[15]), nil
+}
+
+// marshalFlexFEC03 builds the payload of a FlexFEC-03 packet with a flexible mask protecting a single SSRC
+// https://tools.ietf.org/html/draft-ietf-payload-flexible-fec-scheme-03#section-4.2
+func marshalFlexFEC03(ssrc uint32, sequenceNumberBase uint16, mask uint64, recovery *bitString) []byte {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |R|F|P|X|  CC   |M| PT recovery |        length recovery        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                          TS recovery                          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |   SSRCCount   |                    reserved                   |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                             SSRC_i                            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |           SN base_i           |k|          Mask [0-14]        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |k|                   Mask [15-45] (optional)                   |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                     Mask [46-108] (optional)                  |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	out := make([]byte, flexFECHeaderSize+flexFECSSRCSize+flexFECSNBaseSize, flexFECHeaderSize+flexFECSSRCSize+flexFECSNBaseSize+flexFECMask0Size+flexFECMask1Size+flexFECMask2Size+len(recovery.data))
+	out[0] = recovery.pxcc & flexFECPXCCBitmask
+	out[1] = recovery.mpt
+	out[2], out[3] = byte(recovery.length>>8), byte(recovery.length)
+	out[4], out[5], out[6], out[7] = byte(recovery.timestamp>>24), byte(recovery.timestamp>>16), byte(recovery.timestamp>>8), byte(recovery.timestamp)
+	out[8] = 1
+	out[12], out[13], out[14], out[15] = byte(ssrc>>24), byte(ssrc>>16), byte(ssrc>>8), byte(ssrc)
+	out[16], out[17] = byte(sequenceNumberBase>>8), byte(sequenceNumberBase)
+
+	mask0 := uint16(mask >> 49)
+	if mask&(1<<49-1) == 0 {
+		out = append(out, byte(mask0>>8)|flexFECKBitmask, byte(mask0))
+		return append(out, recovery.data...)
+	}
+	out = append(out, byte(mask0>>8), byte(mask0))
+
+	mask1 := uint32(mask>>18) & 0x7FFFFFFF
+	if mask&(1<<18-1) == 0 {
+		out = append(out, byte(mask1>>24)|flexFECKBitmask, byte(mask1>>16), byte(mask1>>8), byte(mask1))
+		return append(out, recovery.data...)
+	}
+	out = append(out, byte(mask1>>24), byte(mask1>>16), byte(mask1>>8), byte(mask1))
+
+	mask2 := mask << 46
+	for i := uint(0); i < flexFECMask2Size; i++ {
+		out = append(out, byte(mask2>>(56-8*i)))
+	}
+	return append(out, recovery.data...)
+}
+
+// unmarshalFlexFEC03 parses the payload of a FlexFEC-03 packet, only packets with a flexible mask
+// protecting a single SSRC are supported
+func unmarshalFlexFEC03(payload []byte) (*fecPacket, error) {
+	offset := flexFECHeaderSize + flexFECSSRCSize + flexFECSNBaseSize + flexFECMask0Size
+	if len(payload) < offset {
+		return nil, errors.Errorf("FlexFEC payload is too short; %d < %d", len(payload), offset)
+	} else if payload[0]&(flexFECRetransmissionBitmask|flexFECFlexibleMaskBitmask) != 0 {
+		return nil, errors.Errorf("FlexFEC retransmissions and fixed masks are not supported")
+	} else if payload[8] != 1 {
+		return nil, errors.Errorf("FlexFEC packets protecting %d SSRCs are not supported", payload[8])
+	}
+
+	ssrc, err := FlexFECProtectedSSRC(payload)
+	if err != nil {
+		return nil, err
+	}
+	sequenceNumberBase := uint16(payload[16])<<8 | uint16(payload[17])
+
+	mask := uint64(payload[18]&^flexFECKBitmask)<<57 | uint64(payload[19])<<49
+	if payload[18]&flexFECKBitmask == 0 {
+		if len(payload) < offset+flexFECMask1Size {
+			return nil, errors.Errorf("FlexFEC mask is truncated")
+		}
+		mask1 := uint64(payload[offset]&^flexFECKBitmask)<<24 | uint64(payload[offset+1])<<16 | uint64(payload[offset+2])<<8 | uint64(payload[offset+3])
+		mask |= mask1 << 18
+		k := payload[offset]&flexFECKBitmask != 0
+		offset += flexFECMask1Size
+
+		if !k {
+			if len(payload) < offset+flexFECMask2Size {
+				return nil, errors.Errorf("FlexFEC mask is truncated")
+			}
+			mask2 := uint64(0)
+			for i := 0; i < flexFECMask2Size; i++ {
+				mask2 |= uint64(payload[offset+i]) << (56 - 8*uint(i))
+			}
+			if mask2<<18 != 0 {
+				return nil, errors.Errorf("FlexFEC masks protecting more than 64 packets are not supported")
+			}
+			mask |= mask2 >> 46
+			offset += flexFECMask2Size
+		}
+	}
+
+	return &fecPacket{
+		ssrc:            ssrc,
+		sequenceNumbers: maskSequenceNumbers(sequenceNumberBase, mask),
+		recovery: bitString{
+			pxcc:      payload[0] & flexFECPXCCBitmask,
+			mpt:       payload[1],
+			length:    uint16(payload[2])<<8 | uint16(payload[3]),
+			timestamp: uint32(payload[4])<<24 | uint32(payload[5])<<16 | uint32(payload[6])<<8 | uint32(payload[7]),
+			data:      append([]byte{}, payload[offset:]...),
+		},
+	}, nil
+}
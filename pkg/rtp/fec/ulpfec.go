@@ -0,0 +1,98 @@
+package fec
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	ulpfecHeaderSize      = 10
+	ulpfecLevelHeaderSize = 2
+	ulpfecShortMaskSize   = 2
+	ulpfecLongMaskSize    = 6
+
+	ulpfecExtensionBitmask = 0x80
+	ulpfecLongMaskBitmask  = 0x40
+	ulpfecPXCCBitmask      = 0x3F
+)
+
+// marshalULPFEC builds the payload of a ULPFEC packet with a single protection level covering the whole packets
+// https://tools.ietf.org/html/rfc5109#section-7.3
+func marshalULPFEC(sequenceNumberBase uint16, mask uint64, recovery *bitString) []byte {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |E|L|P|X|  CC   |M| PT recovery |            SN base            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                          TS recovery                          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |        length recovery        |       Protection Length       |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |             mask              |     mask cont. (present only  |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                          when L = 1)                          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	longMask := mask<<16 != 0
+	maskSize := ulpfecShortMaskSize
+	if longMask {
+		maskSize = ulpfecLongMaskSize
+	}
+
+	out := make([]byte, ulpfecHeaderSize+ulpfecLevelHeaderSize+maskSize, ulpfecHeaderSize+ulpfecLevelHeaderSize+maskSize+len(recovery.data))
+	out[0] = recovery.pxcc & ulpfecPXCCBitmask
+	if longMask {
+		out[0] |= ulpfecLongMaskBitmask
+	}
+	out[1] = recovery.mpt
+	out[2], out[3] = byte(sequenceNumberBase>>8), byte(sequenceNumberBase)
+	out[4], out[5], out[6], out[7] = byte(recovery.timestamp>>24), byte(recovery.timestamp>>16), byte(recovery.timestamp>>8), byte(recovery.timestamp)
+	out[8], out[9] = byte(recovery.length>>8), byte(recovery.length)
+
+	out[10], out[11] = byte(len(recovery.data)>>8), byte(len(recovery.data))
+	for i := 0; i < maskSize; i++ {
+		out[12+i] = byte(mask >> (56 - 8*uint(i)))
+	}
+
+	return append(out, recovery.data...)
+}
+
+// unmarshalULPFEC parses the payload of a ULPFEC packet, only the first protection level is used
+func unmarshalULPFEC(ssrc uint32, payload []byte) (*fecPacket, error) {
+	if len(payload) < ulpfecHeaderSize+ulpfecLevelHeaderSize+ulpfecShortMaskSize {
+		return nil, errors.Errorf("ULPFEC payload is too short; %d < %d", len(payload), ulpfecHeaderSize+ulpfecLevelHeaderSize+ulpfecShortMaskSize)
+	} else if payload[0]&ulpfecExtensionBitmask != 0 {
+		return nil, errors.Errorf("ULPFEC extension bit is set")
+	}
+
+	maskSize := ulpfecShortMaskSize
+	if payload[0]&ulpfecLongMaskBitmask != 0 {
+		maskSize = ulpfecLongMaskSize
+	}
+	dataOffset := ulpfecHeaderSize + ulpfecLevelHeaderSize + maskSize
+	if len(payload) < dataOffset {
+		return nil, errors.Errorf("ULPFEC payload is too short; %d < %d", len(payload), dataOffset)
+	}
+
+	protectionLength := int(payload[10])<<8 | int(payload[11])
+	if protectionLength > len(payload)-dataOffset {
+		return nil, errors.Errorf("ULPFEC protection length %d is larger than the remaining %d bytes", protectionLength, len(payload)-dataOffset)
+	}
+
+	mask := uint64(0)
+	for i := 0; i < maskSize; i++ {
+		mask |= uint64(payload[12+i]) << (56 - 8*uint(i))
+	}
+
+	return &fecPacket{
+		ssrc:            ssrc,
+		sequenceNumbers: maskSequenceNumbers(uint16(payload[2])<<8|uint16(payload[3]), mask),
+		recovery: bitString{
+			pxcc:      payload[0] & ulpfecPXCCBitmask,
+			mpt:       payload[1],
+			timestamp: uint32(payload[4])<<24 | uint32(payload[5])<<16 | uint32(payload[6])<<8 | uint32(payload[7]),
+			length:    uint16(payload[8])<<8 | uint16(payload[9]),
+			data:      append([]byte{}, payload[dataOffset:dataOffset+protectionLength]...),
+		},
+	}, nil
+}
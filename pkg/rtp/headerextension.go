@@ -0,0 +1,217 @@
+package rtp
+
+import (
+	"github.com/pkg/errors"
+)
+
+// List of RTP header extensions pion-WebRTC can negotiate, stamp and parse
+const (
+	// AbsSendTimeURI https://webrtc.org/experiments/rtp-hdrext/abs-send-time/
+	AbsSendTimeURI = "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"
+
+	// AudioLevelURI https://tools.ietf.org/html/rfc6464
+	AudioLevelURI = "urn:ietf:params:rtp-hdrext:ssrc-audio-level"
+
+	// VideoOrientationURI 3GPP TS 26.114 Section 7.4.5
+	VideoOrientationURI = "urn:3gpp:video-orientation"
+
+	// SDESMidURI https://tools.ietf.org/html/draft-ietf-mmusic-sdp-bundle-negotiation-53#section-15
+	SDESMidURI = "urn:ietf:params:rtp-hdrext:sdes:mid"
+
+	// SDESRTPStreamIDURI https://tools.ietf.org/html/draft-ietf-avtext-rid-09#section-3
+	SDESRTPStreamIDURI = "urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id"
+
+	// PlayoutDelayURI https://webrtc.org/experiments/rtp-hdrext/playout-delay/
+	PlayoutDelayURI = "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"
+)
+
+// The header extensions pion-WebRTC negotiates. The RID is only parsed on received packets, tracks are sent without
+// simulcast so there is no rtp-stream-id to stamp
+var (
+	audioHeaderExtensions = []string{AbsSendTimeURI, AudioLevelURI, SDESMidURI}
+	videoHeaderExtensions = []string{AbsSendTimeURI, VideoOrientationURI, SDESMidURI, SDESRTPStreamIDURI, PlayoutDelayURI}
+)
+
+// NegotiateHeaderExtensions takes the a=extmap URIs and IDs offered by a remote peer for a media section
+// and returns the ones pion-WebRTC supports for that kind of media. The offered IDs are kept, so the same
+// IDs are used in both directions
+func NegotiateHeaderExtensions(offered map[string]uint8, isAudio bool) map[string]uint8 {
+	supported := videoHeaderExtensions
+	if isAudio {
+		supported = audioHeaderExtensions
+	}
+
+	negotiated := map[string]uint8{}
+	for _, uri := range supported {
+		if id, ok := offered[uri]; ok {
+			negotiated[uri] = id
+		}
+	}
+	return negotiated
+}
+
+// HeaderExtension is implemented by the typed RTP header extensions in this package
+type HeaderExtension interface {
+	Marshal() ([]byte, error)
+	Unmarshal(rawExtension []byte) error
+}
+
+// Extension is a single RTP header extension element
+// https://tools.ietf.org/html/rfc8285#section-4
+type Extension struct {
+	ID      uint8
+	Payload []byte
+}
+
+const (
+	// ExtensionProfileOneByte is the "defined by profile" value of one-byte header extensions
+	// https://tools.ietf.org/html/rfc8285#section-4.2
+	ExtensionProfileOneByte = 0xBEDE
+
+	// ExtensionProfileTwoByte is the "defined by profile" value of two-byte header extensions, the
+	// lower 4 bits are application dependent
+	// https://tools.ietf.org/html/rfc8285#section-4.3
+	ExtensionProfileTwoByte = 0x1000
+
+	extensionProfileTwoByteMask = 0xFFF0
+
+	oneByteExtensionMaxID     = 14
+	oneByteExtensionMaxLength = 16
+	twoByteExtensionMaxLength = 255
+)
+
+func isRFC8285Profile(profile uint16) bool {
+	return profile == ExtensionProfileOneByte || profile&extensionProfileTwoByteMask == ExtensionProfileTwoByte
+}
+
+func unmarshalExtensions(profile uint16, payload []byte) ([]Extension, error) {
+	var extensions []Extension
+
+	switch {
+	case profile == ExtensionProfileOneByte:
+		/*
+		 *  0 1 2 3 4 5 6 7
+		 * +-+-+-+-+-+-+-+-+
+		 * |  ID   |  len  |
+		 * +-+-+-+-+-+-+-+-+
+		 */
+		for i := 0; i < len(payload); {
+			id := payload[i] >> 4
+			length := int(payload[i]&0x0F) + 1
+			i++
+
+			if id == 0 { // Padding
+				continue
+			} else if id == 15 { // Reserved, processing of the extension block stops
+				break
+			}
+
+			if i+length > len(payload) {
+				return nil, errors.Errorf("RTP one-byte header extension %d overflows extension block; %d > %d", id, i+length, len(payload))
+			}
+			extensions = append(extensions, Extension{ID: id, Payload: payload[i : i+length]})
+			i += length
+		}
+	case profile&extensionProfileTwoByteMask == ExtensionProfileTwoByte:
+		/*
+		 *  0                   1
+		 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5
+		 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 * |       ID      |     length    |
+		 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+		 */
+		for i := 0; i < len(payload); {
+			id := payload[i]
+			i++
+
+			if id == 0 { // Padding
+				continue
+			} else if i >= len(payload) {
+				return nil, errors.Errorf("RTP two-byte header extension %d is missing its length", id)
+			}
+
+			length := int(payload[i])
+			i++
+
+			if i+length > len(payload) {
+				return nil, errors.Errorf("RTP two-byte header extension %d overflows extension block; %d > %d", id, i+length, len(payload))
+			}
+			extensions = append(extensions, Extension{ID: id, Payload: payload[i : i+length]})
+			i += length
+		}
+	}
+
+	return extensions, nil
+}
+
+func marshalExtensions(profile uint16, extensions []Extension) ([]byte, error) {
+	var raw []byte
+	for _, e := range extensions {
+		if profile == ExtensionProfileOneByte {
+			if e.ID == 0 || e.ID > oneByteExtensionMaxID || len(e.Payload) == 0 || len(e.Payload) > oneByteExtensionMaxLength {
+				return nil, errors.Errorf("RTP header extension %d can not be encoded in the one-byte form", e.ID)
+			}
+			raw = append(raw, e.ID<<4|uint8(len(e.Payload)-1))
+		} else {
+			if e.ID == 0 || len(e.Payload) > twoByteExtensionMaxLength {
+				return nil, errors.Errorf("RTP header extension %d can not be encoded in the two-byte form", e.ID)
+			}
+			raw = append(raw, e.ID, uint8(len(e.Payload)))
+		}
+		raw = append(raw, e.Payload...)
+	}
+
+	// The extension block is measured in 32-bit words, pad with zeros
+	for len(raw)%4 != 0 {
+		raw = append(raw, 0)
+	}
+	return raw, nil
+}
+
+// GetExtension returns the payload of the header extension with the given ID, or nil if it is not present
+func (h *Header) GetExtension(id uint8) []byte {
+	for _, e := range h.Extensions {
+		if e.ID == id {
+			return e.Payload
+		}
+	}
+	return nil
+}
+
+// SetExtension sets (or replaces) the header extension with the given ID. A nil payload removes it.
+// The one-byte form is used as long as every extension fits in it, otherwise the two-byte form
+func (h *Header) SetExtension(id uint8, payload []byte) error {
+	if id == 0 {
+		return errors.Errorf("RTP header extension ID 0 is reserved for padding")
+	} else if len(payload) > twoByteExtensionMaxLength {
+		return errors.Errorf("RTP header extension %d is too large; %d > %d", id, len(payload), twoByteExtensionMaxLength)
+	}
+
+	extensions := h.Extensions[:0:0]
+	for _, e := range h.Extensions {
+		if e.ID != id {
+			extensions = append(extensions, e)
+		}
+	}
+	if payload != nil {
+		extensions = append(extensions, Extension{ID: id, Payload: payload})
+	}
+	h.Extensions = extensions
+
+	if len(h.Extensions) == 0 {
+		h.Extension = false
+		h.ExtensionProfile = 0
+		h.ExtensionPayload = nil
+		return nil
+	}
+
+	h.Extension = true
+	h.ExtensionProfile = ExtensionProfileOneByte
+	for _, e := range h.Extensions {
+		if e.ID > oneByteExtensionMaxID || len(e.Payload) == 0 || len(e.Payload) > oneByteExtensionMaxLength {
+			h.ExtensionProfile = ExtensionProfileTwoByte
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package rtp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestHeaderExtensionRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name            string
+		extensions      []Extension
+		expectedProfile uint16
+	}{
+		{"OneByte", []Extension{{ID: 1, Payload: []byte{0xAA}}, {ID: 14, Payload: []byte{0x01, 0x02, 0x03}}}, ExtensionProfileOneByte},
+		{"TwoByte", []Extension{{ID: 1, Payload: []byte{0xAA}}, {ID: 20, Payload: bytes.Repeat([]byte{0x01}, 17)}}, ExtensionProfileTwoByte},
+	} {
+		p := &Packet{Header: Header{Version: 2, SSRC: 1234}, Payload: []byte{0x98, 0x36}}
+		for _, e := range test.extensions {
+			if err := p.SetExtension(e.ID, e.Payload); err != nil {
+				t.Fatalf("%s: SetExtension failed: %v", test.name, err)
+			}
+		}
+		if p.ExtensionProfile != test.expectedProfile {
+			t.Fatalf("%s: expected profile %x, got %x", test.name, test.expectedProfile, p.ExtensionProfile)
+		}
+
+		raw, err := p.Marshal()
+		if err != nil {
+			t.Fatalf("%s: Marshal failed: %v", test.name, err)
+		}
+
+		parsed := &Packet{}
+		if err := parsed.Unmarshal(raw); err != nil {
+			t.Fatalf("%s: Unmarshal failed: %v", test.name, err)
+		}
+		for _, e := range test.extensions {
+			if got := parsed.GetExtension(e.ID); !bytes.Equal(got, e.Payload) {
+				t.Errorf("%s: extension %d expected %v, got %v", test.name, e.ID, e.Payload, got)
+			}
+		}
+		if !bytes.Equal(parsed.Payload, p.Payload) {
+			t.Errorf("%s: payload expected %v, got %v", test.name, p.Payload, parsed.Payload)
+		}
+	}
+}
+
+func TestTypedHeaderExtensions(t *testing.T) {
+	for _, test := range []struct {
+		in, out HeaderExtension
+	}{
+		{&AudioLevelExtension{Level: 42, Voice: true}, &AudioLevelExtension{}},
+		{&VideoOrientationExtension{Direction: CameraBack, Flip: true, Rotation: VideoRotation270}, &VideoOrientationExtension{}},
+		{&PlayoutDelayExtension{MinDelay: 0x123, MaxDelay: 0xABC}, &PlayoutDelayExtension{}},
+		{&SDESItemExtension{Value: "audio"}, &SDESItemExtension{}},
+		{&AbsSendTimeExtension{Timestamp: 0x123456}, &AbsSendTimeExtension{}},
+	} {
+		raw, err := test.in.Marshal()
+		if err != nil {
+			t.Fatalf("%T Marshal failed: %v", test.in, err)
+		}
+		if err := test.out.Unmarshal(raw); err != nil {
+			t.Fatalf("%T Unmarshal failed: %v", test.in, err)
+		}
+		if reRaw, _ := test.out.Marshal(); !bytes.Equal(raw, reRaw) {
+			t.Errorf("%T did not round trip: %v != %v", test.in, raw, reRaw)
+		}
+	}
+}
+
+func TestAbsSendTimeEstimate(t *testing.T) {
+	sendTime := time.Unix(1500000000, 250000000)
+	ext := NewAbsSendTimeExtension(sendTime)
+
+	estimate := ext.Estimate(sendTime.Add(30 * time.Millisecond))
+	if diff := estimate.Sub(sendTime); diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("abs-send-time estimate is off by %v", diff)
+	}
+}
+
+func TestNegotiateHeaderExtensions(t *testing.T) {
+	offered := map[string]uint8{SDESMidURI: 1, SDESRTPStreamIDURI: 2, AudioLevelURI: 3, "urn:unknown": 4}
+
+	// The RID is negotiated for video so received simulcast layers can be told apart
+	video := NegotiateHeaderExtensions(offered, false)
+	if len(video) != 2 || video[SDESMidURI] != 1 || video[SDESRTPStreamIDURI] != 2 {
+		t.Errorf("unexpected video header extensions %v", video)
+	}
+	audio := NegotiateHeaderExtensions(offered, true)
+	if len(audio) != 2 || audio[SDESMidURI] != 1 || audio[AudioLevelURI] != 3 {
+		t.Errorf("unexpected audio header extensions %v", audio)
+	}
+}
@@ -6,10 +6,9 @@ import (
 	"github.com/pkg/errors"
 )
 
-// Packet represents an RTP Packet
-// RTP is a network protocol for delivering audio and video over IP networks.
-type Packet struct {
-	Raw              []byte
+// Header represents an RTP packet header
+// https://tools.ietf.org/html/rfc3550#section-5.1
+type Header struct {
 	Version          uint8
 	Padding          bool
 	Extension        bool
@@ -22,11 +21,23 @@ type Packet struct {
 	CSRC             []uint32
 	ExtensionProfile uint16
 	ExtensionPayload []byte
-	Payload          []byte
+	Extensions       []Extension
+}
+
+// Packet represents an RTP Packet
+// RTP is a network protocol for delivering audio and video over IP networks.
+type Packet struct {
+	Header
+	Raw     []byte
+	Payload []byte
+
+	// PaddingSize is the amount of padding octets at the end of the packet, including the
+	// count octet itself. Marshal writes padding (and sets the P bit) when it is non-zero
+	PaddingSize uint8
 }
 
 const (
-	headerLength    = 4
+	headerLength    = 12
 	versionShift    = 6
 	versionMask     = 0x3
 	paddingShift    = 5
@@ -47,8 +58,9 @@ const (
 	csrcLength      = 4
 )
 
-// Unmarshal parses the passed byte slice and stores the result in the Packet this method is called upon
-func (p *Packet) Unmarshal(rawPacket []byte) error {
+// Unmarshal parses the header in the passed byte slice and stores the result in the Header this method is called upon.
+// The payload (and padding) that follows the header is not inspected, PayloadOffset is set to where it starts
+func (h *Header) Unmarshal(rawPacket []byte) error {
 	if len(rawPacket) < headerLength {
 		return errors.Errorf("RTP header size insufficient; %d < %d", len(rawPacket), headerLength)
 	}
@@ -68,46 +80,106 @@ func (p *Packet) Unmarshal(rawPacket []byte) error {
 	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
 	 */
 
-	p.Version = rawPacket[0] >> versionShift & versionMask
-	p.Padding = (rawPacket[0] >> paddingShift & paddingMask) > 0
-	p.Extension = (rawPacket[0] >> extensionShift & extensionMask) > 0
-	p.CSRC = make([]uint32, rawPacket[0]&ccMask)
+	h.Version = rawPacket[0] >> versionShift & versionMask
+	h.Padding = (rawPacket[0] >> paddingShift & paddingMask) > 0
+	h.Extension = (rawPacket[0] >> extensionShift & extensionMask) > 0
+	h.CSRC = make([]uint32, rawPacket[0]&ccMask)
 
-	p.Marker = (rawPacket[1] >> markerShift & markerMask) > 0
-	p.PayloadType = rawPacket[1] & ptMask
+	h.Marker = (rawPacket[1] >> markerShift & markerMask) > 0
+	h.PayloadType = rawPacket[1] & ptMask
 
-	p.SequenceNumber = binary.BigEndian.Uint16(rawPacket[seqNumOffset : seqNumOffset+seqNumLength])
-	p.Timestamp = binary.BigEndian.Uint32(rawPacket[timestampOffset : timestampOffset+timestampLength])
-	p.SSRC = binary.BigEndian.Uint32(rawPacket[ssrcOffset : ssrcOffset+ssrcLength])
+	h.SequenceNumber = binary.BigEndian.Uint16(rawPacket[seqNumOffset : seqNumOffset+seqNumLength])
+	h.Timestamp = binary.BigEndian.Uint32(rawPacket[timestampOffset : timestampOffset+timestampLength])
+	h.SSRC = binary.BigEndian.Uint32(rawPacket[ssrcOffset : ssrcOffset+ssrcLength])
 
-	currOffset := csrcOffset + (len(p.CSRC) * csrcLength)
+	currOffset := csrcOffset + (len(h.CSRC) * csrcLength)
 	if len(rawPacket) < currOffset {
 		return errors.Errorf("RTP header size insufficient; %d < %d", len(rawPacket), currOffset)
 	}
 
-	for i := range p.CSRC {
+	for i := range h.CSRC {
 		offset := csrcOffset + (i * csrcLength)
-		p.CSRC[i] = binary.BigEndian.Uint32(rawPacket[offset:offset])
+		h.CSRC[i] = binary.BigEndian.Uint32(rawPacket[offset:])
 	}
 
-	if p.Extension {
-		p.ExtensionProfile = binary.BigEndian.Uint16(rawPacket[currOffset:])
+	if h.Extension {
+		if len(rawPacket) < currOffset+4 {
+			return errors.Errorf("RTP header size insufficient for extension; %d < %d", len(rawPacket), currOffset+4)
+		}
+
+		h.ExtensionProfile = binary.BigEndian.Uint16(rawPacket[currOffset:])
 		currOffset += 2
-		extensionLength := binary.BigEndian.Uint16(rawPacket[currOffset:])
+		extensionLength := int(binary.BigEndian.Uint16(rawPacket[currOffset:])) * 4
 		currOffset += 2
-		p.ExtensionPayload = rawPacket[currOffset : currOffset+int(extensionLength)]
-		currOffset += len(p.ExtensionPayload) * 4
+
+		if len(rawPacket) < currOffset+extensionLength {
+			return errors.Errorf("RTP header size insufficient for extension; %d < %d", len(rawPacket), currOffset+extensionLength)
+		}
+		h.ExtensionPayload = rawPacket[currOffset : currOffset+extensionLength]
+		currOffset += extensionLength
+
+		var err error
+		if h.Extensions, err = unmarshalExtensions(h.ExtensionProfile, h.ExtensionPayload); err != nil {
+			return err
+		}
+	} else {
+		h.ExtensionProfile = 0
+		h.ExtensionPayload = nil
+		h.Extensions = nil
 	}
 
-	p.Payload = rawPacket[currOffset:]
-	p.PayloadOffset = currOffset
+	h.PayloadOffset = currOffset
+	return nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the Packet this method is called upon
+func (p *Packet) Unmarshal(rawPacket []byte) error {
+	if err := p.Header.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	end := len(rawPacket)
+	p.PaddingSize = 0
+	if p.Padding {
+		// https://tools.ietf.org/html/rfc3550#section-5.1
+		// The last octet of the padding contains a count of how many
+		// padding octets should be ignored, including itself.
+		if end <= p.PayloadOffset {
+			return errors.Errorf("RTP packet has padding bit set, but no padding")
+		}
+		p.PaddingSize = rawPacket[end-1]
+		if p.PaddingSize == 0 || int(p.PaddingSize) > end-p.PayloadOffset {
+			return errors.Errorf("RTP padding size %d is invalid for a payload of %d octets", p.PaddingSize, end-p.PayloadOffset)
+		}
+		end -= int(p.PaddingSize)
+	}
+
+	p.Payload = rawPacket[p.PayloadOffset:end]
 	p.Raw = rawPacket
 	return nil
 }
 
-// Marshal returns a raw RTP packet for the instance it is called upon
-func (p *Packet) Marshal() ([]byte, error) {
+// MarshalSize returns the size of the header once marshaled
+func (h *Header) MarshalSize() int {
+	size := csrcOffset + (len(h.CSRC) * csrcLength)
+	if h.Extension {
+		if isRFC8285Profile(h.ExtensionProfile) {
+			if raw, err := marshalExtensions(h.ExtensionProfile, h.Extensions); err == nil {
+				return size + 4 + len(raw)
+			}
+		}
+		size += 4 + len(h.ExtensionPayload)
+	}
+	return size
+}
+
+// MarshalTo serializes the header into buf, which must be at least MarshalSize() long, and returns
+// the amount of bytes written. The header is not modified, so it can be marshaled concurrently
+func (h *Header) MarshalTo(buf []byte) (int, error) {
+	return h.marshalTo(buf, h.Padding)
+}
 
+func (h *Header) marshalTo(buf []byte, padding bool) (int, error) {
 	/*
 	 *  0                   1                   2                   3
 	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
@@ -123,53 +195,112 @@ func (p *Packet) Marshal() ([]byte, error) {
 	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
 	 */
 
-	rawPacketLength := 12 + (len(p.CSRC) * csrcLength)
-	if p.Extension {
-		rawPacketLength += 4 + len(p.ExtensionPayload)
+	if len(h.CSRC) > ccMask {
+		return 0, errors.Errorf("RTP header can only hold %d CSRCs, got %d", ccMask, len(h.CSRC))
 	}
-	rawPacket := make([]byte, rawPacketLength)
 
-	rawPacket[0] |= p.Version << versionShift
-	if p.Padding {
-		rawPacket[0] |= 1 << paddingShift
+	// RFC 8285 extensions are marshaled from Extensions, the header itself is left as it is
+	extensionPayload := h.ExtensionPayload
+	if h.Extension && isRFC8285Profile(h.ExtensionProfile) {
+		var err error
+		if extensionPayload, err = marshalExtensions(h.ExtensionProfile, h.Extensions); err != nil {
+			return 0, err
+		}
 	}
-	if p.Extension {
-		rawPacket[0] |= 1 << extensionShift
+	if h.Extension && len(extensionPayload)%4 != 0 {
+		return 0, errors.Errorf("RTP header extension must be a multiple of 4 octets, got %d", len(extensionPayload))
 	}
-	rawPacket[0] |= uint8(len(p.CSRC))
 
-	if p.Marker {
-		rawPacket[1] |= 1 << markerShift
+	size := h.MarshalSize()
+	if len(buf) < size {
+		return 0, errors.Errorf("buffer too small for RTP header; %d < %d", len(buf), size)
 	}
-	rawPacket[1] |= p.PayloadType
 
-	binary.BigEndian.PutUint16(rawPacket[seqNumOffset:], p.SequenceNumber)
-	binary.BigEndian.PutUint32(rawPacket[timestampOffset:], p.Timestamp)
-	binary.BigEndian.PutUint32(rawPacket[ssrcOffset:], p.SSRC)
+	buf[0] = h.Version << versionShift
+	if padding {
+		buf[0] |= 1 << paddingShift
+	}
+	if h.Extension {
+		buf[0] |= 1 << extensionShift
+	}
+	buf[0] |= uint8(len(h.CSRC))
 
-	for i, csrc := range p.CSRC {
-		binary.BigEndian.PutUint32(rawPacket[csrcOffset+(i*csrcLength):], csrc)
+	buf[1] = h.PayloadType & ptMask
+	if h.Marker {
+		buf[1] |= 1 << markerShift
 	}
 
-	currOffset := csrcOffset + (len(p.CSRC) * csrcLength)
+	binary.BigEndian.PutUint16(buf[seqNumOffset:], h.SequenceNumber)
+	binary.BigEndian.PutUint32(buf[timestampOffset:], h.Timestamp)
+	binary.BigEndian.PutUint32(buf[ssrcOffset:], h.SSRC)
 
-	for i := range p.CSRC {
-		offset := csrcOffset + (i * csrcLength)
-		p.CSRC[i] = binary.BigEndian.Uint32(rawPacket[offset:offset])
+	for i, csrc := range h.CSRC {
+		binary.BigEndian.PutUint32(buf[csrcOffset+(i*csrcLength):], csrc)
 	}
 
-	if p.Extension {
-		binary.BigEndian.PutUint16(rawPacket[currOffset:], p.ExtensionProfile)
+	currOffset := csrcOffset + (len(h.CSRC) * csrcLength)
+	if h.Extension {
+		binary.BigEndian.PutUint16(buf[currOffset:], h.ExtensionProfile)
 		currOffset += 2
-		binary.BigEndian.PutUint16(rawPacket[currOffset:], uint16(len(p.ExtensionPayload))/4)
+		binary.BigEndian.PutUint16(buf[currOffset:], uint16(len(extensionPayload)/4))
 		currOffset += 2
-		copy(rawPacket[currOffset:], p.ExtensionPayload)
+		currOffset += copy(buf[currOffset:], extensionPayload)
 	}
 
-	p.PayloadOffset = csrcOffset + (len(p.CSRC) * csrcLength)
+	return currOffset, nil
+}
 
-	rawPacket = append(rawPacket, p.Payload...)
-	p.Raw = rawPacket
+// Marshal returns a raw RTP header for the instance it is called upon
+func (h *Header) Marshal() ([]byte, error) {
+	buf := make([]byte, h.MarshalSize())
+	n, err := h.MarshalTo(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// MarshalSize returns the size of the packet once marshaled
+func (p *Packet) MarshalSize() int {
+	return p.Header.MarshalSize() + len(p.Payload) + int(p.PaddingSize)
+}
+
+// MarshalTo serializes the packet into buf, which must be at least MarshalSize() long, and returns
+// the amount of bytes written
+func (p *Packet) MarshalTo(buf []byte) (int, error) {
+	// The P bit is set by PaddingSize, the packet is not modified so it can be sent by several goroutines
+	padding := p.PaddingSize != 0
+	n, err := p.Header.marshalTo(buf, padding)
+	if err != nil {
+		return 0, err
+	}
+
+	size := n + len(p.Payload) + int(p.PaddingSize)
+	if len(buf) < size {
+		return 0, errors.Errorf("buffer too small for RTP packet; %d < %d", len(buf), size)
+	}
+
+	n += copy(buf[n:], p.Payload)
+	if padding {
+		// https://tools.ietf.org/html/rfc3550#section-5.1
+		// The padding octets are zero, except the last one which holds the padding count
+		for i := n; i < size-1; i++ {
+			buf[i] = 0
+		}
+		buf[size-1] = p.PaddingSize
+	}
+
+	return size, nil
+}
+
+// Marshal returns a raw RTP packet for the instance it is called upon
+func (p *Packet) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, p.MarshalSize())
+	n, err := p.MarshalTo(rawPacket)
+	if err != nil {
+		return nil, err
+	}
 
-	return rawPacket, nil
+	p.Raw = rawPacket[:n]
+	return p.Raw, nil
 }
@@ -0,0 +1,142 @@
+package rtp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPacketUnmarshal(t *testing.T) {
+	rawPkt := []byte{
+		0xa2, 0xe0, 0x69, 0x8f, 0xd9, 0xc2, 0x93, 0xda, 0x1c, 0x64,
+		0x27, 0x82, 0x00, 0x00, 0x11, 0x11, 0x00, 0x00, 0x22, 0x22,
+		0x98, 0x36, 0xbe, 0x88, 0x00, 0x00, 0x00, 0x03,
+	}
+
+	p := &Packet{}
+	if err := p.Unmarshal(rawPkt); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(p.CSRC, []uint32{0x1111, 0x2222}) {
+		t.Errorf("CSRCs were not parsed, got %v", p.CSRC)
+	}
+	if !p.Padding || p.PaddingSize != 3 {
+		t.Errorf("Padding was not parsed, got %t %d", p.Padding, p.PaddingSize)
+	}
+	if !bytes.Equal(p.Payload, []byte{0x98, 0x36, 0xbe, 0x88, 0x00}) {
+		t.Errorf("Padding was not stripped from the payload, got %v", p.Payload)
+	}
+	if p.PayloadOffset != 20 || !p.Marker || p.PayloadType != 96 || p.SequenceNumber != 27023 || p.SSRC != 476325762 {
+		t.Errorf("Header was not parsed correctly: %+v", p.Header)
+	}
+
+	raw, err := p.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	} else if !bytes.Equal(raw, rawPkt) {
+		t.Errorf("Marshal did not round trip\n%v\n%v", raw, rawPkt)
+	}
+}
+
+func TestPacketUnmarshalInvalidPadding(t *testing.T) {
+	for _, rawPkt := range [][]byte{
+		// Padding bit without any octets after the header
+		{0xa0, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01},
+		// Padding count larger than the payload
+		{0xa0, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x05},
+		// Padding count of zero
+		{0xa0, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00},
+	} {
+		if err := (&Packet{}).Unmarshal(rawPkt); err == nil {
+			t.Errorf("Unmarshal accepted invalid padding %v", rawPkt)
+		}
+	}
+}
+
+func TestPaddingOnlyPacket(t *testing.T) {
+	p := &Packet{Header: Header{Version: 2, PayloadType: 96, SSRC: 5}, PaddingSize: 224}
+	raw, err := p.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	} else if len(raw) != headerLength+224 {
+		t.Fatalf("Marshal wrote %d octets, expected %d", len(raw), headerLength+224)
+	}
+
+	parsed := &Packet{}
+	if err := parsed.Unmarshal(raw); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	} else if len(parsed.Payload) != 0 {
+		t.Errorf("padding-only packet has a payload of %d octets", len(parsed.Payload))
+	}
+}
+
+func TestHeaderMarshalTo(t *testing.T) {
+	h := &Header{Version: 2, Marker: true, PayloadType: 111, SequenceNumber: 1, Timestamp: 2, SSRC: 3, CSRC: []uint32{4}}
+
+	buf := make([]byte, h.MarshalSize()-1)
+	if _, err := h.MarshalTo(buf); err == nil {
+		t.Errorf("MarshalTo accepted a buffer that was too small")
+	}
+
+	buf = make([]byte, h.MarshalSize())
+	n, err := h.MarshalTo(buf)
+	if err != nil {
+		t.Fatalf("MarshalTo failed: %v", err)
+	}
+
+	// The payload follows the header that was written
+	expected := *h
+	expected.PayloadOffset = n
+
+	parsed := &Header{}
+	if err := parsed.Unmarshal(buf[:n]); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	} else if !reflect.DeepEqual(parsed, &expected) {
+		t.Errorf("Header did not round trip\n%+v\n%+v", parsed, &expected)
+	}
+}
+
+func TestPacketMarshalToReadOnly(t *testing.T) {
+	p := &Packet{Header: Header{Version: 2, SSRC: 3}, Payload: []byte{0x01}, PaddingSize: 3}
+	expected := *p
+
+	// The P bit is written for the padding without being set on the packet, which may be sent concurrently
+	buf := make([]byte, p.MarshalSize())
+	if _, err := p.MarshalTo(buf); err != nil {
+		t.Fatalf("MarshalTo failed: %v", err)
+	} else if !reflect.DeepEqual(p, &expected) {
+		t.Errorf("MarshalTo modified the packet\n%+v\n%+v", p, &expected)
+	}
+
+	parsed := &Packet{}
+	if err := parsed.Unmarshal(buf); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	} else if !parsed.Padding || parsed.PaddingSize != 3 || !reflect.DeepEqual(parsed.Payload, []byte{0x01}) {
+		t.Errorf("expected the padding to be marshaled, got %+v", parsed)
+	}
+}
+
+func TestHeaderMarshalToExtensions(t *testing.T) {
+	h := &Header{Version: 2, SSRC: 3}
+	if err := h.SetExtension(1, []byte{0xAA}); err != nil {
+		t.Fatal(err)
+	}
+	expected := *h
+
+	// The padded extension payload is only written to the buffer
+	buf := make([]byte, h.MarshalSize())
+	if _, err := h.MarshalTo(buf); err != nil {
+		t.Fatalf("MarshalTo failed: %v", err)
+	}
+	if h.ExtensionPayload != nil || !reflect.DeepEqual(h.Extensions, expected.Extensions) {
+		t.Errorf("MarshalTo changed the extensions of the header: %+v", h)
+	}
+
+	parsed := &Header{}
+	if err := parsed.Unmarshal(buf); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	} else if payload := parsed.GetExtension(1); !reflect.DeepEqual(payload, []byte{0xAA}) {
+		t.Errorf("expected the extension to be marshaled, got %x", payload)
+	}
+}
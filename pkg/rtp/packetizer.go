@@ -1,6 +1,7 @@
 package rtp
 
 import (
+	"fmt"
 	"math/rand"
 	"time"
 )
@@ -10,9 +11,24 @@ type Payloader interface {
 	Payload(mtu int, payload []byte) [][]byte
 }
 
+// FramedPayloader is a Payloader that splits a payload into frames of a fixed duration, like audio codecs
+// sent with a fixed ptime. Every frame is sent with its own timestamp, the samples given to Packetize are ignored
+type FramedPayloader interface {
+	Payloader
+
+	// FrameSamples returns the duration of a payload returned by Payload, in RTP clock units
+	FrameSamples(payload []byte) uint32
+}
+
 // Packetizer packetizes a payload
 type Packetizer interface {
 	Packetize(payload []byte, samples uint32) []*Packet
+
+	// EnableAbsSendTime stamps every packet with the time it was packetized, using the given extension ID
+	EnableAbsSendTime(id uint8)
+
+	// SetHeaderExtension stamps every following packet with the given extension, a nil extension stops stamping it
+	SetHeaderExtension(id uint8, extension HeaderExtension) error
 }
 
 type packetizer struct {
@@ -23,6 +39,9 @@ type packetizer struct {
 	Sequencer   Sequencer
 	Timestamp   uint32
 	ClockRate   uint32
+
+	absSendTimeID    uint8
+	headerExtensions []Extension
 }
 
 // NewPacketizer returns a new instance of a Packetizer for a specific payloader
@@ -31,35 +50,101 @@ func NewPacketizer(mtu int, pt uint8, ssrc uint32, payloader Payloader, sequence
 	r := rand.New(rs)
 
 	return &packetizer{
-		mtu,
-		pt,
-		ssrc,
-		payloader,
-		sequencer,
-		r.Uint32(),
-		clockRate,
+		MTU:         mtu,
+		PayloadType: pt,
+		SSRC:        ssrc,
+		Payloader:   payloader,
+		Sequencer:   sequencer,
+		Timestamp:   r.Uint32(),
+		ClockRate:   clockRate,
 	}
 }
 
-// Packetize packetizes the payload of an RTP packet and returns one or more RTP packets
+// EnableAbsSendTime stamps every packet with the time it was packetized, using the given extension ID
+func (p *packetizer) EnableAbsSendTime(id uint8) {
+	p.absSendTimeID = id
+}
+
+// SetHeaderExtension stamps every following packet with the given extension, a nil extension stops stamping it
+func (p *packetizer) SetHeaderExtension(id uint8, extension HeaderExtension) error {
+	var payload []byte
+	if extension != nil {
+		var err error
+		if payload, err = extension.Marshal(); err != nil {
+			return err
+		}
+	}
+
+	for i := len(p.headerExtensions) - 1; i >= 0; i-- {
+		if p.headerExtensions[i].ID == id {
+			p.headerExtensions = append(p.headerExtensions[:i], p.headerExtensions[i+1:]...)
+		}
+	}
+	if payload != nil {
+		p.headerExtensions = append(p.headerExtensions, Extension{ID: id, Payload: payload})
+	}
+	return nil
+}
+
+// Packetize packetizes the payload of an RTP packet and returns one or more RTP packets. The marker is set on the
+// last packet, which ends a video frame, senders of audio set it on the first packet of a talkspurt instead
 func (p *packetizer) Packetize(payload []byte, samples uint32) []*Packet {
-	payloads := p.Payloader.Payload(p.MTU-12, payload)
+	headerExtensions := p.headerExtensions
+	if p.absSendTimeID != 0 {
+		sendTime, err := NewAbsSendTimeExtension(time.Now()).Marshal()
+		if err != nil {
+			fmt.Printf("Failed to marshal abs-send-time: %v \n", err)
+		} else {
+			headerExtensions = append(headerExtensions[:len(headerExtensions):len(headerExtensions)], Extension{ID: p.absSendTimeID, Payload: sendTime})
+		}
+	}
+
+	// Leave room for the extension block, worst case is the two-byte form
+	headerExtensionSize := 0
+	if len(headerExtensions) != 0 {
+		headerExtensionSize = 4
+		for _, e := range headerExtensions {
+			headerExtensionSize += 2 + len(e.Payload)
+		}
+		headerExtensionSize += (4 - headerExtensionSize%4) % 4
+	}
+
+	payloads := p.Payloader.Payload(p.MTU-12-headerExtensionSize, payload)
 	packets := make([]*Packet, len(payloads))
 
+	// Framed payloads are timed by their content, instead of the samples given by the caller
+	timestamp := p.Timestamp
+	framedPayloader, isFramed := p.Payloader.(FramedPayloader)
+
 	for i, pp := range payloads {
 		packets[i] = &Packet{
-			Version:        2,
-			Padding:        false,
-			Extension:      false,
-			Marker:         i == len(payloads)-1,
-			PayloadType:    p.PayloadType,
-			SequenceNumber: p.Sequencer.NextSequenceNumber(),
-			Timestamp:      p.Timestamp, // Figure out how to do timestamps
-			SSRC:           p.SSRC,
-			Payload:        pp,
+			Header: Header{
+				Version:        2,
+				Padding:        false,
+				Extension:      false,
+				Marker:         i == len(payloads)-1,
+				PayloadType:    p.PayloadType,
+				SequenceNumber: p.Sequencer.NextSequenceNumber(),
+				Timestamp:      timestamp,
+				SSRC:           p.SSRC,
+			},
+			Payload: pp,
+		}
+		if isFramed {
+			timestamp += framedPayloader.FrameSamples(pp)
 		}
+
+		for _, e := range headerExtensions {
+			if err := packets[i].SetExtension(e.ID, e.Payload); err != nil {
+				fmt.Printf("Failed to set RTP header extension %d: %v \n", e.ID, err)
+			}
+		}
+	}
+	if isFramed {
+		p.Timestamp = timestamp
+	} else {
+		p.Timestamp += samples
 	}
-	p.Timestamp += samples
 
 	return packets
 }
@@ -0,0 +1,62 @@
+package rtp
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	playoutDelayExtensionSize = 3
+	playoutDelayMax           = 0xFFF
+	playoutDelayGranularity   = 10 * time.Millisecond
+)
+
+// PlayoutDelayExtension is the minimum and maximum delay the sender wants the receiver to apply before playout
+// https://webrtc.org/experiments/rtp-hdrext/playout-delay/
+type PlayoutDelayExtension struct {
+	// MinDelay and MaxDelay are in 10ms units
+	MinDelay, MaxDelay uint16
+}
+
+// Min returns MinDelay as a time.Duration
+func (p *PlayoutDelayExtension) Min() time.Duration {
+	return time.Duration(p.MinDelay) * playoutDelayGranularity
+}
+
+// Max returns MaxDelay as a time.Duration
+func (p *PlayoutDelayExtension) Max() time.Duration {
+	return time.Duration(p.MaxDelay) * playoutDelayGranularity
+}
+
+// Marshal serializes the members to buffer
+func (p *PlayoutDelayExtension) Marshal() ([]byte, error) {
+	if p.MinDelay > playoutDelayMax || p.MaxDelay > playoutDelayMax {
+		return nil, errors.Errorf("playout delay %d-%d overflows 12 bits", p.MinDelay, p.MaxDelay)
+	}
+
+	return []byte{
+		byte(p.MinDelay >> 4),
+		byte(p.MinDelay<<4) | byte(p.MaxDelay>>8),
+		byte(p.MaxDelay),
+	}, nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the members
+func (p *PlayoutDelayExtension) Unmarshal(rawExtension []byte) error {
+	if len(rawExtension) < playoutDelayExtensionSize {
+		return errors.Errorf("playout delay extension size insufficient; %d < %d", len(rawExtension), playoutDelayExtensionSize)
+	}
+
+	/*
+	 *  0                   1                   2
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |       MIN delay       |       MAX delay       |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	p.MinDelay = uint16(rawExtension[0])<<4 | uint16(rawExtension[1])>>4
+	p.MaxDelay = uint16(rawExtension[1]&0x0F)<<8 | uint16(rawExtension[2])
+	return nil
+}
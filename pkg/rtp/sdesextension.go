@@ -0,0 +1,29 @@
+package rtp
+
+import (
+	"github.com/pkg/errors"
+)
+
+// SDESItemExtension carries a single RTCP SDES item in an RTP header extension, it is used for
+// the MID (urn:ietf:params:rtp-hdrext:sdes:mid) and RID (urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id)
+// https://tools.ietf.org/html/rfc7941
+type SDESItemExtension struct {
+	Value string
+}
+
+// Marshal serializes the members to buffer
+func (s *SDESItemExtension) Marshal() ([]byte, error) {
+	if len(s.Value) == 0 || len(s.Value) > twoByteExtensionMaxLength {
+		return nil, errors.Errorf("SDES item %q must be between 1 and %d bytes", s.Value, twoByteExtensionMaxLength)
+	}
+	return []byte(s.Value), nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the members
+func (s *SDESItemExtension) Unmarshal(rawExtension []byte) error {
+	if len(rawExtension) == 0 {
+		return errors.Errorf("SDES item extension is empty")
+	}
+	s.Value = string(rawExtension)
+	return nil
+}
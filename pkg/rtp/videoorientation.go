@@ -0,0 +1,80 @@
+package rtp
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	videoOrientationExtensionSize = 1
+)
+
+// CameraDirection is the direction the capturing camera faces
+type CameraDirection int
+
+// List of supported CameraDirections
+const (
+	CameraFront CameraDirection = iota
+	CameraBack
+)
+
+// VideoRotation is the clockwise rotation a receiver has to apply before rendering
+type VideoRotation int
+
+// List of supported VideoRotations
+const (
+	VideoRotation0 VideoRotation = iota
+	VideoRotation90
+	VideoRotation180
+	VideoRotation270
+)
+
+// Degrees returns the rotation in degrees
+func (v VideoRotation) Degrees() int {
+	return int(v) * 90
+}
+
+// VideoOrientationExtension is the Coordination of Video Orientation (CVO) extension
+// 3GPP TS 26.114 Section 7.4.5
+type VideoOrientationExtension struct {
+	Direction CameraDirection
+	Flip      bool
+	Rotation  VideoRotation
+}
+
+// Marshal serializes the members to buffer
+func (v *VideoOrientationExtension) Marshal() ([]byte, error) {
+	if v.Rotation < VideoRotation0 || v.Rotation > VideoRotation270 {
+		return nil, errors.Errorf("invalid video rotation %d", v.Rotation)
+	}
+
+	raw := uint8(v.Rotation)
+	if v.Flip {
+		raw |= 0x04
+	}
+	if v.Direction == CameraBack {
+		raw |= 0x08
+	}
+	return []byte{raw}, nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the members
+func (v *VideoOrientationExtension) Unmarshal(rawExtension []byte) error {
+	if len(rawExtension) < videoOrientationExtensionSize {
+		return errors.Errorf("video orientation extension size insufficient; %d < %d", len(rawExtension), videoOrientationExtensionSize)
+	}
+
+	/*
+	 *  0 1 2 3 4 5 6 7
+	 * +-+-+-+-+-+-+-+-+
+	 * |0 0 0 0 C F R R|
+	 * +-+-+-+-+-+-+-+-+
+	 */
+
+	v.Rotation = VideoRotation(rawExtension[0] & 0x03)
+	v.Flip = rawExtension[0]&0x04 != 0
+	v.Direction = CameraFront
+	if rawExtension[0]&0x08 != 0 {
+		v.Direction = CameraBack
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package sdp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Codec is a format of a MediaDescription, as described by its rtpmap, fmtp and rtcp-fb attributes
+// https://tools.ietf.org/html/rfc4566#section-6
+type Codec struct {
+	PayloadType uint8
+	Name        string
+	ClockRate   uint32
+
+	// Channels is the encoding parameter of audio codecs, 0 if it is omitted
+	Channels uint16
+
+	Fmtp         string
+	RTCPFeedback []string
+}
+
+// staticPayloadTypes are the payload types with a static encoding, they may be used without an rtpmap attribute
+// https://tools.ietf.org/html/rfc3551#section-6
+var staticPayloadTypes = map[uint8]Codec{
+	0:  {Name: "PCMU", ClockRate: 8000},
+	3:  {Name: "GSM", ClockRate: 8000},
+	4:  {Name: "G723", ClockRate: 8000},
+	5:  {Name: "DVI4", ClockRate: 8000},
+	6:  {Name: "DVI4", ClockRate: 16000},
+	7:  {Name: "LPC", ClockRate: 8000},
+	8:  {Name: "PCMA", ClockRate: 8000},
+	9:  {Name: "G722", ClockRate: 8000},
+	10: {Name: "L16", ClockRate: 44100, Channels: 2},
+	11: {Name: "L16", ClockRate: 44100},
+	12: {Name: "QCELP", ClockRate: 8000},
+	13: {Name: "CN", ClockRate: 8000},
+	14: {Name: "MPA", ClockRate: 90000},
+	15: {Name: "G728", ClockRate: 8000},
+	16: {Name: "DVI4", ClockRate: 11025},
+	17: {Name: "DVI4", ClockRate: 22050},
+	18: {Name: "G729", ClockRate: 8000},
+	25: {Name: "CelB", ClockRate: 90000},
+	26: {Name: "JPEG", ClockRate: 90000},
+	28: {Name: "nv", ClockRate: 90000},
+	31: {Name: "H261", ClockRate: 90000},
+	32: {Name: "MPV", ClockRate: 90000},
+	33: {Name: "MP2T", ClockRate: 90000},
+	34: {Name: "H263", ClockRate: 90000},
+}
+
+// staticCodec returns the codec of a static payload type
+func staticCodec(format string) (*Codec, bool) {
+	payloadType, err := strconv.ParseUint(format, 10, 8)
+	if err != nil {
+		return nil, false
+	}
+	codec, ok := staticPayloadTypes[uint8(payloadType)]
+	if !ok {
+		return nil, false
+	}
+	codec.PayloadType = uint8(payloadType)
+	return &codec, true
+}
+
+// ParseRTPMap parses the value of an rtpmap attribute, <payload type> <encoding name>/<clock rate>[/<encoding parameters>]
+func ParseRTPMap(value string) (*Codec, error) {
+	split := strings.Fields(value)
+	if len(split) != 2 {
+		return nil, errors.Errorf("rtpmap must have a payload type and an encoding: %s", value)
+	}
+
+	payloadType, err := strconv.ParseUint(split[0], 10, 8)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse rtpmap payload type")
+	}
+
+	encoding := strings.Split(split[1], "/")
+	if len(encoding) < 2 {
+		return nil, errors.Errorf("rtpmap encoding must have a clock rate: %s", value)
+	}
+	clockRate, err := strconv.ParseUint(encoding[1], 10, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse rtpmap clock rate")
+	}
+
+	codec := &Codec{PayloadType: uint8(payloadType), Name: encoding[0], ClockRate: uint32(clockRate)}
+	if len(encoding) > 2 {
+		channels, err := strconv.ParseUint(encoding[2], 10, 16)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse rtpmap channels")
+		}
+		codec.Channels = uint16(channels)
+	}
+	return codec, nil
+}
+
+// RTPMap returns the value of the codec's rtpmap attribute
+func (c *Codec) RTPMap() string {
+	rtpmap := strconv.Itoa(int(c.PayloadType)) + " " + c.Name + "/" + strconv.Itoa(int(c.ClockRate))
+	if c.Channels != 0 {
+		rtpmap += "/" + strconv.Itoa(int(c.Channels))
+	}
+	return rtpmap
+}
+
+// Attributes returns the rtpmap, fmtp and rtcp-fb attributes describing the codec
+func (c *Codec) Attributes() []Attribute {
+	payloadType := strconv.Itoa(int(c.PayloadType))
+	attributes := []Attribute{NewAttribute("rtpmap", c.RTPMap())}
+	if c.Fmtp != "" {
+		attributes = append(attributes, NewAttribute("fmtp", payloadType+" "+c.Fmtp))
+	}
+	for _, feedback := range c.RTCPFeedback {
+		attributes = append(attributes, NewAttribute("rtcp-fb", payloadType+" "+feedback))
+	}
+	return attributes
+}
+
+// ParseFmtp splits fmtp parameters, like "minptime=10;useinbandfec=1", into a map keyed by the lower case parameter names
+// https://tools.ietf.org/html/rfc4566#section-6
+func ParseFmtp(fmtp string) map[string]string {
+	parameters := map[string]string{}
+	for _, parameter := range strings.Split(fmtp, ";") {
+		split := strings.SplitN(strings.TrimSpace(parameter), "=", 2)
+		if split[0] == "" {
+			continue
+		}
+		if len(split) == 2 {
+			parameters[strings.ToLower(split[0])] = split[1]
+		} else {
+			parameters[strings.ToLower(split[0])] = ""
+		}
+	}
+	return parameters
+}
+
+// ExtMap maps an RTP header extension URI to the ID it is sent with
+// extmap:<value>["/"<direction>] <URI> <extensionattributes>
+// https://tools.ietf.org/html/rfc8285#section-8
+type ExtMap struct {
+	ID uint8
+
+	// Direction is empty if the extension is used in the direction of the media
+	Direction Direction
+
+	URI                 string
+	ExtensionAttributes string
+}
+
+// ParseExtMap parses the value of an extmap attribute
+func ParseExtMap(value string) (*ExtMap, error) {
+	split := strings.Fields(value)
+	if len(split) < 2 {
+		return nil, errors.Errorf("extmap must have an ID and a URI: %s", value)
+	}
+
+	idAndDirection := strings.SplitN(split[0], "/", 2)
+	id, err := strconv.ParseUint(idAndDirection[0], 10, 8)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse extmap ID")
+	} else if id == 0 {
+		return nil, errors.Errorf("extmap ID 0 is reserved")
+	}
+
+	extMap := &ExtMap{ID: uint8(id), URI: split[1], ExtensionAttributes: strings.Join(split[2:], " ")}
+	if len(idAndDirection) == 2 {
+		extMap.Direction = Direction(idAndDirection[1])
+	}
+	return extMap, nil
+}
+
+func (e *ExtMap) String() string {
+	value := strconv.Itoa(int(e.ID))
+	if e.Direction != "" {
+		value += "/" + string(e.Direction)
+	}
+	value += " " + e.URI
+	if e.ExtensionAttributes != "" {
+		value += " " + e.ExtensionAttributes
+	}
+	return value
+}
+
+// SSRC is a media source of a MediaDescription, with the source attributes describing it
+// ssrc:<ssrc-id> <attribute>:<value>
+// https://tools.ietf.org/html/rfc5576#section-4.1
+type SSRC struct {
+	SSRC  uint32
+	CNAME string
+
+	// MSID is the MediaStream and MediaStreamTrack the source belongs to, "<stream id> <track id>"
+	// https://tools.ietf.org/html/draft-ietf-mmusic-msid-16#section-2
+	MSID string
+
+	// MSLabel and Label are the stream and track IDs of Chrome's Plan B descriptions
+	MSLabel string
+	Label   string
+}
+
+// Attributes returns the ssrc attributes of the source, one for each of its attributes that is set
+func (s *SSRC) Attributes() (attributes []Attribute) {
+	for _, a := range []struct{ key, value string }{
+		{"cname", s.CNAME},
+		{"msid", s.MSID},
+		{"mslabel", s.MSLabel},
+		{"label", s.Label},
+	} {
+		if a.value != "" {
+			attributes = append(attributes, NewAttribute("ssrc", strconv.FormatUint(uint64(s.SSRC), 10)+" "+a.key+":"+a.value))
+		}
+	}
+	return attributes
+}
+
+// SSRCGroup is a relation between sources of a MediaDescription, like FID for retransmission or FEC-FR for FEC
+// ssrc-group:<semantics> <ssrc-id> ...
+// https://tools.ietf.org/html/rfc5576#section-4.2
+type SSRCGroup struct {
+	Semantics string
+	SSRCs     []uint32
+}
+
+// ParseSSRCGroup parses the value of an ssrc-group attribute
+func ParseSSRCGroup(value string) (*SSRCGroup, error) {
+	split := strings.Fields(value)
+	if len(split) < 2 {
+		return nil, errors.Errorf("ssrc-group must have semantics and a source: %s", value)
+	}
+
+	group := &SSRCGroup{Semantics: split[0]}
+	for _, s := range split[1:] {
+		ssrc, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse ssrc-group source")
+		}
+		group.SSRCs = append(group.SSRCs, uint32(ssrc))
+	}
+	return group, nil
+}
+
+func (g *SSRCGroup) String() string {
+	value := g.Semantics
+	for _, ssrc := range g.SSRCs {
+		value += " " + strconv.FormatUint(uint64(ssrc), 10)
+	}
+	return value
+}
+
+// Candidate is an ICE candidate
+// candidate:<foundation> <component-id> <transport> <priority> <address> <port> typ <type> [raddr <address>] [rport <port>] ...
+// https://tools.ietf.org/html/rfc5245#section-15.1
+type Candidate struct {
+	Foundation string
+	Component  uint16
+	Transport  string
+	Priority   uint32
+	Address    string
+	Port       int
+	Type       string
+
+	// RelatedAddress and RelatedPort are set for reflexive and relayed candidates
+	RelatedAddress string
+	RelatedPort    int
+
+	// ExtensionAttributes are the name value pairs after the candidate, like "generation 0"
+	ExtensionAttributes [][2]string
+}
+
+// ParseCandidate parses the value of a candidate attribute
+func ParseCandidate(value string) (*Candidate, error) {
+	split := strings.Fields(value)
+	if len(split) < 8 || split[6] != "typ" {
+		return nil, errors.Errorf("candidate must have a foundation, component, transport, priority, address, port and type: %s", value)
+	}
+
+	component, err := strconv.ParseUint(split[1], 10, 16)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse candidate component")
+	}
+	priority, err := strconv.ParseUint(split[3], 10, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse candidate priority")
+	}
+	port, err := strconv.Atoi(split[5])
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse candidate port")
+	}
+
+	c := &Candidate{
+		Foundation: split[0],
+		Component:  uint16(component),
+		Transport:  split[2],
+		Priority:   uint32(priority),
+		Address:    split[4],
+		Port:       port,
+		Type:       split[7],
+	}
+
+	rest := split[8:]
+	if len(rest)%2 != 0 {
+		return nil, errors.Errorf("candidate extension attributes must be name value pairs: %s", value)
+	}
+	for i := 0; i < len(rest); i += 2 {
+		switch rest[i] {
+		case "raddr":
+			c.RelatedAddress = rest[i+1]
+		case "rport":
+			if c.RelatedPort, err = strconv.Atoi(rest[i+1]); err != nil {
+				return nil, errors.Wrapf(err, "Failed to parse candidate related port")
+			}
+		default:
+			c.ExtensionAttributes = append(c.ExtensionAttributes, [2]string{rest[i], rest[i+1]})
+		}
+	}
+	return c, nil
+}
+
+func (c *Candidate) String() string {
+	value := c.Foundation + " " +
+		strconv.Itoa(int(c.Component)) + " " +
+		c.Transport + " " +
+		strconv.FormatUint(uint64(c.Priority), 10) + " " +
+		c.Address + " " +
+		strconv.Itoa(c.Port) + " typ " + c.Type
+	if c.RelatedAddress != "" {
+		value += " raddr " + c.RelatedAddress + " rport " + strconv.Itoa(c.RelatedPort)
+	}
+	for _, e := range c.ExtensionAttributes {
+		value += " " + e[0] + " " + e[1]
+	}
+	return value
+}
+
+// Fingerprint is the hash of the certificate used for DTLS
+// fingerprint:<hash-func> <fingerprint>
+// https://tools.ietf.org/html/rfc8122#section-5
+type Fingerprint struct {
+	HashFunction string
+	Value        string
+}
+
+// ParseFingerprint parses the value of a fingerprint attribute
+func ParseFingerprint(value string) (*Fingerprint, error) {
+	split := strings.Fields(value)
+	if len(split) != 2 {
+		return nil, errors.Errorf("fingerprint must have a hash function and a value: %s", value)
+	}
+	return &Fingerprint{HashFunction: split[0], Value: split[1]}, nil
+}
+
+func (f *Fingerprint) String() string {
+	return f.HashFunction + " " + f.Value
+}
+
+// Direction is the direction media is sent in, from the point of view of the description it appears in
+// https://tools.ietf.org/html/rfc3264#section-5.1
+type Direction string
+
+// List of media directions
+const (
+	DirectionSendRecv Direction = "sendrecv"
+	DirectionSendOnly Direction = "sendonly"
+	DirectionRecvOnly Direction = "recvonly"
+	DirectionInactive Direction = "inactive"
+)
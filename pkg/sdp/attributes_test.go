@@ -0,0 +1,144 @@
+package sdp
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMediaDescriptionCodecs(t *testing.T) {
+	sd := &SessionDescription{
+		MediaDescriptions: []*MediaDescription{
+			{MediaName: MediaName{Media: "audio", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"111", "0"}}, Attributes: []Attribute{{Key: "rtpmap", Value: "111 opus/48000/2"}, {Key: "rtpmap", Value: "0 PCMU/8000"}}},
+			{MediaName: MediaName{Media: "video", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"102", "96", "97", "9"}}, Attributes: []Attribute{
+				{Key: "rtpmap", Value: "96 VP8/90000"},
+				{Key: "rtcp-fb", Value: "96 nack"},
+				{Key: "rtcp-fb", Value: "* transport-cc"},
+				{Key: "rtpmap", Value: "97 rtx/90000"},
+				{Key: "fmtp", Value: "97 apt=96"},
+				{Key: "fmtp", Value: "102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f"},
+				{Key: "rtpmap", Value: "102 H264/90000"},
+				{Key: "rtpmap", Value: "9 G722/8000"},
+			}},
+		},
+	}
+
+	// rtpmap:96 starts with rtpmap:9, payload types must match exactly
+	expected := []*Codec{
+		{PayloadType: 102, Name: "H264", ClockRate: 90000, Fmtp: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f", RTCPFeedback: []string{"transport-cc"}},
+		{PayloadType: 96, Name: "VP8", ClockRate: 90000, RTCPFeedback: []string{"nack", "transport-cc"}},
+		{PayloadType: 97, Name: "rtx", ClockRate: 90000, Fmtp: "apt=96", RTCPFeedback: []string{"transport-cc"}},
+		{PayloadType: 9, Name: "G722", ClockRate: 8000, RTCPFeedback: []string{"transport-cc"}},
+	}
+	if actual := sd.FindMediaDescription("video").Codecs(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("video codecs were not parsed in m= line order")
+	}
+
+	if audio := sd.FindMediaDescription("audio").Codecs(); len(audio) != 2 || audio[0].Channels != 2 || audio[1].Channels != 0 {
+		t.Errorf("audio channels were not parsed")
+	}
+
+	fmtp := ParseFmtp(expected[0].Fmtp)
+	if fmtp["packetization-mode"] != "1" || fmtp["profile-level-id"] != "42001f" {
+		t.Errorf("fmtp was not parsed: %v", fmtp)
+	}
+}
+
+func TestMediaDescriptionStaticCodecs(t *testing.T) {
+	sd := &SessionDescription{}
+	if err := sd.Unmarshal(`v=0
+o=- 1 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=audio 4000 RTP/AVP 0 8 101 9
+a=fmtp:101 0-15
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Static payload types don't need an rtpmap, dynamic ones without one are skipped
+	expected := []*Codec{
+		{PayloadType: 0, Name: "PCMU", ClockRate: 8000},
+		{PayloadType: 8, Name: "PCMA", ClockRate: 8000},
+		{PayloadType: 9, Name: "G722", ClockRate: 8000},
+	}
+	if actual := sd.MediaDescriptions[0].Codecs(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestMediaDescriptionAttributes(t *testing.T) {
+	sd := &SessionDescription{}
+	if err := sd.Unmarshal(`v=0
+o=- 1 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+a=ice-ufrag:sessionufrag
+a=fingerprint:sha-256 AA:BB
+m=video 9 UDP/TLS/RTP/SAVPF 96
+a=ice-pwd:mediapwd
+a=mid:1
+a=sendonly
+a=extmap:3/recvonly urn:ietf:params:rtp-hdrext:sdes:mid
+a=extmap:0 urn:reserved
+a=ssrc-group:FID 1000 1001
+a=ssrc:1000 cname:user@example
+a=ssrc:1000 msid:stream track
+a=ssrc:1001 cname:user@example
+a=candidate:842163049 1 udp 1677729535 203.0.113.1 3478 typ srflx raddr 192.168.1.2 rport 56143 generation 0
+`); err != nil {
+		t.Fatal(err)
+	}
+	m := sd.MediaDescriptions[0]
+	ufrag, pwd, hasCredentials := m.ICECredentials(sd)
+	_, _, hasMediaCredentials := m.ICECredentials(nil)
+	fingerprint, hasFingerprint := m.Fingerprint(sd)
+	mid, hasMID := m.MID()
+	candidate, _ := m.Attribute("candidate")
+
+	for _, test := range []struct {
+		name     string
+		actual   interface{}
+		expected interface{}
+	}{
+		{"ICECredentials", []interface{}{ufrag, pwd, hasCredentials}, []interface{}{"sessionufrag", "mediapwd", true}},
+		{"ICECredentialsWithoutSession", hasMediaCredentials, false},
+		{"Fingerprint", []interface{}{fingerprint, hasFingerprint}, []interface{}{&Fingerprint{HashFunction: "sha-256", Value: "AA:BB"}, true}},
+		{"MID", []interface{}{mid, hasMID}, []interface{}{"1", true}},
+		{"Direction", m.Direction(), DirectionSendOnly},
+		{"ExtMaps", m.ExtMaps(), []*ExtMap{{ID: 3, Direction: DirectionRecvOnly, URI: "urn:ietf:params:rtp-hdrext:sdes:mid"}}},
+		{"ExtMapStrings", attributeStrings(m.ExtMaps()), []string{"3/recvonly urn:ietf:params:rtp-hdrext:sdes:mid"}},
+		{"SSRCs", m.SSRCs(), []*SSRC{{SSRC: 1000, CNAME: "user@example", MSID: "stream track"}, {SSRC: 1001, CNAME: "user@example"}}},
+		{"SSRCGroups", attributeStrings(m.SSRCGroups()), []string{"FID 1000 1001"}},
+		{"Candidates", m.Candidates(), []*Candidate{{
+			Foundation:          "842163049",
+			Component:           1,
+			Transport:           "udp",
+			Priority:            1677729535,
+			Address:             "203.0.113.1",
+			Port:                3478,
+			Type:                "srflx",
+			RelatedAddress:      "192.168.1.2",
+			RelatedPort:         56143,
+			ExtensionAttributes: [][2]string{{"generation", "0"}},
+		}}},
+		{"CandidateRoundTrip", attributeStrings(m.Candidates()), []string{candidate}},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(test.actual, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, test.actual)
+			}
+		})
+	}
+}
+
+// attributeStrings formats each element of a slice of parsed attributes
+func attributeStrings(values interface{}) []string {
+	v := reflect.ValueOf(values)
+	s := make([]string, v.Len())
+	for i := range s {
+		s[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package sdp
+
+// ConnectionData is the network address media is received at
+// c=<nettype> <addrtype> <connection-address>
+// https://tools.ietf.org/html/rfc4566#section-5.7
+type ConnectionData struct {
+	NetworkType string
+	AddressType string
+
+	// ConnectionAddress is the unicast or multicast address, multicast addresses may be followed by a TTL
+	// and a number of addresses, like 224.2.1.1/127/3
+	ConnectionAddress string
+}
+
+func (c ConnectionData) String() string {
+	return c.NetworkType + " " + c.AddressType + " " + c.ConnectionAddress
+}
+
+// Attribute is an a= line, either a property attribute (a=<attribute>) or a value attribute
+// (a=<attribute>:<value>). The Value of a property attribute is empty
+// https://tools.ietf.org/html/rfc4566#section-5.13
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// NewPropertyAttribute creates an attribute without a value, like a=recvonly
+func NewPropertyAttribute(key string) Attribute {
+	return Attribute{Key: key}
+}
+
+// NewAttribute creates an attribute with a value, like a=mid:audio
+func NewAttribute(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func (a Attribute) String() string {
+	if a.Value == "" {
+		return a.Key
+	}
+	return a.Key + ":" + a.Value
+}
+
+func findAttribute(attributes []Attribute, key string) (string, bool) {
+	for _, a := range attributes {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
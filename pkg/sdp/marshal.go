@@ -20,8 +20,8 @@ func kvBuilder(key, value string) string {
 // p=* (phone number)
 // c=* (connection information -- not required if included in all media)
 // b=* (zero or more bandwidth information lines)
-// t=* (One or more time descriptions)
-// r=* (One or more repeat descriptions)
+// t=  (time the session is active)
+// r=* (zero or more repeat times)
 // z=* (time zone adjustments)
 // k=* (encryption key)
 // a=* (zero or more session attribute lines)
@@ -38,33 +38,45 @@ func (s *SessionDescription) Marshal() (raw string) {
 			raw += kvBuilder(key, v)
 		}
 	}
+	addConnectionData := func(c *ConnectionData) {
+		if c != nil {
+			raw += kvBuilder("c", c.String())
+		}
+	}
+	addAttributes := func(attributes []Attribute) {
+		for _, a := range attributes {
+			raw += kvBuilder("a", a.String())
+		}
+	}
 
 	raw += kvBuilder("v", strconv.Itoa(s.ProtocolVersion))
-	raw += kvBuilder("o", s.Origin)
+	raw += kvBuilder("o", s.Origin.String())
 	raw += kvBuilder("s", s.SessionName)
 
 	addIfSet("i", s.SessionInformation)
 	addIfSet("u", s.URI)
 	addIfSet("e", s.EmailAddress)
 	addIfSet("p", s.PhoneNumber)
-	addIfSet("c", s.ConnectionData)
+	addConnectionData(s.ConnectionData)
 
 	addSlice("b", s.Bandwidth)
-	addSlice("t", s.Timing)
-	addSlice("r", s.RepeatTimes)
+	for _, t := range s.TimeDescriptions {
+		raw += kvBuilder("t", t.Timing.String())
+		addSlice("r", t.RepeatTimes)
+	}
 	addSlice("z", s.TimeZones)
 	addSlice("k", s.EncryptionKeys)
-	addSlice("a", s.Attributes)
+	addAttributes(s.Attributes)
 
 	for _, a := range s.MediaDescriptions {
-		raw += kvBuilder("m", a.MediaName)
+		raw += kvBuilder("m", a.MediaName.String())
 
 		addIfSet("i", a.MediaInformation)
-		addIfSet("c", a.ConnectionData)
+		addConnectionData(a.ConnectionData)
 
 		addSlice("b", a.Bandwidth)
 		addSlice("k", a.EncryptionKeys)
-		addSlice("a", a.Attributes)
+		addAttributes(a.Attributes)
 	}
 
 	return raw
@@ -0,0 +1,90 @@
+package sdp
+
+import (
+	"reflect"
+	"testing"
+)
+
+const canonicalSessionDescription = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+c=IN IP4 224.2.1.1/127/3
+t=3034423619 3042462419
+a=group:BUNDLE audio
+a=msid-semantic: WMS
+m=audio 49170/2 UDP/TLS/RTP/SAVPF 111 0
+c=IN IP4 0.0.0.0
+a=rtpmap:111 opus/48000/2
+a=fmtp:111 minptime=10;useinbandfec=1
+a=rtcp-mux
+a=candidate:1 1 udp 2113937151 192.168.1.2 49170 typ host
+m=application 9 DTLS/SCTP 5000
+a=sctpmap:5000 webrtc-datachannel 1024
+`
+
+func TestMarshalRoundTrip(t *testing.T) {
+	sd := &SessionDescription{}
+	if err := sd.Unmarshal(canonicalSessionDescription); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOrigin := Origin{
+		Username:       "-",
+		SessionID:      4596489990601351948,
+		SessionVersion: 2,
+		NetworkType:    "IN",
+		AddressType:    "IP4",
+		UnicastAddress: "127.0.0.1",
+	}
+	if sd.Origin != expectedOrigin {
+		t.Errorf("origin was not parsed: %+v", sd.Origin)
+	}
+	if sd.ConnectionData == nil || sd.ConnectionData.ConnectionAddress != "224.2.1.1/127/3" {
+		t.Errorf("connection data was not parsed: %+v", sd.ConnectionData)
+	}
+	if len(sd.TimeDescriptions) != 1 || sd.TimeDescriptions[0].Timing != (Timing{StartTime: 3034423619, StopTime: 3042462419}) {
+		t.Errorf("timing was not parsed: %+v", sd.TimeDescriptions)
+	}
+	if value, ok := sd.Attribute("msid-semantic"); !ok || value != " WMS" {
+		t.Errorf("msid-semantic was not parsed: %q", value)
+	}
+
+	if len(sd.MediaDescriptions) != 2 {
+		t.Fatalf("expected 2 media descriptions, got %d", len(sd.MediaDescriptions))
+	}
+	expectedMediaName := MediaName{
+		Media:         "audio",
+		Port:          49170,
+		NumberOfPorts: 2,
+		Protos:        []string{"UDP", "TLS", "RTP", "SAVPF"},
+		Formats:       []string{"111", "0"},
+	}
+	if audio := sd.MediaDescriptions[0]; !reflect.DeepEqual(audio.MediaName, expectedMediaName) {
+		t.Errorf("media name was not parsed: %+v", audio.MediaName)
+	}
+	if value, ok := sd.MediaDescriptions[0].Attribute("rtcp-mux"); !ok || value != "" {
+		t.Errorf("property attribute was not parsed")
+	}
+	if value, _ := sd.MediaDescriptions[0].Attribute("candidate"); value != "1 1 udp 2113937151 192.168.1.2 49170 typ host" {
+		t.Errorf("candidate was not parsed: %q", value)
+	}
+
+	if raw := sd.Marshal(); raw != canonicalSessionDescription {
+		t.Errorf("session description did not round trip:\n%s", raw)
+	}
+}
+
+func TestUnmarshalInvalidFields(t *testing.T) {
+	for _, raw := range []string{
+		"v=0\no=- 1 2 IN IP4\ns=-\n",
+		"v=0\no=- a 2 IN IP4 127.0.0.1\ns=-\n",
+		"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nt=0\n",
+		"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nt=0 0\nm=audio 9 RTP/AVP\n",
+		"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nt=0 0\nm=audio x RTP/AVP 0\n",
+		"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nt=0 0\nm=audio 9 RTP/AVP 0\nc=IN IP4\n",
+	} {
+		if err := (&SessionDescription{}).Unmarshal(raw); err == nil {
+			t.Errorf("expected an error unmarshalling %q", raw)
+		}
+	}
+}
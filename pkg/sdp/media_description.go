@@ -0,0 +1,269 @@
+package sdp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MediaDescription represents a media type.  Currently defined media are "audio",
+// "video", "text", "application", and "message", although this list
+// may be extended in the future
+// https://tools.ietf.org/html/rfc4566#section-5.14
+type MediaDescription struct {
+	// MediaName is m=<media> <port> <proto> <fmt>
+	// <media> is the media type
+	// <port> is the transport port to which the media stream is sent
+	// <proto> is the transport protocol
+	// <fmt> is a media format description
+	// https://tools.ietf.org/html/rfc4566#section-5.13
+	MediaName MediaName
+
+	// SessionInformation field provides textual information about the session.  There
+	// MUST be at most one session-level SessionInformation field per session description,
+	// and at most one SessionInformation field per media
+	// https://tools.ietf.org/html/rfc4566#section-5.4
+	MediaInformation string
+
+	// ConnectionData a session description MUST contain either at least one ConnectionData field in
+	// each media description or a single ConnectionData field at the session level.
+	// https://tools.ietf.org/html/rfc4566#section-5.7
+	ConnectionData *ConnectionData
+
+	// Bandwidth field denotes the proposed bandwidth to be used by the
+	// session or media
+	// b=<bwtype>:<bandwidth>
+	// https://tools.ietf.org/html/rfc4566#section-5.8
+	Bandwidth []string
+
+	// EncryptionKeys if for when the SessionDescription is transported over a secure and trusted channel,
+	// the Session Description Protocol MAY be used to convey encryption keys
+	// https://tools.ietf.org/html/rfc4566#section-5.11
+	EncryptionKeys []string
+
+	// Attributes are the primary means for extending SDP.  Attributes may
+	// be defined to be used as "session-level" attributes, "media-level"
+	// attributes, or both.
+	// https://tools.ietf.org/html/rfc4566#section-5.12
+	Attributes []Attribute
+}
+
+// Attribute returns the value of the first media-level attribute with the given key
+func (m *MediaDescription) Attribute(key string) (string, bool) {
+	return findAttribute(m.Attributes, key)
+}
+
+// MediaName is the media type, transport and formats of a MediaDescription
+// m=<media> <port>[/<number of ports>] <proto> <fmt> ...
+// https://tools.ietf.org/html/rfc4566#section-5.14
+type MediaName struct {
+	Media string
+	Port  int
+
+	// NumberOfPorts is the number of consecutive ports used from Port, 0 if it wasn't given
+	NumberOfPorts int
+
+	// Protos are the parts of <proto>, like ["UDP", "TLS", "RTP", "SAVPF"]
+	Protos []string
+
+	// Formats are the RTP payload types for RTP/AVP and RTP/SAVP(F), and opaque otherwise
+	Formats []string
+}
+
+func (m MediaName) String() string {
+	port := strconv.Itoa(m.Port)
+	if m.NumberOfPorts != 0 {
+		port += "/" + strconv.Itoa(m.NumberOfPorts)
+	}
+	return strings.Join(append([]string{m.Media, port, strings.Join(m.Protos, "/")}, m.Formats...), " ")
+}
+
+// Codecs returns the formats of the MediaDescription described by an rtpmap attribute or a static payload type, in
+// the order of the m= line, with their fmtp and rtcp-fb attributes. rtcp-fb:* applies to every format
+func (m *MediaDescription) Codecs() []*Codec {
+	codecs := map[string]*Codec{}
+	fmtps := map[string]string{}
+	var feedback [][2]string
+	for _, a := range m.Attributes {
+		split := strings.SplitN(a.Value, " ", 2)
+		if len(split) != 2 {
+			continue
+		}
+
+		switch a.Key {
+		case "rtpmap":
+			if codec, err := ParseRTPMap(a.Value); err == nil {
+				codecs[split[0]] = codec
+			}
+		case "fmtp":
+			fmtps[split[0]] = strings.TrimSpace(split[1])
+		case "rtcp-fb":
+			feedback = append(feedback, [2]string{split[0], strings.TrimSpace(split[1])})
+		}
+	}
+
+	var ordered []*Codec
+	for _, format := range m.MediaName.Formats {
+		codec, ok := codecs[format]
+		if !ok {
+			if codec, ok = staticCodec(format); !ok {
+				continue
+			}
+		}
+		codec.Fmtp = fmtps[format]
+		for _, f := range feedback {
+			if f[0] == "*" || f[0] == format {
+				codec.RTCPFeedback = append(codec.RTCPFeedback, f[1])
+			}
+		}
+		ordered = append(ordered, codec)
+	}
+	return ordered
+}
+
+// ExtMaps returns the RTP header extensions of the MediaDescription, extmap attributes that can't be parsed are skipped
+func (m *MediaDescription) ExtMaps() (extMaps []*ExtMap) {
+	for _, a := range m.Attributes {
+		if a.Key != "extmap" {
+			continue
+		}
+		if extMap, err := ParseExtMap(a.Value); err == nil {
+			extMaps = append(extMaps, extMap)
+		}
+	}
+	return extMaps
+}
+
+// SSRCs returns the sources of the MediaDescription in the order they first appear, with their cname, msid,
+// mslabel and label source attributes
+func (m *MediaDescription) SSRCs() (ssrcs []*SSRC) {
+	bySSRC := map[uint32]*SSRC{}
+	for _, a := range m.Attributes {
+		if a.Key != "ssrc" {
+			continue
+		}
+
+		split := strings.SplitN(a.Value, " ", 2)
+		id, err := strconv.ParseUint(split[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		s, ok := bySSRC[uint32(id)]
+		if !ok {
+			s = &SSRC{SSRC: uint32(id)}
+			bySSRC[s.SSRC] = s
+			ssrcs = append(ssrcs, s)
+		}
+		if len(split) != 2 {
+			continue
+		}
+
+		attribute := unmarshalAttribute(split[1])
+		switch attribute.Key {
+		case "cname":
+			s.CNAME = attribute.Value
+		case "msid":
+			s.MSID = attribute.Value
+		case "mslabel":
+			s.MSLabel = attribute.Value
+		case "label":
+			s.Label = attribute.Value
+		}
+	}
+	return ssrcs
+}
+
+// SSRCGroups returns the ssrc-group attributes of the MediaDescription
+func (m *MediaDescription) SSRCGroups() (groups []*SSRCGroup) {
+	for _, a := range m.Attributes {
+		if a.Key != "ssrc-group" {
+			continue
+		}
+		if group, err := ParseSSRCGroup(a.Value); err == nil {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// Candidates returns the ICE candidates of the MediaDescription, candidates that can't be parsed are skipped
+func (m *MediaDescription) Candidates() (candidates []*Candidate) {
+	for _, a := range m.Attributes {
+		if a.Key != "candidate" {
+			continue
+		}
+		if candidate, err := ParseCandidate(a.Value); err == nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// ICECredentials returns the ice-ufrag and ice-pwd of the MediaDescription. They are looked up in session when
+// the MediaDescription has none, session may be nil
+// https://tools.ietf.org/html/rfc5245#section-15.4
+func (m *MediaDescription) ICECredentials(session *SessionDescription) (ufrag, pwd string, ok bool) {
+	ufrag, hasUfrag := m.Attribute("ice-ufrag")
+	pwd, hasPwd := m.Attribute("ice-pwd")
+	if session != nil {
+		if !hasUfrag {
+			ufrag, hasUfrag = session.Attribute("ice-ufrag")
+		}
+		if !hasPwd {
+			pwd, hasPwd = session.Attribute("ice-pwd")
+		}
+	}
+	return ufrag, pwd, hasUfrag && hasPwd
+}
+
+// Fingerprint returns the DTLS fingerprint of the MediaDescription. It is looked up in session when the
+// MediaDescription has none, session may be nil
+func (m *MediaDescription) Fingerprint(session *SessionDescription) (*Fingerprint, bool) {
+	value, ok := m.Attribute("fingerprint")
+	if !ok && session != nil {
+		value, ok = session.Attribute("fingerprint")
+	}
+	if !ok {
+		return nil, false
+	}
+
+	fingerprint, err := ParseFingerprint(value)
+	if err != nil {
+		return nil, false
+	}
+	return fingerprint, true
+}
+
+// Direction returns the direction attribute of the MediaDescription, sendrecv if it has none
+// https://tools.ietf.org/html/rfc3264#section-5.1
+func (m *MediaDescription) Direction() Direction {
+	for _, a := range m.Attributes {
+		switch direction := Direction(a.Key); direction {
+		case DirectionSendRecv, DirectionSendOnly, DirectionRecvOnly, DirectionInactive:
+			return direction
+		}
+	}
+	return DirectionSendRecv
+}
+
+// MID returns the identification tag of the MediaDescription
+// https://tools.ietf.org/html/rfc5888#section-4
+func (m *MediaDescription) MID() (string, bool) {
+	return m.Attribute("mid")
+}
+
+// MSID returns the stream and track id of the msid attribute of the MediaDescription
+// https://tools.ietf.org/html/draft-ietf-mmusic-msid-16#section-2
+func (m *MediaDescription) MSID() (streamID, trackID string, ok bool) {
+	value, ok := m.Attribute("msid")
+	if !ok {
+		return "", "", false
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return "", "", false
+	} else if len(fields) == 1 {
+		return fields[0], "", true
+	}
+	return fields[0], fields[1], true
+}
@@ -1,5 +1,10 @@
 package sdp
 
+import (
+	"fmt"
+	"strings"
+)
+
 // SessionDescription is a a well-defined format for conveying sufficient
 // information to discover and participate in a multimedia session.
 type SessionDescription struct {
@@ -10,7 +15,7 @@ type SessionDescription struct {
 	// Origin gives the originator of the session in the form of
 	// o=<username> <sess-id> <sess-version> <nettype> <addrtype> <unicast-address>
 	// https://tools.ietf.org/html/rfc4566#section-5.2
-	Origin string
+	Origin Origin
 
 	// SessionName is the textual session name. There MUST be one and only one
 	// only one "s=" field per session description
@@ -41,7 +46,7 @@ type SessionDescription struct {
 	// ConnectionData a session description MUST contain either at least one ConnectionData field in
 	// each media description or a single ConnectionData field at the session level.
 	// https://tools.ietf.org/html/rfc4566#section-5.7
-	ConnectionData string
+	ConnectionData *ConnectionData
 
 	// Bandwidth field denotes the proposed bandwidth to be used by the
 	// session or media
@@ -49,15 +54,10 @@ type SessionDescription struct {
 	// https://tools.ietf.org/html/rfc4566#section-5.8
 	Bandwidth []string
 
-	// Timing lines specify the start and stop times for a session.
-	// t=<start-time> <stop-time>
+	// TimeDescriptions specify the start and stop times for a session, and when they repeat.
+	// There MUST be at least one
 	// https://tools.ietf.org/html/rfc4566#section-5.9
-	Timing []string
-
-	// RepeatTimes specify repeat times for a session
-	// r=<repeat interval> <active duration> <offsets from start-time>
-	// https://tools.ietf.org/html/rfc4566#section-5.10
-	RepeatTimes []string
+	TimeDescriptions []TimeDescription
 
 	// TimeZones schedule a repeated session that spans a change from daylight
 	// z=<adjustment time> <offset> <adjustment time> <offset>
@@ -73,7 +73,7 @@ type SessionDescription struct {
 	// be defined to be used as "session-level" attributes, "media-level"
 	// attributes, or both.
 	// https://tools.ietf.org/html/rfc4566#section-5.12
-	Attributes []string
+	Attributes []Attribute
 
 	// MediaDescriptions A session description may contain a number of media descriptions.
 	// Each media description starts with an "m=" field and is terminated by
@@ -85,18 +85,82 @@ type SessionDescription struct {
 // Reset cleans the SessionDescription, and sets all fields back to their default values
 func (s *SessionDescription) Reset() {
 	s.ProtocolVersion = 0
-	s.Origin = ""
+	s.Origin = Origin{}
 	s.SessionName = ""
 	s.SessionInformation = ""
 	s.URI = ""
 	s.EmailAddress = ""
 	s.PhoneNumber = ""
-	s.ConnectionData = ""
+	s.ConnectionData = nil
 	s.Bandwidth = nil
-	s.Timing = nil
-	s.RepeatTimes = nil
+	s.TimeDescriptions = nil
 	s.TimeZones = nil
 	s.EncryptionKeys = nil
 	s.Attributes = nil
 	s.MediaDescriptions = nil
 }
+
+// Attribute returns the value of the first session-level attribute with the given key
+func (s *SessionDescription) Attribute(key string) (string, bool) {
+	return findAttribute(s.Attributes, key)
+}
+
+// FindMediaDescription returns the first MediaDescription of the given media type, like "audio" or "video"
+func (s *SessionDescription) FindMediaDescription(media string) *MediaDescription {
+	for _, m := range s.MediaDescriptions {
+		if m.MediaName.Media == media {
+			return m
+		}
+	}
+	return nil
+}
+
+// Bundle returns the mids of the BUNDLE group of the session
+// https://tools.ietf.org/html/draft-ietf-mmusic-sdp-bundle-negotiation-54#section-7.1
+func (s *SessionDescription) Bundle() ([]string, bool) {
+	for _, a := range s.Attributes {
+		if split := strings.Fields(a.Value); a.Key == "group" && len(split) != 0 && split[0] == "BUNDLE" {
+			return split[1:], true
+		}
+	}
+	return nil, false
+}
+
+// Origin is the originator of the session and its identifier
+// o=<username> <sess-id> <sess-version> <nettype> <addrtype> <unicast-address>
+// https://tools.ietf.org/html/rfc4566#section-5.2
+type Origin struct {
+	Username       string
+	SessionID      uint64
+	SessionVersion uint64
+	NetworkType    string
+	AddressType    string
+	UnicastAddress string
+}
+
+func (o Origin) String() string {
+	return fmt.Sprintf("%s %d %d %s %s %s", o.Username, o.SessionID, o.SessionVersion, o.NetworkType, o.AddressType, o.UnicastAddress)
+}
+
+// TimeDescription is a t= line followed by the r= lines that repeat it
+type TimeDescription struct {
+	Timing Timing
+
+	// RepeatTimes specify repeat times for a session
+	// r=<repeat interval> <active duration> <offsets from start-time>
+	// https://tools.ietf.org/html/rfc4566#section-5.10
+	RepeatTimes []string
+}
+
+// Timing is the start and stop time of a session, as NTP timestamps in seconds. A stop time of 0 means the
+// session is unbounded, and a start time of 0 that it is permanent
+// t=<start-time> <stop-time>
+// https://tools.ietf.org/html/rfc4566#section-5.9
+type Timing struct {
+	StartTime uint64
+	StopTime  uint64
+}
+
+func (t Timing) String() string {
+	return fmt.Sprintf("%d %d", t.StartTime, t.StopTime)
+}
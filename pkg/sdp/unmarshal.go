@@ -0,0 +1,367 @@
+package sdp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// UnmarshalOptions change how strictly a SessionDescription is parsed
+type UnmarshalOptions struct {
+	// Lenient accepts descriptions as they are sent by some SIP endpoints: lines in any order, repeated
+	// lines where only one is allowed (the last one is kept), blank lines, unknown line types and no s= or t=
+	// line. Values that can't be parsed are still errors
+	Lenient bool
+}
+
+// line is a <type>=<value> line of a session description, number counts from 1 for errors
+type line struct {
+	number int
+	key    string
+	value  string
+}
+
+// splitLines splits raw into lines, accepting both CRLF and LF line endings. Trailing blank lines are dropped,
+// other blank lines are an error unless lenient
+// https://tools.ietf.org/html/rfc4566#section-5
+func splitLines(raw string, lenient bool) ([]*line, error) {
+	rawLines := strings.Split(raw, "\n")
+	for i := range rawLines {
+		rawLines[i] = strings.TrimSuffix(rawLines[i], "\r")
+	}
+	for len(rawLines) != 0 && strings.TrimSpace(rawLines[len(rawLines)-1]) == "" {
+		rawLines = rawLines[:len(rawLines)-1]
+	}
+
+	var lines []*line
+	for i, rawLine := range rawLines {
+		number := i + 1
+		switch {
+		case strings.TrimSpace(rawLine) == "":
+			if lenient {
+				continue
+			}
+			return nil, errors.Errorf("line %d: blank lines are not allowed", number)
+		case len(rawLine) < 2:
+			return nil, errors.Errorf("line %d: line is not long enough to contain both a key and value: %s", number, rawLine)
+		case rawLine[1] != '=':
+			return nil, errors.Errorf("line %d: line is not a proper key value pair, second character is not `=`: %s", number, rawLine)
+		}
+		lines = append(lines, &line{number: number, key: rawLine[:1], value: rawLine[2:]})
+	}
+	return lines, nil
+}
+
+// sessionOrder and mediaOrder rank the lines of a description, lines must appear in increasing rank.
+// Lines that may repeat can have the rank of the previous line
+var (
+	sessionOrder = map[string]int{"v": 0, "o": 1, "s": 2, "i": 3, "u": 4, "e": 5, "p": 6, "c": 7, "b": 8, "t": 9, "r": 9, "z": 10, "k": 11, "a": 12}
+	mediaOrder   = map[string]int{"m": 0, "i": 1, "c": 2, "b": 3, "k": 4, "a": 5}
+	repeatable   = map[string]bool{"b": true, "t": true, "r": true, "a": true}
+)
+
+// checkOrder returns an error if key may not follow previous, which is "" for the first line of a description
+func checkOrder(order map[string]int, previous, key string) error {
+	rank, ok := order[key]
+	if !ok {
+		return errors.Errorf("%s= is not a valid line type here", key)
+	}
+	if previous == "" {
+		return nil
+	}
+
+	previousRank := order[previous]
+	switch {
+	case rank < previousRank:
+		return errors.Errorf("%s= must come before %s=", key, previous)
+	case rank == previousRank && !repeatable[key]:
+		return errors.Errorf("%s= must only appear once", key)
+	case key == "r" && previous != "t" && previous != "r":
+		return errors.Errorf("r= must follow a t= line")
+	}
+	return nil
+}
+
+// Unmarshal populates a SessionDescription from a raw string, the lines must be in the order of RFC 4566
+//
+// Some lines in each description are REQUIRED and some are OPTIONAL,
+// but all MUST appear in exactly the order given here (the fixed order
+// greatly enhances error detection and allows for a simple parser).
+// OPTIONAL items are marked with a "*".
+// v=  (protocol version)
+// o=  (originator and session identifier)
+// s=  (session name)
+// i=* (session information)
+// u=* (URI of description)
+// e=* (email address)
+// p=* (phone number)
+// c=* (connection information -- not required if included in all media)
+// b=* (zero or more bandwidth information lines)
+// One or more time descriptions ("t=" and "r=" lines; see below)
+// z=* (time zone adjustments)
+// k=* (encryption key)
+// a=* (zero or more session attribute lines)
+// Zero or more media descriptions
+//
+// Time description
+// t=  (time the session is active)
+// r=* (zero or more repeat times)
+//
+// Media description, if present
+// m=  (media name and transport address)
+// i=* (media title)
+// c=* (connection information -- optional if included at session-level)
+// b=* (zero or more bandwidth information lines)
+// k=* (encryption key)
+// a=* (zero or more media attribute lines)
+// https://tools.ietf.org/html/rfc4566#section-5
+func (s *SessionDescription) Unmarshal(raw string) error {
+	return s.UnmarshalWithOptions(raw, UnmarshalOptions{})
+}
+
+// UnmarshalWithOptions populates a SessionDescription from a raw string, see Unmarshal
+func (s *SessionDescription) UnmarshalWithOptions(raw string, options UnmarshalOptions) error {
+	s.Reset()
+	lines, err := splitLines(raw, options.Lenient)
+	if err != nil {
+		return err
+	}
+
+	if !options.Lenient {
+		if err := checkRequiredPrefix(lines); err != nil {
+			return err
+		}
+	}
+
+	seen := map[string]bool{}
+	previous := ""
+	i := 0
+	for ; i < len(lines) && lines[i].key != "m"; i++ {
+		l := lines[i]
+		if !options.Lenient {
+			if err := checkOrder(sessionOrder, previous, l.key); err != nil {
+				return errors.Wrapf(err, "line %d", l.number)
+			}
+		}
+		previous = l.key
+		seen[l.key] = true
+
+		if err := s.unmarshalSessionLine(l, options.Lenient); err != nil {
+			return errors.Wrapf(err, "line %d", l.number)
+		}
+	}
+
+	if err := checkRequiredLines(seen, options.Lenient); err != nil {
+		return err
+	}
+	return s.unmarshalMediaDescriptions(lines[i:], options.Lenient)
+}
+
+// requiredLines are the lines every session description has, they are its first lines in this order
+var requiredLines = []struct {
+	key, description string
+}{
+	{"v", "v (protocol version)"},
+	{"o", "o (originator and session identifier)"},
+	{"s", "s (session name)"},
+}
+
+// checkRequiredPrefix returns an error if the session description doesn't start with v=, o= and s=
+func checkRequiredPrefix(lines []*line) error {
+	for i, r := range requiredLines {
+		if i >= len(lines) {
+			return errors.Errorf("session description ended before %s was found", r.description)
+		} else if lines[i].key != r.key {
+			return errors.Errorf("line %d: %s was expected, but not found", lines[i].number, r.description)
+		}
+	}
+	return nil
+}
+
+// checkRequiredLines returns an error if a required line or t= wasn't seen in the session section. A lenient
+// session description may leave out s= and t=
+func checkRequiredLines(seen map[string]bool, lenient bool) error {
+	for _, r := range requiredLines {
+		if !seen[r.key] && (r.key != "s" || !lenient) {
+			return errors.Errorf("%s was expected, but not found", r.description)
+		}
+	}
+	if !seen["t"] && !lenient {
+		return errors.Errorf("t (time the session is active) was expected, but not found")
+	}
+	return nil
+}
+
+// unmarshalMediaDescriptions adds a MediaDescription for every m= line of lines, which starts with the first one
+func (s *SessionDescription) unmarshalMediaDescriptions(lines []*line, lenient bool) error {
+	previous := ""
+	for _, l := range lines {
+		if l.key == "m" {
+			mediaName, err := unmarshalMediaName(l.value)
+			if err != nil {
+				return errors.Wrapf(err, "line %d", l.number)
+			}
+			s.MediaDescriptions = append(s.MediaDescriptions, &MediaDescription{MediaName: mediaName})
+			previous = ""
+		}
+
+		if !lenient {
+			if err := checkOrder(mediaOrder, previous, l.key); err != nil {
+				return errors.Wrapf(err, "line %d", l.number)
+			}
+		}
+		previous = l.key
+
+		media := s.MediaDescriptions[len(s.MediaDescriptions)-1]
+		if err := media.unmarshalMediaLine(l); err != nil {
+			return errors.Wrapf(err, "line %d", l.number)
+		}
+	}
+	return nil
+}
+
+func (s *SessionDescription) unmarshalSessionLine(l *line, lenient bool) (err error) {
+	switch l.key {
+	case "v":
+		if s.ProtocolVersion, err = strconv.Atoi(l.value); err != nil {
+			return errors.Errorf("Failed to take protocol version to int")
+		}
+	case "o":
+		s.Origin, err = unmarshalOrigin(l.value)
+	case "s":
+		s.SessionName = l.value
+	case "i":
+		s.SessionInformation = l.value
+	case "u":
+		s.URI = l.value
+	case "e":
+		s.EmailAddress = l.value
+	case "p":
+		s.PhoneNumber = l.value
+	case "c":
+		s.ConnectionData, err = unmarshalConnectionData(l.value)
+	case "b":
+		s.Bandwidth = append(s.Bandwidth, l.value)
+	case "t":
+		timing, err := unmarshalTiming(l.value)
+		if err != nil {
+			return err
+		}
+		s.TimeDescriptions = append(s.TimeDescriptions, TimeDescription{Timing: timing})
+	case "r":
+		// Unless lenient r= always follows a t= line
+		if len(s.TimeDescriptions) == 0 {
+			return nil
+		}
+		t := &s.TimeDescriptions[len(s.TimeDescriptions)-1]
+		t.RepeatTimes = append(t.RepeatTimes, l.value)
+	case "z":
+		s.TimeZones = append(s.TimeZones, l.value)
+	case "k":
+		s.EncryptionKeys = append(s.EncryptionKeys, l.value)
+	case "a":
+		s.Attributes = append(s.Attributes, unmarshalAttribute(l.value))
+	default:
+		if !lenient {
+			return errors.Errorf("Invalid session attribute: %s", l.key)
+		}
+	}
+	return err
+}
+
+// unmarshalMediaLine adds a line following the m= line to the MediaDescription. Unknown lines are only
+// reached when lenient, they are ignored
+func (m *MediaDescription) unmarshalMediaLine(l *line) (err error) {
+	switch l.key {
+	case "i":
+		m.MediaInformation = l.value
+	case "c":
+		m.ConnectionData, err = unmarshalConnectionData(l.value)
+	case "b":
+		m.Bandwidth = append(m.Bandwidth, l.value)
+	case "k":
+		m.EncryptionKeys = append(m.EncryptionKeys, l.value)
+	case "a":
+		m.Attributes = append(m.Attributes, unmarshalAttribute(l.value))
+	}
+	return err
+}
+
+func unmarshalOrigin(value string) (Origin, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 6 {
+		return Origin{}, errors.Errorf("o (originator and session identifier) must have 6 fields: %s", value)
+	}
+
+	sessionID, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return Origin{}, errors.Wrapf(err, "Failed to parse o (originator and session identifier) session id")
+	}
+	sessionVersion, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return Origin{}, errors.Wrapf(err, "Failed to parse o (originator and session identifier) session version")
+	}
+
+	return Origin{
+		Username:       fields[0],
+		SessionID:      sessionID,
+		SessionVersion: sessionVersion,
+		NetworkType:    fields[3],
+		AddressType:    fields[4],
+		UnicastAddress: fields[5],
+	}, nil
+}
+
+func unmarshalConnectionData(value string) (*ConnectionData, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return nil, errors.Errorf("c (connection information) must have 3 fields: %s", value)
+	}
+	return &ConnectionData{NetworkType: fields[0], AddressType: fields[1], ConnectionAddress: fields[2]}, nil
+}
+
+func unmarshalTiming(value string) (Timing, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return Timing{}, errors.Errorf("t (time the session is active) must have 2 fields: %s", value)
+	}
+
+	startTime, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return Timing{}, errors.Wrapf(err, "Failed to parse t (time the session is active) start time")
+	}
+	stopTime, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return Timing{}, errors.Wrapf(err, "Failed to parse t (time the session is active) stop time")
+	}
+	return Timing{StartTime: startTime, StopTime: stopTime}, nil
+}
+
+func unmarshalMediaName(value string) (MediaName, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 4 {
+		return MediaName{}, errors.Errorf("m (media name and transport address) must have at least 4 fields: %s", value)
+	}
+
+	m := MediaName{Media: fields[0], Protos: strings.Split(fields[2], "/"), Formats: fields[3:]}
+	port := strings.SplitN(fields[1], "/", 2)
+	var err error
+	if m.Port, err = strconv.Atoi(port[0]); err != nil {
+		return MediaName{}, errors.Wrapf(err, "Failed to parse m (media name and transport address) port")
+	}
+	if len(port) == 2 {
+		if m.NumberOfPorts, err = strconv.Atoi(port[1]); err != nil {
+			return MediaName{}, errors.Wrapf(err, "Failed to parse m (media name and transport address) number of ports")
+		}
+	}
+	return m, nil
+}
+
+func unmarshalAttribute(value string) Attribute {
+	split := strings.SplitN(value, ":", 2)
+	if len(split) == 1 {
+		return NewPropertyAttribute(split[0])
+	}
+	return NewAttribute(split[0], split[1])
+}
@@ -0,0 +1,331 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+const chromeOffer = `v=0
+o=- 4215775240449105457 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+a=group:BUNDLE 0 1
+a=msid-semantic: WMS Lwgsm2ynsqRqkCXEhZDEqc5XUkXJoW9tVoQt
+m=audio 9 UDP/TLS/RTP/SAVPF 111 103 9 0 8 106 105 13 110 126
+c=IN IP4 0.0.0.0
+a=rtcp:9 IN IP4 0.0.0.0
+a=ice-ufrag:3GjD
+a=ice-pwd:lsnHo2ibC5FJyiTyNXfNl7Nm
+a=ice-options:trickle
+a=fingerprint:sha-256 3E:5A:D1:6A:2C:0D:C0:7A:2E:F9:4F:F6:76:E6:3A:BE:2C:9B:B3:32:8A:2C:80:0B:0F:D3:7D:2B:D0:7E:4E:B6
+a=setup:actpass
+a=mid:0
+a=extmap:1 urn:ietf:params:rtp-hdrext:ssrc-audio-level
+a=extmap:9 urn:ietf:params:rtp-hdrext:sdes:mid
+a=sendrecv
+a=msid:Lwgsm2ynsqRqkCXEhZDEqc5XUkXJoW9tVoQt 0a8c7bc0-1cd4-4a0c-a0d5-c5d0a1b1cbd8
+a=rtcp-mux
+a=rtpmap:111 opus/48000/2
+a=rtcp-fb:111 transport-cc
+a=fmtp:111 minptime=10;useinbandfec=1
+a=rtpmap:103 ISAC/16000
+a=rtpmap:9 G722/8000
+a=rtpmap:0 PCMU/8000
+a=rtpmap:8 PCMA/8000
+a=rtpmap:106 CN/32000
+a=rtpmap:105 CN/16000
+a=rtpmap:13 CN/8000
+a=rtpmap:110 telephone-event/48000
+a=rtpmap:126 telephone-event/8000
+a=ssrc:3570614608 cname:4TOk42mSjXCkVIa6
+a=ssrc:3570614608 msid:Lwgsm2ynsqRqkCXEhZDEqc5XUkXJoW9tVoQt 0a8c7bc0-1cd4-4a0c-a0d5-c5d0a1b1cbd8
+m=video 9 UDP/TLS/RTP/SAVPF 96 97 102 125
+c=IN IP4 0.0.0.0
+a=rtcp:9 IN IP4 0.0.0.0
+a=ice-ufrag:3GjD
+a=ice-pwd:lsnHo2ibC5FJyiTyNXfNl7Nm
+a=ice-options:trickle
+a=fingerprint:sha-256 3E:5A:D1:6A:2C:0D:C0:7A:2E:F9:4F:F6:76:E6:3A:BE:2C:9B:B3:32:8A:2C:80:0B:0F:D3:7D:2B:D0:7E:4E:B6
+a=setup:actpass
+a=mid:1
+a=extmap:2 urn:ietf:params:rtp-hdrext:toffset
+a=extmap:3 http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time
+a=extmap:4 urn:3gpp:video-orientation
+a=sendrecv
+a=rtcp-mux
+a=rtcp-rsize
+a=rtpmap:96 VP8/90000
+a=rtcp-fb:96 goog-remb
+a=rtcp-fb:96 transport-cc
+a=rtcp-fb:96 ccm fir
+a=rtcp-fb:96 nack
+a=rtcp-fb:96 nack pli
+a=rtpmap:97 rtx/90000
+a=fmtp:97 apt=96
+a=rtpmap:102 H264/90000
+a=rtcp-fb:102 nack pli
+a=fmtp:102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f
+a=rtpmap:125 rtx/90000
+a=fmtp:125 apt=102
+a=ssrc-group:FID 2231627014 632943048
+a=ssrc:2231627014 cname:4TOk42mSjXCkVIa6
+a=ssrc:632943048 cname:4TOk42mSjXCkVIa6
+`
+
+const firefoxOffer = `v=0
+o=mozilla...THIS_IS_SDPARTA-63.0 5813946390185463585 0 IN IP4 0.0.0.0
+s=-
+t=0 0
+a=fingerprint:sha-256 9F:5D:5D:9A:68:35:DD:B4:32:1B:9A:02:0E:91:9C:6E:CB:E6:1C:A6:39:32:E1:A2:C2:32:BC:3C:94:73:13:F0
+a=group:BUNDLE 0 1
+a=ice-options:trickle
+a=msid-semantic:WMS *
+m=audio 9 UDP/TLS/RTP/SAVPF 109 9 0 8 101
+c=IN IP4 0.0.0.0
+a=sendrecv
+a=extmap:1 urn:ietf:params:rtp-hdrext:ssrc-audio-level
+a=extmap:2/recvonly urn:ietf:params:rtp-hdrext:csrc-audio-level
+a=extmap:3 urn:ietf:params:rtp-hdrext:sdes:mid
+a=fmtp:109 maxplaybackrate=48000;stereo=1;useinbandfec=1
+a=fmtp:101 0-15
+a=ice-pwd:4a799b3d1b4e1d5e3e1e4c06ab5e8b62
+a=ice-ufrag:7e8b1ca5
+a=mid:0
+a=msid:{5a990edd-35b3-4a2b-8d49-1e5a4d0b2e0c} {2f6c5bd0-3a1b-4e89-9e8c-1b0d7f4d6a31}
+a=rtcp-mux
+a=rtpmap:109 opus/48000/2
+a=rtpmap:9 G722/8000/1
+a=rtpmap:0 PCMU/8000
+a=rtpmap:8 PCMA/8000
+a=rtpmap:101 telephone-event/8000
+a=setup:actpass
+a=ssrc:2655508255 cname:{735484ea-4f8b-4a87-9bee-6b0c2a1a1b19}
+m=video 9 UDP/TLS/RTP/SAVPF 120 121 126 97
+c=IN IP4 0.0.0.0
+a=recvonly
+a=extmap:3 urn:ietf:params:rtp-hdrext:sdes:mid
+a=extmap:4 http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time
+a=extmap:5 urn:ietf:params:rtp-hdrext:toffset
+a=fmtp:126 profile-level-id=42e01f;level-asymmetry-allowed=1;packetization-mode=1
+a=fmtp:97 profile-level-id=42e01f;level-asymmetry-allowed=1
+a=fmtp:120 max-fs=12288;max-fr=60
+a=fmtp:121 max-fs=12288;max-fr=60
+a=ice-pwd:4a799b3d1b4e1d5e3e1e4c06ab5e8b62
+a=ice-ufrag:7e8b1ca5
+a=mid:1
+a=rtcp-fb:120 nack
+a=rtcp-fb:120 nack pli
+a=rtcp-fb:120 ccm fir
+a=rtcp-fb:120 goog-remb
+a=rtcp-mux
+a=rtpmap:120 VP8/90000
+a=rtpmap:121 VP9/90000
+a=rtpmap:126 H264/90000
+a=rtpmap:97 H264/90000
+a=setup:actpass
+`
+
+const safariOffer = `v=0
+o=- 6719718427738163829 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+a=group:BUNDLE audio video
+a=msid-semantic: WMS
+m=audio 9 UDP/TLS/RTP/SAVPF 111 103 9 102 0 8 105 13 110 113 126
+c=IN IP4 0.0.0.0
+a=rtcp:9 IN IP4 0.0.0.0
+a=ice-ufrag:yV3q
+a=ice-pwd:yJ/4iOMCo0a9HeyfdB1ruC2T
+a=ice-options:trickle
+a=fingerprint:sha-256 52:6B:E7:4C:1F:7E:8D:A9:6B:EF:93:F5:0E:A6:22:77:DE:62:72:FB:49:2E:E5:97:08:32:53:22:3E:50:8D:2C
+a=setup:actpass
+a=mid:audio
+a=extmap:1 urn:ietf:params:rtp-hdrext:ssrc-audio-level
+a=recvonly
+a=rtcp-mux
+a=rtpmap:111 opus/48000/2
+a=rtcp-fb:111 transport-cc
+a=fmtp:111 minptime=10;useinbandfec=1
+a=rtpmap:103 ISAC/16000
+a=rtpmap:9 G722/8000
+a=rtpmap:102 ILBC/8000
+a=rtpmap:0 PCMU/8000
+a=rtpmap:8 PCMA/8000
+a=rtpmap:105 CN/16000
+a=rtpmap:13 CN/8000
+a=rtpmap:110 telephone-event/48000
+a=rtpmap:113 telephone-event/16000
+a=rtpmap:126 telephone-event/8000
+m=video 9 UDP/TLS/RTP/SAVPF 96 97 98 99 100 101 127
+c=IN IP4 0.0.0.0
+a=rtcp:9 IN IP4 0.0.0.0
+a=ice-ufrag:yV3q
+a=ice-pwd:yJ/4iOMCo0a9HeyfdB1ruC2T
+a=ice-options:trickle
+a=fingerprint:sha-256 52:6B:E7:4C:1F:7E:8D:A9:6B:EF:93:F5:0E:A6:22:77:DE:62:72:FB:49:2E:E5:97:08:32:53:22:3E:50:8D:2C
+a=setup:actpass
+a=mid:video
+a=extmap:2 urn:ietf:params:rtp-hdrext:toffset
+a=extmap:3 http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time
+a=extmap:4 urn:3gpp:video-orientation
+a=recvonly
+a=rtcp-mux
+a=rtcp-rsize
+a=rtpmap:96 H264/90000
+a=rtcp-fb:96 goog-remb
+a=rtcp-fb:96 transport-cc
+a=rtcp-fb:96 ccm fir
+a=rtcp-fb:96 nack
+a=rtcp-fb:96 nack pli
+a=fmtp:96 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640c1f
+a=rtpmap:97 rtx/90000
+a=fmtp:97 apt=96
+a=rtpmap:98 H264/90000
+a=fmtp:98 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f
+a=rtpmap:99 rtx/90000
+a=fmtp:99 apt=98
+a=rtpmap:100 VP8/90000
+a=rtpmap:101 rtx/90000
+a=fmtp:101 apt=100
+a=rtpmap:127 red/90000
+`
+
+// sipOffer is the example of RFC 4566 section 5, with interleaved time descriptions
+const sipOffer = `v=0
+o=jdoe 2890844526 2890842807 IN IP4 10.47.16.5
+s=SDP Seminar
+i=A Seminar on the session description protocol
+u=http://www.example.com/seminars/sdp.pdf
+e=j.doe@example.com (Jane Doe)
+c=IN IP4 224.2.17.12/127
+t=2873397496 2873404696
+r=604800 3600 0 90000
+t=2873404696 2873411896
+r=7d 1h 0 25h
+a=recvonly
+m=audio 49170 RTP/AVP 0
+m=video 51372 RTP/AVP 99
+a=rtpmap:99 h263-1998/90000
+`
+
+const pbxOffer = `v=0
+o=root 1821 1821 IN IP4 10.0.0.1
+s=Asterisk PBX 13.1.0
+c=IN IP4 10.0.0.1
+b=CT:384
+t=0 0
+m=audio 10000 RTP/AVP 0 8 101
+a=rtpmap:0 PCMU/8000
+a=rtpmap:8 PCMA/8000
+a=rtpmap:101 telephone-event/8000
+a=fmtp:101 0-16
+a=ptime:20
+a=maxptime:150
+a=sendrecv
+`
+
+func TestUnmarshalCorpus(t *testing.T) {
+	for _, test := range []struct {
+		name              string
+		raw               string
+		mediaDescriptions int
+		check             func(sd *SessionDescription) bool
+	}{
+		{"Chrome", chromeOffer, 2, func(sd *SessionDescription) bool {
+			ssrcs := sd.MediaDescriptions[0].SSRCs()
+			return len(ssrcs) == 1 && ssrcs[0].CNAME == "4TOk42mSjXCkVIa6" && len(sd.MediaDescriptions[1].Codecs()) == 4
+		}},
+		{"Firefox", firefoxOffer, 2, func(sd *SessionDescription) bool {
+			fingerprint, ok := sd.MediaDescriptions[1].Fingerprint(sd)
+			mid, _ := sd.MediaDescriptions[1].MID()
+			return ok && fingerprint.HashFunction == "sha-256" && mid == "1" && sd.MediaDescriptions[1].Direction() == DirectionRecvOnly
+		}},
+		{"Safari", safariOffer, 2, func(sd *SessionDescription) bool {
+			codecs := sd.FindMediaDescription("video").Codecs()
+			return len(codecs) == 7 && codecs[0].Name == "H264" && codecs[0].Fmtp == "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640c1f"
+		}},
+		{"SIP", sipOffer, 2, func(sd *SessionDescription) bool {
+			return len(sd.TimeDescriptions) == 2 &&
+				sd.TimeDescriptions[0].RepeatTimes[0] == "604800 3600 0 90000" &&
+				sd.TimeDescriptions[1].RepeatTimes[0] == "7d 1h 0 25h" &&
+				sd.EmailAddress == "j.doe@example.com (Jane Doe)" &&
+				len(sd.MediaDescriptions[0].Attributes) == 0
+		}},
+		{"PBX", pbxOffer, 1, func(sd *SessionDescription) bool {
+			ptime, _ := sd.MediaDescriptions[0].Attribute("ptime")
+			return sd.SessionName == "Asterisk PBX 13.1.0" && sd.Bandwidth[0] == "CT:384" && ptime == "20"
+		}},
+	} {
+		// Both line endings are accepted, and a trailing blank line
+		for _, raw := range []string{test.raw, strings.Replace(test.raw, "\n", "\r\n", -1) + "\r\n"} {
+			assertUnmarshalCorpus(t, test.name, raw, test.raw, test.mediaDescriptions, test.check)
+		}
+	}
+}
+
+// assertUnmarshalCorpus checks that raw is parsed with mediaDescriptions media descriptions, passes check and is
+// marshaled to expected
+func assertUnmarshalCorpus(t *testing.T, name, raw, expected string, mediaDescriptions int, check func(sd *SessionDescription) bool) {
+	sd := &SessionDescription{}
+	if err := sd.Unmarshal(raw); err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	if len(sd.MediaDescriptions) != mediaDescriptions {
+		t.Errorf("%s: expected %d media descriptions, got %d", name, mediaDescriptions, len(sd.MediaDescriptions))
+		return
+	}
+	if !check(sd) {
+		t.Errorf("%s: was not parsed as expected", name)
+	}
+	if marshaled := sd.Marshal(); strings.ContainsRune(marshaled, '\r') {
+		t.Errorf("%s: CR was left in a value", name)
+	} else if marshaled != expected {
+		t.Errorf("%s: did not round trip:\n%s", name, marshaled)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for _, test := range []struct {
+		raw      string
+		expected string
+	}{
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\nt=0 0\n", "line 3: s (session name) was expected, but not found"},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\n", "session description ended before s (session name) was found"},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\n", "t (time the session is active) was expected, but not found"},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\na=recvonly\nt=0 0\n", "line 5: t= must come before a="},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nc=IN IP4 0.0.0.0\nr=7d 1h 0 25h\nt=0 0\n", "line 5: r= must follow a t= line"},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\ni=a\ni=b\nt=0 0\n", "line 5: i= must only appear once"},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nt=0 0\n\nm=audio 9 RTP/AVP 0\n", "line 5: blank lines are not allowed"},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nt=0 0\nm=audio 9 RTP/AVP 0\na=sendrecv\nc=IN IP4 0.0.0.0\n", "line 7: c= must come before a="},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nt=0 0\nm=audio 9 RTP/AVP 0\nx=unknown\n", "line 6: x= is not a valid line type here"},
+		{"v=0\no=- 1 2 IN IP4 127.0.0.1\ns=-\nt=0\n", "line 4: t (time the session is active) must have 2 fields: 0"},
+	} {
+		err := (&SessionDescription{}).Unmarshal(test.raw)
+		if err == nil {
+			t.Errorf("expected %q unmarshalling %q", test.expected, test.raw)
+		} else if err.Error() != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, err.Error())
+		}
+	}
+}
+
+func TestUnmarshalLenient(t *testing.T) {
+	// A SIP endpoint without s= and t=, with session lines out of order, a blank line and an unknown line type
+	raw := "v=0\r\no=- 1 2 IN IP4 10.0.0.1\r\na=sendrecv\r\nc=IN IP4 10.0.0.1\r\n\r\nx=vendor\r\nm=audio 5004 RTP/AVP 0\r\na=rtpmap:0 PCMU/8000\r\ny=vendor\r\nc=IN IP4 10.0.0.2\r\n"
+	if err := (&SessionDescription{}).Unmarshal(raw); err == nil {
+		t.Errorf("expected the description to be rejected unless lenient")
+	}
+
+	sd := &SessionDescription{}
+	if err := sd.UnmarshalWithOptions(raw, UnmarshalOptions{Lenient: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sd.MediaDescriptions) != 1 || sd.ConnectionData == nil || sd.MediaDescriptions[0].ConnectionData.ConnectionAddress != "10.0.0.2" {
+		t.Errorf("lenient description was not parsed")
+	}
+
+	if err := (&SessionDescription{}).UnmarshalWithOptions("v=0\ns=-\nt=0 0\n", UnmarshalOptions{Lenient: true}); err == nil {
+		t.Errorf("o= must be required even when lenient")
+	}
+}
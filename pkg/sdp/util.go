@@ -0,0 +1,196 @@
+package sdp
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// SessionBuilderTrack represents a single track in a SessionBuilder
+type SessionBuilderTrack struct {
+	SSRC uint32
+
+	// ID and StreamID are announced in the msid of the track
+	// https://tools.ietf.org/html/draft-ietf-mmusic-msid-16
+	ID, StreamID string
+
+	// Kind is the media type of the track, like "audio" or "video"
+	Kind string
+
+	// FECSSRC is the SSRC of the FlexFEC stream protecting the track, if any
+	FECSSRC uint32
+}
+
+// SessionBuilderExtMap represents a single RTP header extension in a SessionBuilder
+// https://tools.ietf.org/html/rfc8285#section-8
+type SessionBuilderExtMap struct {
+	ID      uint8
+	URI     string
+	IsAudio bool
+}
+
+// SessionBuilderMedia represents a single MediaDescription in a SessionBuilder, the answer to the
+// MediaDescription of the offer at the same index
+type SessionBuilderMedia struct {
+	// Kind is the media type, like "audio" or "video"
+	Kind string
+
+	// MID is echoed from the offer, it is left out if empty
+	MID string
+
+	// Protos and Formats are the ones of the offer, Formats are only used when the MediaDescription is rejected
+	Protos  []string
+	Formats []string
+
+	// Codecs are the formats that were accepted in order of preference, without codecs the MediaDescription is
+	// rejected with port 0
+	Codecs []*Codec
+
+	Direction Direction
+
+	// Track is the track sent in the MediaDescription, it is only announced if the MediaDescription sends
+	// https://tools.ietf.org/html/draft-ietf-rtcweb-jsep-24#section-5.2.1
+	Track *SessionBuilderTrack
+}
+
+// SessionBuilder provides an easy way to build an SDP for an RTCPeerConnection
+type SessionBuilder struct {
+	IceUsername, IcePassword, Fingerprint string
+
+	// CNAME is the canonical name of the sources of all tracks
+	// https://tools.ietf.org/html/rfc3550#section-6.5.1
+	CNAME string
+
+	Candidates []*Candidate
+
+	ExtMaps []*SessionBuilderExtMap
+
+	Media []*SessionBuilderMedia
+
+	// Bundle are the mids of the BUNDLE group, there is no group if it is empty
+	// https://tools.ietf.org/html/draft-ietf-mmusic-sdp-bundle-negotiation-54
+	Bundle []string
+}
+
+// BaseSessionDescription generates a default SDP response that is ice-lite, initiates the DTLS session and
+// has a MediaDescription for every SessionBuilderMedia
+func BaseSessionDescription(b *SessionBuilder) *SessionDescription {
+	var mediaDescriptions []*MediaDescription
+	for _, media := range b.Media {
+		mediaDescriptions = append(mediaDescriptions, b.mediaDescriptionFor(media))
+	}
+
+	mediaStreams := " WMS"
+	announced := map[string]bool{}
+	for i, media := range b.Media {
+		track := media.Track
+		if track == nil || len(media.Codecs) == 0 || (media.Direction != DirectionSendRecv && media.Direction != DirectionSendOnly) {
+			continue
+		}
+
+		m := mediaDescriptions[i]
+		m.Attributes = append(m.Attributes, NewAttribute("msid", track.StreamID+" "+track.ID))
+
+		hasFlexFEC := false
+		for _, codec := range media.Codecs {
+			hasFlexFEC = hasFlexFEC || strings.EqualFold(codec.Name, "flexfec-03")
+		}
+
+		ssrcs := []uint32{track.SSRC}
+		if track.FECSSRC != 0 && hasFlexFEC {
+			// https://tools.ietf.org/html/rfc5956#section-4.3
+			group := &SSRCGroup{Semantics: "FEC-FR", SSRCs: []uint32{track.SSRC, track.FECSSRC}}
+			m.Attributes = append(m.Attributes, NewAttribute("ssrc-group", group.String()))
+			ssrcs = append(ssrcs, track.FECSSRC)
+		}
+
+		for _, ssrc := range ssrcs {
+			source := &SSRC{SSRC: ssrc, CNAME: b.CNAME, MSID: track.StreamID + " " + track.ID, MSLabel: track.StreamID, Label: track.ID}
+			m.Attributes = append(m.Attributes, source.Attributes()...)
+		}
+
+		if !announced[track.StreamID] {
+			announced[track.StreamID] = true
+			mediaStreams += " " + track.StreamID
+		}
+	}
+
+	for _, m := range mediaDescriptions {
+		if m.MediaName.Port == 0 {
+			continue
+		}
+		for _, candidate := range b.Candidates {
+			m.Attributes = append(m.Attributes, NewAttribute("candidate", candidate.String()))
+		}
+		m.Attributes = append(m.Attributes, NewPropertyAttribute("end-of-candidates"))
+	}
+
+	var attributes []Attribute
+	if len(b.Bundle) != 0 {
+		attributes = append(attributes, NewAttribute("group", "BUNDLE "+strings.Join(b.Bundle, " ")))
+	}
+	attributes = append(attributes, NewAttribute("msid-semantic", mediaStreams))
+
+	return &SessionDescription{
+		ProtocolVersion: 0,
+		Origin: Origin{
+			Username:       "pion-webrtc",
+			SessionID:      uint64(rand.Uint32())<<32 + uint64(rand.Uint32()),
+			SessionVersion: 2,
+			NetworkType:    "IN",
+			AddressType:    "IP4",
+			UnicastAddress: "0.0.0.0",
+		},
+		SessionName: "-",
+		TimeDescriptions: []TimeDescription{
+			{Timing: Timing{StartTime: 0, StopTime: 0}},
+		},
+		Attributes:        attributes,
+		MediaDescriptions: mediaDescriptions,
+	}
+}
+
+// mediaDescriptionFor returns the MediaDescription answering media, it is rejected with port 0 if it has no codecs
+func (b *SessionBuilder) mediaDescriptionFor(media *SessionBuilderMedia) *MediaDescription {
+	if len(media.Codecs) == 0 {
+		// https://tools.ietf.org/html/rfc3264#section-6
+		m := &MediaDescription{MediaName: MediaName{Media: media.Kind, Port: 0, Protos: media.Protos, Formats: media.Formats}}
+		if media.MID != "" {
+			m.Attributes = append(m.Attributes, NewAttribute("mid", media.MID))
+		}
+		return m
+	}
+
+	var formats []string
+	for _, codec := range media.Codecs {
+		formats = append(formats, strconv.Itoa(int(codec.PayloadType)))
+	}
+
+	m := &MediaDescription{
+		MediaName:      MediaName{Media: media.Kind, Port: 9, Protos: media.Protos, Formats: formats},
+		ConnectionData: &ConnectionData{NetworkType: "IN", AddressType: "IP4", ConnectionAddress: "127.0.0.1"},
+		Attributes:     []Attribute{NewAttribute("setup", "active")},
+	}
+	if media.MID != "" {
+		m.Attributes = append(m.Attributes, NewAttribute("mid", media.MID))
+	}
+	m.Attributes = append(m.Attributes,
+		NewPropertyAttribute(string(media.Direction)),
+		NewAttribute("ice-ufrag", b.IceUsername),
+		NewAttribute("ice-pwd", b.IcePassword),
+		NewPropertyAttribute("ice-lite"),
+		NewAttribute("fingerprint", (&Fingerprint{HashFunction: "sha-256", Value: b.Fingerprint}).String()),
+		NewPropertyAttribute("rtcp-mux"),
+		NewPropertyAttribute("rtcp-rsize"),
+	)
+
+	for _, codec := range media.Codecs {
+		m.Attributes = append(m.Attributes, codec.Attributes()...)
+	}
+	for _, extMap := range b.ExtMaps {
+		if extMap.IsAudio == (media.Kind == "audio") && media.Kind != "application" {
+			m.Attributes = append(m.Attributes, NewAttribute("extmap", (&ExtMap{ID: extMap.ID, URI: extMap.URI}).String()))
+		}
+	}
+	return m
+}
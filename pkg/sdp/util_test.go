@@ -0,0 +1,63 @@
+package sdp
+
+import (
+	"testing"
+)
+
+func TestBaseSessionDescription(t *testing.T) {
+	sd := BaseSessionDescription(&SessionBuilder{
+		CNAME: "cname",
+		Media: []*SessionBuilderMedia{
+			{Kind: "audio", MID: "0", Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"111"}, Direction: DirectionSendRecv},
+			{Kind: "video", MID: "1", Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"96", "115"}, Direction: DirectionSendOnly, Codecs: []*Codec{
+				{PayloadType: 96, Name: "VP8", ClockRate: 90000, RTCPFeedback: []string{"nack pli"}},
+				{PayloadType: 115, Name: "flexfec-03", ClockRate: 90000, Fmtp: "repair-window=10000000"},
+			}, Track: &SessionBuilderTrack{SSRC: 1000, ID: "camera", StreamID: "stream", Kind: "video", FECSSRC: 2000}},
+		},
+		Bundle: []string{"1"},
+	})
+
+	if len(sd.MediaDescriptions) != 2 || sd.Attributes[0].String() != "group:BUNDLE 1" {
+		t.Fatalf("expected a MediaDescription for every offered one and a BUNDLE of the accepted ones")
+	}
+
+	audio := sd.MediaDescriptions[0]
+	if audio.MediaName.String() != "audio 0 UDP/TLS/RTP/SAVPF 111" || len(audio.Attributes) != 1 || audio.Attributes[0].String() != "mid:0" {
+		t.Errorf("audio without codecs should be rejected with port 0 and only its mid: %s %v", audio.MediaName, audio.Attributes)
+	}
+
+	video := sd.MediaDescriptions[1]
+	if video.MediaName.String() != "video 9 UDP/TLS/RTP/SAVPF 96 115" {
+		t.Errorf("video formats were not added: %s", video.MediaName)
+	}
+	if mid, ok := video.MID(); !ok || mid != "1" || video.Direction() != DirectionSendOnly {
+		t.Errorf("video mid and direction were not answered: %q %s", mid, video.Direction())
+	}
+
+	for _, expected := range []string{
+		"rtpmap:96 VP8/90000",
+		"rtcp-fb:96 nack pli",
+		"rtpmap:115 flexfec-03/90000",
+		"fmtp:115 repair-window=10000000",
+		"ssrc-group:FEC-FR 1000 2000",
+		"ssrc:2000 cname:cname",
+		"ssrc:1000 msid:stream camera",
+		"msid:stream camera",
+	} {
+		found := false
+		for _, a := range video.Attributes {
+			found = found || a.String() == expected
+		}
+		if !found {
+			t.Errorf("video is missing a=%s", expected)
+		}
+	}
+
+	if value, _ := sd.Attribute("msid-semantic"); value != " WMS stream" {
+		t.Errorf("the stream of the track was not announced: %q", value)
+	}
+
+	if codecs := video.Codecs(); len(codecs) != 2 || codecs[1].Name != "flexfec-03" || codecs[1].Fmtp != "repair-window=10000000" {
+		t.Errorf("flexfec-03 was not described by its attributes")
+	}
+}
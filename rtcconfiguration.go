@@ -3,6 +3,8 @@ package webrtc
 import (
 	"fmt"
 	"strings"
+
+	"github.com/pions/webrtc/pkg/media/jitterbuffer"
 )
 
 // RTCCredentialType specifies the type of credentials provided
@@ -71,4 +73,23 @@ type RTCConfiguration struct {
 	// these are typically STUN and/or TURN servers. If this isn't specified, the ICE agent may choose to use its own ICE servers;
 	// otherwise, the connection attempt will be made with no STUN or TURN server available, which limits the connection to local peers.
 	ICEServers []RTCICEServer
+
+	// MediaEngine holds the codecs the RTCPeerConnection supports, if it is nil every codec with a payloader is supported
+	MediaEngine *MediaEngine
+
+	// JitterBuffer, if set, places a jitter buffer between the network and the channels given to Ontrack.
+	// Packets are then delivered in sequence number order, after being held for the configured playout delay
+	JitterBuffer *jitterbuffer.Config
+
+	// REDDistance is how many previous frames are sent with every Opus frame when the remote supports RED (RFC 2198),
+	// if it is 0 one previous frame is sent. A negative value disables sending RED
+	REDDistance int
+
+	// FECProtectionRatio is how many FEC packets are sent per media packet on video tracks when the remote supports
+	// FlexFEC-03, or ULPFEC for older peers, 0.25 sends one FEC packet for every four media packets. If it is 0 no
+	// FEC is sent.
+	// Lost packets are recovered from the FEC packets the remote sends regardless
+	FECProtectionRatio float64
 }
+
+const defaultREDDistance = 1
@@ -0,0 +1,236 @@
+package webrtc
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pions/webrtc/pkg/rtp/codecs"
+	"github.com/pkg/errors"
+)
+
+const (
+	dtmfMinDuration     = 40 * time.Millisecond
+	dtmfMaxDuration     = 6000 * time.Millisecond
+	dtmfMinInterToneGap = 30 * time.Millisecond
+	dtmfCommaDelay      = 2000 * time.Millisecond
+
+	// dtmfPacketInterval is how often a tone is updated while it plays, and its end is retransmitted
+	// https://tools.ietf.org/html/rfc4733#section-2.5.1.2
+	dtmfPacketInterval = 50 * time.Millisecond
+	dtmfEndPackets     = 3
+	dtmfVolume         = 10
+
+	// dtmfMaxSegmentDuration is the longest duration of an event in samples, longer events are sent in segments
+	// https://tools.ietf.org/html/rfc4733#section-2.5.1.3
+	dtmfMaxSegmentDuration = 0xFFFF
+)
+
+// dtmfPacket is a telephone event to be sent on an audio track, the marker bit starts a new event. offset is the
+// number of samples since the start of the event the segment of the packet starts at
+type dtmfPacket struct {
+	event  codecs.TelephoneEvent
+	marker bool
+	offset uint32
+}
+
+// RTCDTMFSender sends DTMF tones on an audio track, as RFC 4733 telephone events
+// https://www.w3.org/TR/webrtc/#rtcdtmfsender
+type RTCDTMFSender struct {
+	peerConnection *RTCPeerConnection
+
+	// clockRate is the one of the audio codec of the track, telephone events are sent with the same clock
+	// https://tools.ietf.org/html/rfc4733#section-2.1
+	clockRate uint32
+
+	// packets are sent by the goroutine of the audio track, so they share its SSRC and sequence numbers. done is
+	// closed when the track was removed, nothing reads packets anymore
+	packets chan *dtmfPacket
+	done    chan struct{}
+
+	// after paces the packets of a tone, it is time.After
+	after func(time.Duration) <-chan time.Time
+
+	lock         sync.Mutex
+	toneBuffer   string
+	duration     time.Duration
+	interToneGap time.Duration
+	playing      bool
+	stopped      bool
+}
+
+func newRTCDTMFSender(peerConnection *RTCPeerConnection, clockRate uint32) *RTCDTMFSender {
+	return &RTCDTMFSender{
+		peerConnection: peerConnection,
+		clockRate:      clockRate,
+		packets:        make(chan *dtmfPacket, dtmfEndPackets),
+		done:           make(chan struct{}),
+		after:          time.After,
+	}
+}
+
+// CanInsertDTMF returns if the remote accepted telephone events with the clock rate of the track's codec, which is
+// only known once the answer was created
+func (d *RTCDTMFSender) CanInsertDTMF() bool {
+	_, ok := d.peerConnection.getTelephoneEventPayloadType(d.clockRate)
+	return ok
+}
+
+// ToneBuffer returns the tones that remain to be played
+func (d *RTCDTMFSender) ToneBuffer() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.toneBuffer
+}
+
+// InsertDTMF replaces the tones that remain to be played. tones may contain 0-9, A-D, # and *, every tone is played
+// for duration followed by interToneGap of silence, a comma pauses for 2 seconds instead. duration is limited to
+// 40-6000ms and interToneGap to at least 30ms. A tone that is playing is finished before the new tones are played
+func (d *RTCDTMFSender) InsertDTMF(tones string, duration, interToneGap time.Duration) error {
+	if !d.CanInsertDTMF() {
+		return errors.Errorf("telephone-event was not negotiated, DTMF can't be sent")
+	}
+
+	tones = strings.ToUpper(tones)
+	for _, tone := range tones {
+		if _, ok := codecs.DTMFEvent(tone); !ok && tone != ',' {
+			return errors.Errorf("%q is not a DTMF tone", tone)
+		}
+	}
+
+	if duration < dtmfMinDuration {
+		duration = dtmfMinDuration
+	} else if duration > dtmfMaxDuration {
+		duration = dtmfMaxDuration
+	}
+	if interToneGap < dtmfMinInterToneGap {
+		interToneGap = dtmfMinInterToneGap
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.stopped {
+		return errors.Errorf("the track was removed, DTMF can't be sent")
+	}
+
+	d.toneBuffer = tones
+	d.duration = duration
+	d.interToneGap = interToneGap
+	if !d.playing && tones != "" {
+		d.playing = true
+		go d.play()
+	}
+	return nil
+}
+
+func (d *RTCDTMFSender) play() {
+	for d.playNext() {
+	}
+}
+
+// playNext plays the next tone of the buffer, it returns false once the buffer is empty or the track was removed
+func (d *RTCDTMFSender) playNext() bool {
+	d.lock.Lock()
+	if d.toneBuffer == "" || d.stopped {
+		d.playing = false
+		d.lock.Unlock()
+		return false
+	}
+	tone := rune(d.toneBuffer[0])
+	d.toneBuffer = d.toneBuffer[1:]
+	duration, interToneGap := d.duration, d.interToneGap
+	d.lock.Unlock()
+
+	if tone == ',' {
+		d.sleep(dtmfCommaDelay)
+		return true
+	}
+
+	event, _ := codecs.DTMFEvent(tone)
+	if d.playTone(event, duration) && interToneGap > (dtmfEndPackets-1)*dtmfPacketInterval {
+		d.sleep(interToneGap - (dtmfEndPackets-1)*dtmfPacketInterval)
+	}
+	return true
+}
+
+// playTone sends an update of the event every dtmfPacketInterval, with the duration it will have reached by the
+// next update, then sends its end dtmfEndPackets times. The retransmissions of the end overlap the inter-tone gap.
+// It returns false if the track was removed while the tone played
+// https://tools.ietf.org/html/rfc4733#section-2.5.1
+func (d *RTCDTMFSender) playTone(event uint8, duration time.Duration) bool {
+	marker := true
+	var offset uint32
+	update := func(elapsed time.Duration, end bool) bool {
+		// An event longer than the duration field can hold continues in a new segment, which starts where the
+		// previous one ended
+		total := uint32(elapsed * time.Duration(d.clockRate) / time.Second)
+		for total-offset > dtmfMaxSegmentDuration {
+			segmentEnd := &dtmfPacket{
+				event:  codecs.TelephoneEvent{Event: event, Volume: dtmfVolume, Duration: dtmfMaxSegmentDuration},
+				marker: marker,
+				offset: offset,
+			}
+			if !d.send(segmentEnd) {
+				return false
+			}
+			marker = false
+			offset += dtmfMaxSegmentDuration
+		}
+
+		p := &dtmfPacket{
+			event:  codecs.TelephoneEvent{Event: event, EndOfEvent: end, Volume: dtmfVolume, Duration: uint16(total - offset)},
+			marker: marker,
+			offset: offset,
+		}
+		marker = false
+		return d.send(p)
+	}
+
+	for elapsed := dtmfPacketInterval; elapsed < duration; elapsed += dtmfPacketInterval {
+		if !update(elapsed, false) || !d.sleep(dtmfPacketInterval) {
+			return false
+		}
+	}
+
+	for i := 0; i < dtmfEndPackets; i++ {
+		if i != 0 && !d.sleep(dtmfPacketInterval) {
+			return false
+		}
+		if !update(duration, true) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *RTCDTMFSender) send(p *dtmfPacket) bool {
+	select {
+	case d.packets <- p:
+		return true
+	case <-d.done:
+		return false
+	}
+}
+
+func (d *RTCDTMFSender) sleep(duration time.Duration) bool {
+	select {
+	case <-d.after(duration):
+		return true
+	case <-d.done:
+		return false
+	}
+}
+
+// stop is called when the track was removed, a tone that is playing is abandoned
+func (d *RTCDTMFSender) stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.stopped {
+		return
+	}
+
+	d.stopped = true
+	d.toneBuffer = ""
+	close(d.done)
+}
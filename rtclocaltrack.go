@@ -0,0 +1,95 @@
+package webrtc
+
+import (
+	"sync"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/sdp"
+	"github.com/pkg/errors"
+)
+
+// RTCLocalTrack is a track added with AddRTPTrack, the application writes its RTP packets instead of RTCSamples
+// that are packetized
+type RTCLocalTrack struct {
+	peerConnection *RTCPeerConnection
+	sender         *RTCRtpSender
+	codec          *RTCRtpCodec
+	track          *sdp.SessionBuilderTrack
+
+	lock sync.Mutex
+
+	// sequenceOffset is added to the sequence numbers of written packets, so the track starts at a random one
+	sequenceOffset uint16
+	started        bool
+}
+
+// Sender returns the RTCRtpSender of the track, it is used to remove the track with RemoveTrack
+func (t *RTCLocalTrack) Sender() *RTCRtpSender {
+	return t.sender
+}
+
+// ID returns the id the track was added with
+func (t *RTCLocalTrack) ID() string {
+	return t.track.ID
+}
+
+// StreamID returns the id of the stream the track was added to
+func (t *RTCLocalTrack) StreamID() string {
+	return t.track.StreamID
+}
+
+// SSRC returns the SSRC the track is sent with
+func (t *RTCLocalTrack) SSRC() uint32 {
+	return t.track.SSRC
+}
+
+// Codec returns the codec the track was added with
+func (t *RTCLocalTrack) Codec() *RTCRtpCodec {
+	return t.codec
+}
+
+// WriteRTP sends p with the SSRC of the track, the negotiated payload type of its codec and the track's sequence
+// numbers. Sequence numbers are offset, so gaps and reordering of the written packets are kept. Everything else,
+// like the timestamp, marker and header extensions, is sent as written. p is not modified
+// Packets are dropped until the codec was negotiated, and while the answer doesn't send the track's kind of media
+func (t *RTCLocalTrack) WriteRTP(p *rtp.Packet) error {
+	if p == nil {
+		return errors.Errorf("packet must not be nil")
+	} else if t.sender.getTrack() != t.track {
+		return errors.Errorf("the track was removed")
+	}
+
+	r := t.peerConnection
+	out, ok := t.rewrite(p)
+	if !ok || !t.sender.transceiver.isSending() {
+		return nil
+	}
+
+	r.portsLock.RLock()
+	defer r.portsLock.RUnlock()
+	for _, port := range r.ports {
+		port.Send(out)
+	}
+	return nil
+}
+
+// rewrite returns a copy of p as it is sent, it fails if the codec wasn't negotiated
+func (t *RTCLocalTrack) rewrite(p *rtp.Packet) (*rtp.Packet, bool) {
+	negotiated := t.peerConnection.getNegotiatedCodec(t.codec)
+	if negotiated == nil {
+		return nil, false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.started {
+		t.sequenceOffset -= p.SequenceNumber
+		t.started = true
+	}
+
+	out := &rtp.Packet{Header: p.Header, Payload: p.Payload, PaddingSize: p.PaddingSize}
+	out.SSRC = t.track.SSRC
+	out.PayloadType = negotiated.PayloadType
+	out.SequenceNumber = p.SequenceNumber + t.sequenceOffset
+	return out, true
+}
@@ -0,0 +1,76 @@
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/sdp"
+)
+
+func TestRTCLocalTrackRewrite(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track, err := r.AddRTPTrack(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8), "forwarded", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.remoteDescription = &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "video", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"120"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "120 VP8/90000"},
+			}},
+		},
+	}
+	r.negotiateMedia()
+
+	var first uint16
+	for i, sequenceNumber := range []uint16{65534, 65535, 2, 1} {
+		in := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         i == 3,
+				PayloadType:    96,
+				SequenceNumber: sequenceNumber,
+				Timestamp:      3000,
+				SSRC:           1234,
+				Extensions:     []rtp.Extension{{ID: 3, Payload: []byte{0x01}}},
+			},
+			Payload: []byte{0x90, 0x80},
+		}
+
+		out, ok := track.rewrite(in)
+		if !ok {
+			t.Fatalf("expected VP8 to be negotiated")
+		}
+		if i == 0 {
+			first = out.SequenceNumber
+		}
+
+		if out.SSRC != track.SSRC() || out.PayloadType != 120 {
+			t.Errorf("expected the SSRC of the track and the negotiated payload type, got %d and %d", out.SSRC, out.PayloadType)
+		}
+		// Gaps and reordering are kept across the wrap around
+		if expected := first + sequenceNumber - 65534; out.SequenceNumber != expected {
+			t.Errorf("expected sequence number %d, got %d", expected, out.SequenceNumber)
+		}
+		if out.Timestamp != 3000 || out.Marker != in.Marker || len(out.Extensions) != 1 || len(out.Payload) != 2 {
+			t.Errorf("expected everything else to be passed through: %+v", out)
+		}
+		if in.SSRC != 1234 || in.PayloadType != 96 || in.SequenceNumber != sequenceNumber {
+			t.Errorf("the written packet was modified")
+		}
+	}
+
+	if err := track.Sender().ReplaceTrack(make(chan RTCSample)); err == nil {
+		t.Errorf("expected a track written with WriteRTP to have no source to replace")
+	}
+	if err := r.RemoveTrack(track.Sender()); err != nil {
+		t.Fatal(err)
+	}
+	if err := track.WriteRTP(&rtp.Packet{}); err == nil {
+		t.Errorf("expected writing to a removed track to fail")
+	}
+}
@@ -4,17 +4,23 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pions/pkg/stun"
 	"github.com/pions/webrtc/internal/dtls"
 	"github.com/pions/webrtc/internal/network"
-	"github.com/pions/webrtc/internal/sdp"
 	"github.com/pions/webrtc/internal/util"
 	"github.com/pions/webrtc/pkg/ice"
+	"github.com/pions/webrtc/pkg/media/jitterbuffer"
+	"github.com/pions/webrtc/pkg/rtcp"
 	"github.com/pions/webrtc/pkg/rtp"
 	"github.com/pions/webrtc/pkg/rtp/codecs"
+	"github.com/pions/webrtc/pkg/rtp/fec"
+	"github.com/pions/webrtc/pkg/sdp"
 
 	"github.com/pkg/errors"
 )
@@ -27,9 +33,15 @@ func init() {
 type RTCSample struct {
 	Data    []byte
 	Samples uint32
+
+	// AudioLevel, VideoOrientation and PlayoutDelay are optional, they are sent as RTP header extensions if the
+	// remote supports them
+	AudioLevel       *rtp.AudioLevelExtension
+	VideoOrientation *rtp.VideoOrientationExtension
+	PlayoutDelay     *rtp.PlayoutDelayExtension
 }
 
-// TrackType determines the type of media we are sending receiving
+// TrackType identifies the codecs pion-WebRTC has a payloader for
 type TrackType int
 
 // List of supported TrackTypes
@@ -38,6 +50,11 @@ const (
 	VP9
 	H264
 	Opus
+	AV1
+	H265
+	PCMU
+	PCMA
+	G722
 )
 
 func (t TrackType) String() string {
@@ -50,6 +67,16 @@ func (t TrackType) String() string {
 		return "H264"
 	case Opus:
 		return "Opus"
+	case AV1:
+		return "AV1"
+	case H265:
+		return "H265"
+	case PCMU:
+		return "PCMU"
+	case PCMA:
+		return "PCMA"
+	case G722:
+		return "G722"
 	default:
 		return "Unknown"
 	}
@@ -57,42 +84,98 @@ func (t TrackType) String() string {
 
 // New creates a new RTCPeerConfiguration with the provided configuration
 func New(config *RTCConfiguration) (*RTCPeerConnection, error) {
+	mediaEngine := &MediaEngine{}
+	mediaEngine.RegisterDefaultCodecs()
+	if config != nil && config.MediaEngine != nil {
+		mediaEngine = config.MediaEngine
+	}
+
 	return &RTCPeerConnection{
-		config: config,
+		config:      config,
+		mediaEngine: mediaEngine,
+		codecs:      mediaEngine.negotiate(nil),
+		cname:       util.RandSeq(16),
 	}, nil
 }
 
 // RTCPeerConnection represents a WebRTC connection between itself and a remote peer
 type RTCPeerConnection struct {
-	Ontrack                    func(mediaType TrackType, buffers <-chan *rtp.Packet)
+	// Ontrack is called for every remote track with the channel its packets are delivered on. The RTCTrack has the
+	// negotiated codec, including custom codecs registered in the MediaEngine
+	Ontrack                    func(track *RTCTrack, packets <-chan *rtp.Packet)
 	LocalDescription           *sdp.SessionDescription
 	OnICEConnectionStateChange func(iceConnectionState ice.ConnectionState)
 
-	config *RTCConfiguration
-	tlscfg *dtls.TLSCfg
+	// OnDTMF is called with every DTMF tone received on an audio track, once the tone ended. It is called from the
+	// goroutine delivering the packets of the track, so it should not block
+	OnDTMF func(tone rune, duration time.Duration)
+
+	// OnNegotiationNeeded is called when a change, like RemoveTrack or AddTrack after the answer, needs a new offer
+	// from the remote. The new offer is answered with SetRemoteDescription and CreateAnswer
+	OnNegotiationNeeded func()
+
+	config      *RTCConfiguration
+	mediaEngine *MediaEngine
+	tlscfg      *dtls.TLSCfg
 
 	iceUfrag string
 	icePwd   string
 	iceState ice.ConnectionState
 
+	// cname is the RTCP canonical name of all local tracks
+	cname string
+
 	portsLock sync.RWMutex
 	ports     []*network.Port
 
+	// descriptionsLock guards the LocalDescription and remoteDescription, which are replaced by renegotiation while
+	// tracks are received
+	descriptionsLock  sync.RWMutex
 	remoteDescription *sdp.SessionDescription
 
-	localTracks []*sdp.SessionBuilderTrack
+	localCandidates []*sdp.Candidate
+
+	transceiversLock sync.RWMutex
+	transceivers     []*RTCRtpTransceiver
+
+	headerExtensionsLock  sync.RWMutex
+	audioHeaderExtensions map[string]uint8
+	videoHeaderExtensions map[string]uint8
+
+	// codecs are the codecs of the MediaEngine the remote supports, with the payload types it chose
+	codecsLock          sync.RWMutex
+	codecs              []*RTCRtpCodec
+	redPayloadType      uint8
+	flexFECPayloadType  uint8
+	ulpFECPayloadType   uint8
+	videoREDPayloadType uint8
+
+	// telephoneEventPayloadTypes are keyed by their clock rate, which is the one of the audio they are sent with
+	telephoneEventPayloadTypes map[uint32]uint8
+
+	// senders are the RTCRtpSenders of the tracks added with AddTrack, keyed by the channel AddTrack returned
+	sendersLock sync.RWMutex
+	senders     map[chan<- RTCSample]*RTCRtpSender
+
+	// fecInputs are the channels FlexFEC packets are forwarded to, keyed by the SSRC they protect
+	fecInputsLock sync.Mutex
+	fecInputs     map[uint32]chan<- *rtp.Packet
 }
 
 // Public
 
-// SetRemoteDescription sets the SessionDescription of the remote peer
+// SetRemoteDescription sets the SessionDescription of the remote peer. Setting a new offer after the answer
+// was created renegotiates the session, it is answered by calling CreateAnswer again
 func (r *RTCPeerConnection) SetRemoteDescription(rawSessionDescription string) error {
-	if r.remoteDescription != nil {
-		return errors.Errorf("remoteDescription is already defined, SetRemoteDescription can only be called once")
+	remoteDescription := &sdp.SessionDescription{}
+	if err := remoteDescription.Unmarshal(rawSessionDescription); err != nil {
+		return err
 	}
 
-	r.remoteDescription = &sdp.SessionDescription{}
-	return r.remoteDescription.Unmarshal(rawSessionDescription)
+	r.descriptionsLock.Lock()
+	defer r.descriptionsLock.Unlock()
+	r.remoteDescription = remoteDescription
+	return nil
 }
 
 // CreateOffer starts the RTCPeerConnection and generates the localDescription
@@ -100,12 +183,242 @@ func (r *RTCPeerConnection) CreateOffer() error {
 	return errors.Errorf("CreateOffer is not implemented")
 }
 
-// CreateAnswer starts the RTCPeerConnection and generates the localDescription
+// CreateAnswer starts the RTCPeerConnection and generates the localDescription. Calling it again answers a new
+// offer of the remote, the session keeps its ICE candidates and DTLS certificate
 func (r *RTCPeerConnection) CreateAnswer() error {
-	if r.tlscfg != nil {
-		return errors.Errorf("tlscfg is already defined, CreateOffer can only be called once")
+	if r.tlscfg == nil {
+		if err := r.gatherCandidates(); err != nil {
+			return err
+		}
+	}
+
+	r.descriptionsLock.Lock()
+	defer r.descriptionsLock.Unlock()
+
+	media, bundle := r.negotiateMedia()
+	localDescription := sdp.BaseSessionDescription(&sdp.SessionBuilder{
+		IceUsername: r.iceUfrag,
+		IcePassword: r.icePwd,
+		Fingerprint: r.tlscfg.Fingerprint(),
+		CNAME:       r.cname,
+		Candidates:  r.localCandidates,
+		ExtMaps:     r.negotiateHeaderExtensions(),
+		Media:       media,
+		Bundle:      bundle,
+	})
+
+	// A new answer of the session has the origin of the previous one, with a higher version
+	// https://tools.ietf.org/html/rfc3264#section-8
+	if r.LocalDescription != nil {
+		localDescription.Origin = r.LocalDescription.Origin
+		localDescription.Origin.SessionVersion++
+	}
+	r.LocalDescription = localDescription
+
+	return nil
+}
+
+// AddTrack adds a track of codec, which must be registered in the MediaEngine with a NewPayloader, and returns the
+// channel to push its samples on, closing it is like RemoveTrack. The track is sent by the first RTCRtpTransceiver
+// of its kind without a track, or a new one, samples are dropped while it doesn't send codec. trackID and streamID
+// are announced in the msid of the track
+func (r *RTCPeerConnection) AddTrack(codec *RTCRtpCodec, trackID, streamID string) (samples chan<- RTCSample, err error) {
+	if err := r.validateTrack(codec, trackID, streamID); err != nil {
+		return nil, err
+	}
+
+	// Samples are packetized with the payloader of the registered codec, which the negotiated one is a copy of
+	if r.mediaEngine.getCodec(codec).NewPayloader == nil {
+		return nil, errors.Errorf("%s/%d has no NewPayloader, use AddRTPTrack to send it", codec.MimeType, codec.ClockRate)
+	}
+
+	trackInput := make(chan RTCSample, 15)
+
+	var dtmfSender *RTCDTMFSender
+	if codec.isAudio() {
+		dtmfSender = newRTCDTMFSender(r, codec.ClockRate)
+	}
+
+	sdpTrack := &sdp.SessionBuilderTrack{SSRC: rand.Uint32(), ID: trackID, StreamID: streamID, Kind: codec.Kind()}
+	if codec.Kind() == "video" && r.fecProtectionRatio() > 0 {
+		sdpTrack.FECSSRC = rand.Uint32()
+	}
+	sender, replaced, removed := r.addLocalTrack(codec, sdpTrack, trackInput, dtmfSender)
+
+	r.sendersLock.Lock()
+	if r.senders == nil {
+		r.senders = map[chan<- RTCSample]*RTCRtpSender{}
+	}
+	r.senders[trackInput] = sender
+	r.sendersLock.Unlock()
+
+	s := newSampleSender(sender, codec, sdpTrack, dtmfSender)
+	go s.run(trackInput, replaced, removed)
+	return trackInput, nil
+}
+
+// AddTransceiver adds an RTCRtpTransceiver for media of kind, like "audio" or "video", which must have a codec
+// registered in the MediaEngine. The transceiver is negotiated in the first offered MediaDescription of its kind
+// that has no transceiver, which is answered sending and receiving as far as direction and the offer allow. A
+// transceiver only sends once a track is added to it with AddTrack
+func (r *RTCPeerConnection) AddTransceiver(kind string, direction RTCRtpTransceiverDirection) (*RTCRtpTransceiver, error) {
+	if !r.mediaEngine.hasKind(kind) {
+		return nil, errors.Errorf("no codec of kind %q is registered in the MediaEngine", kind)
+	} else if direction < RTCRtpTransceiverDirectionSendrecv || direction > RTCRtpTransceiverDirectionInactive {
+		return nil, errors.Errorf("invalid RTCRtpTransceiverDirection %d", direction)
+	}
+
+	transceiver := newRTCRtpTransceiver(r, kind, direction)
+
+	r.transceiversLock.Lock()
+	r.transceivers = append(r.transceivers, transceiver)
+	r.transceiversLock.Unlock()
+	return transceiver, nil
+}
+
+// GetTransceivers returns the RTCRtpTransceivers added with AddTransceiver and AddTrack, and the ones the answer
+// added to receive offered media
+func (r *RTCPeerConnection) GetTransceivers() []*RTCRtpTransceiver {
+	r.transceiversLock.RLock()
+	defer r.transceiversLock.RUnlock()
+
+	return append([]*RTCRtpTransceiver{}, r.transceivers...)
+}
+
+// GetSenders returns the RTCRtpSenders of the RTCRtpTransceivers that are not stopped
+func (r *RTCPeerConnection) GetSenders() (senders []*RTCRtpSender) {
+	for _, t := range r.GetTransceivers() {
+		if !t.Stopped() {
+			senders = append(senders, t.sender)
+		}
+	}
+	return senders
+}
+
+// GetReceivers returns the RTCRtpReceivers of the RTCRtpTransceivers that are not stopped
+func (r *RTCPeerConnection) GetReceivers() (receivers []*RTCRtpReceiver) {
+	for _, t := range r.GetTransceivers() {
+		if !t.Stopped() {
+			receivers = append(receivers, t.receiver)
+		}
+	}
+	return receivers
+}
+
+// AddRTPTrack adds a new track whose RTP packets are written with WriteRTP, like when forwarding the packets of a
+// remote track. codec must be registered in the MediaEngine, the packets are sent with its negotiated payload type.
+// The track is sent by an RTCRtpTransceiver like the tracks of AddTrack, it can be removed with RemoveTrack.
+// trackID and streamID are announced like the ones of AddTrack
+func (r *RTCPeerConnection) AddRTPTrack(codec *RTCRtpCodec, trackID, streamID string) (*RTCLocalTrack, error) {
+	if err := r.validateTrack(codec, trackID, streamID); err != nil {
+		return nil, err
+	}
+
+	sdpTrack := &sdp.SessionBuilderTrack{SSRC: rand.Uint32(), ID: trackID, StreamID: streamID, Kind: codec.Kind()}
+	sender, _, _ := r.addLocalTrack(codec, sdpTrack, nil, nil)
+	return &RTCLocalTrack{
+		peerConnection: r,
+		sender:         sender,
+		codec:          codec,
+		track:          sdpTrack,
+		sequenceOffset: uint16(rand.Uint32()),
+	}, nil
+}
+
+// GetSender returns the RTCRtpSender of a track added with AddTrack, samples is the channel AddTrack returned
+func (r *RTCPeerConnection) GetSender(samples chan<- RTCSample) (*RTCRtpSender, error) {
+	r.sendersLock.RLock()
+	defer r.sendersLock.RUnlock()
+
+	sender, ok := r.senders[samples]
+	if !ok {
+		return nil, errors.Errorf("samples is not the channel of a track")
+	}
+	return sender, nil
+}
+
+// GetDTMFSender returns the RTCDTMFSender of an audio track added with AddTrack, its tones are sent on the SSRC
+// of the track with the telephone-event payload type the remote negotiated
+func (r *RTCPeerConnection) GetDTMFSender(samples chan<- RTCSample) (*RTCDTMFSender, error) {
+	sender, err := r.GetSender(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	dtmfSender := sender.DTMF()
+	if dtmfSender == nil {
+		return nil, errors.Errorf("samples is not the channel of an audio track")
+	}
+	return dtmfSender, nil
+}
+
+// RemoveTrack stops sending the track of sender, the remote is told with an RTCP BYE. The transceiver of the
+// sender no longer sends, OnNegotiationNeeded is called so the remote can make a new offer to answer with
+// CreateAnswer. The channel AddTrack returned is no longer read
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-removetrack
+func (r *RTCPeerConnection) RemoveTrack(sender *RTCRtpSender) error {
+	if sender == nil || sender.peerConnection != r {
+		return errors.Errorf("sender does not belong to this RTCPeerConnection")
+	}
+
+	track, samples, ok := sender.removeTrack()
+	if !ok {
+		return errors.Errorf("sender has no track")
 	}
+	r.sendGoodbye(track)
+
+	r.sendersLock.Lock()
+	delete(r.senders, samples)
+	r.sendersLock.Unlock()
+
+	t := sender.transceiver
+	t.lock.Lock()
+	switch t.direction {
+	case RTCRtpTransceiverDirectionSendrecv:
+		t.direction = RTCRtpTransceiverDirectionRecvonly
+	case RTCRtpTransceiverDirectionSendonly:
+		t.direction = RTCRtpTransceiverDirectionInactive
+	}
+	t.lock.Unlock()
+
+	if r.OnNegotiationNeeded != nil {
+		r.OnNegotiationNeeded()
+	}
+	return nil
+}
+
+// GetHeaderExtensionID returns the negotiated ID of the RTP header extension with the given URI for the media kind
+// of codec. The same ID is used for sending and receiving, so it can be used to look up extensions on
+// packets delivered to Ontrack. Header extensions are only negotiated for audio and video
+func (r *RTCPeerConnection) GetHeaderExtensionID(codec *RTCRtpCodec, uri string) (id uint8, ok bool) {
+	r.headerExtensionsLock.RLock()
+	defer r.headerExtensionsLock.RUnlock()
+
+	switch codec.Kind() {
+	case "audio":
+		id, ok = r.audioHeaderExtensions[uri]
+	case "video":
+		id, ok = r.videoHeaderExtensions[uri]
+	}
+	return id, ok
+}
+
+// Close ends the RTCPeerConnection
+func (r *RTCPeerConnection) Close() error {
+	r.portsLock.Lock()
+	defer r.portsLock.Unlock()
+
+	// Walk all ports remove and close them
+	for _, p := range r.ports {
+		if err := p.Close(); err != nil {
+			return err
+		}
+	}
+	r.ports = nil
+	return nil
+}
 
+func (r *RTCPeerConnection) gatherCandidates() error {
 	r.tlscfg = dtls.NewTLSCfg()
 	r.iceUfrag = util.RandSeq(16)
 	r.icePwd = util.RandSeq(32)
@@ -113,14 +426,22 @@ func (r *RTCPeerConnection) CreateAnswer() error {
 	r.portsLock.Lock()
 	defer r.portsLock.Unlock()
 
-	candidates := []string{}
+	r.localCandidates = nil
 	basePriority := uint16(rand.Uint32() & (1<<16 - 1))
 	for _, c := range ice.HostInterfaces() {
 		port, err := network.NewPort(c+":0", []byte(r.icePwd), r.tlscfg, r.generateChannel, r.iceStateChange)
 		if err != nil {
 			return err
 		}
-		candidates = append(candidates, fmt.Sprintf("candidate:udpcandidate 1 udp %d %s %d typ host", basePriority, c, port.ListeningAddr.Port))
+		r.localCandidates = append(r.localCandidates, &sdp.Candidate{
+			Foundation: "udpcandidate",
+			Component:  1,
+			Transport:  "udp",
+			Priority:   uint32(basePriority),
+			Address:    c,
+			Port:       port.ListeningAddr.Port,
+			Type:       "host",
+		})
 		basePriority = basePriority + 1
 		r.ports = append(r.ports, port)
 	}
@@ -169,113 +490,915 @@ func (r *RTCPeerConnection) CreateAnswer() error {
 				if err != nil {
 					return errors.Wrapf(err, "Failed to build network/port")
 				}
-				candidates = append(candidates, fmt.Sprintf("candidate:%scandidate 1 %s %d %s %d typ srflx", proto, proto, basePriority, addr.IP.String(), localAddr.Port))
+				r.localCandidates = append(r.localCandidates, &sdp.Candidate{
+					Foundation: proto + "candidate",
+					Component:  1,
+					Transport:  proto,
+					Priority:   uint32(basePriority),
+					Address:    addr.IP.String(),
+					Port:       localAddr.Port,
+					Type:       "srflx",
+				})
 				basePriority = basePriority + 1
 				r.ports = append(r.ports, port)
 			}
 		}
 	}
 
-	r.LocalDescription = sdp.BaseSessionDescription(&sdp.SessionBuilder{
-		IceUsername: r.iceUfrag,
-		IcePassword: r.icePwd,
-		Fingerprint: r.tlscfg.Fingerprint(),
-		Candidates:  candidates,
-		Tracks:      r.localTracks,
-	})
+	return nil
+}
+
+func (r *RTCPeerConnection) negotiateHeaderExtensions() (extMaps []*sdp.SessionBuilderExtMap) {
+	if r.remoteDescription == nil {
+		return nil
+	}
+
+	r.headerExtensionsLock.Lock()
+	defer r.headerExtensionsLock.Unlock()
+
+	offered := func(mediaType string) map[string]uint8 {
+		extMaps := map[string]uint8{}
+		if m := r.remoteDescription.FindMediaDescription(mediaType); m != nil {
+			for _, extMap := range m.ExtMaps() {
+				extMaps[extMap.URI] = extMap.ID
+			}
+		}
+		return extMaps
+	}
+
+	r.audioHeaderExtensions = rtp.NegotiateHeaderExtensions(offered("audio"), true)
+	r.videoHeaderExtensions = rtp.NegotiateHeaderExtensions(offered("video"), false)
+
+	for uri, id := range r.audioHeaderExtensions {
+		extMaps = append(extMaps, &sdp.SessionBuilderExtMap{ID: id, URI: uri, IsAudio: true})
+	}
+	for uri, id := range r.videoHeaderExtensions {
+		extMaps = append(extMaps, &sdp.SessionBuilderExtMap{ID: id, URI: uri})
+	}
+	sort.Slice(extMaps, func(i, j int) bool { return extMaps[i].ID < extMaps[j].ID })
+	return extMaps
+}
+
+func (r *RTCPeerConnection) negotiateMedia() (media []*sdp.SessionBuilderMedia, bundle []string) {
+	r.codecsLock.Lock()
+	defer r.codecsLock.Unlock()
+
+	r.codecs = r.mediaEngine.negotiate(r.remoteDescription)
+	r.redPayloadType, r.flexFECPayloadType, r.ulpFECPayloadType, r.videoREDPayloadType = 0, 0, 0, 0
+	r.telephoneEventPayloadTypes = nil
+
+	r.transceiversLock.Lock()
+	defer r.transceiversLock.Unlock()
+
+	for _, t := range r.transceivers {
+		t.negotiate(sdp.DirectionInactive)
+	}
+
+	// Without an offer every transceiver gets a bundled MediaDescription, using its index as mid. Kinds with a
+	// codec but no transceiver are received by a recvonly transceiver
+	if r.remoteDescription == nil {
+		for _, kind := range []string{"audio", "video", "application"} {
+			if !r.hasTransceiver(kind) && r.mediaEngine.hasKind(kind) {
+				r.transceivers = append(r.transceivers, newRTCRtpTransceiver(r, kind, RTCRtpTransceiverDirectionRecvonly))
+			}
+		}
+
+		for _, t := range r.transceivers {
+			if t.Stopped() {
+				continue
+			}
+
+			mid := strconv.Itoa(len(media))
+			t.associate(len(media), mid)
+			m := &sdp.SessionBuilderMedia{
+				Kind:      t.kind,
+				MID:       mid,
+				Protos:    []string{"RTP", "SAVPF"},
+				Direction: answerDirection(sdp.DirectionSendRecv, t.sends(), t.receives()),
+			}
+			t.negotiate(m.Direction)
+			for _, codec := range r.codecs {
+				if codec.Kind() == t.kind {
+					m.Codecs = append(m.Codecs, codec.sdpCodec())
+				}
+			}
+			if t.sends() {
+				m.Track = t.sender.getTrack()
+			}
+			media = append(media, m)
+			bundle = append(bundle, mid)
+		}
+		return media, bundle
+	}
+
+	// The answer has the MediaDescriptions of the offer in the same order, the ones that were accepted stay
+	// in the BUNDLE group they were offered in
+	// https://tools.ietf.org/html/rfc3264#section-6
+	// Every accepted MediaDescription is negotiated by a transceiver of its own, which sends at most one track
+	// https://tools.ietf.org/html/draft-ietf-rtcweb-jsep-24#section-5.3.1
+	offeredBundle, _ := r.remoteDescription.Bundle()
+	for i, offered := range r.remoteDescription.MediaDescriptions {
+		mid, _ := offered.MID()
+		m := &sdp.SessionBuilderMedia{
+			Kind:      offered.MediaName.Media,
+			MID:       mid,
+			Protos:    offered.MediaName.Protos,
+			Formats:   offered.MediaName.Formats,
+			Codecs:    r.answerCodecs(offered),
+			Direction: sdp.DirectionInactive,
+		}
+		if len(m.Codecs) != 0 {
+			if t := r.associateTransceiver(i, m.Kind, mid); t == nil {
+				m.Codecs = nil
+			} else {
+				m.Direction = answerDirection(offered.Direction(), t.sends(), t.receives())
+				t.negotiate(m.Direction)
+				if t.sends() {
+					m.Track = t.sender.getTrack()
+				}
+			}
+		}
+		media = append(media, m)
+
+		for _, bundled := range offeredBundle {
+			if len(m.Codecs) != 0 && mid != "" && bundled == mid {
+				bundle = append(bundle, mid)
+			}
+		}
+	}
+	return media, bundle
+}
+
+func (r *RTCPeerConnection) answerCodecs(offered *sdp.MediaDescription) (codecs []*sdp.Codec) {
+	var opus *RTCRtpCodec
+	for _, codec := range r.mediaEngine.negotiateMedia(offered) {
+		codecs = append(codecs, codec.sdpCodec())
+		if codec.Type == Opus && opus == nil {
+			opus = codec
+		}
+	}
+	if len(codecs) == 0 {
+		return nil
+	}
 
+	// RED, FEC and telephone events are only answered if the remote offered them. Their payload types are
+	// expected to be the same in every MediaDescription of a kind, as they are when bundled
+	offeredCodecs := offered.Codecs()
+	payloadType := func(name string, clockRate uint32) uint8 {
+		for _, codec := range offeredCodecs {
+			if strings.EqualFold(codec.Name, name) && codec.ClockRate == clockRate {
+				return codec.PayloadType
+			}
+		}
+		return 0
+	}
+
+	switch offered.MediaName.Media {
+	case "audio":
+		if redPayloadType := payloadType("red", 48000); redPayloadType != 0 && opus != nil {
+			r.redPayloadType = redPayloadType
+			primaryPayloadType := strconv.Itoa(int(opus.PayloadType))
+			codecs = append([]*sdp.Codec{{
+				PayloadType: redPayloadType,
+				Name:        "red",
+				ClockRate:   48000,
+				Channels:    2,
+				Fmtp:        primaryPayloadType + "/" + primaryPayloadType,
+			}}, codecs...)
+		}
+
+		// Telephone events use the clock of the audio they are sent with, one is answered for every clock rate of the
+		// accepted audio codecs, like 48000 for Opus and 8000 for PCMU
+		// https://tools.ietf.org/html/rfc4733#section-2.1
+		for _, clockRate := range audioClockRates(codecs) {
+			if telephoneEventPayloadType := payloadType("telephone-event", clockRate); telephoneEventPayloadType != 0 {
+				if r.telephoneEventPayloadTypes == nil {
+					r.telephoneEventPayloadTypes = map[uint32]uint8{}
+				}
+				r.telephoneEventPayloadTypes[clockRate] = telephoneEventPayloadType
+				codecs = append(codecs, &sdp.Codec{PayloadType: telephoneEventPayloadType, Name: "telephone-event", ClockRate: clockRate, Fmtp: "0-15"})
+			}
+		}
+
+	case "video":
+		// ULPFEC is only used inside RED, and RED video only to carry ULPFEC
+		ulpFECPayloadType, videoREDPayloadType := payloadType("ulpfec", 90000), payloadType("red", 90000)
+		if ulpFECPayloadType != 0 && videoREDPayloadType != 0 {
+			r.ulpFECPayloadType, r.videoREDPayloadType = ulpFECPayloadType, videoREDPayloadType
+			codecs = append(codecs,
+				&sdp.Codec{PayloadType: videoREDPayloadType, Name: "red", ClockRate: 90000},
+				&sdp.Codec{PayloadType: ulpFECPayloadType, Name: "ulpfec", ClockRate: 90000},
+			)
+		}
+
+		if flexFECPayloadType := payloadType("flexfec-03", 90000); flexFECPayloadType != 0 {
+			r.flexFECPayloadType = flexFECPayloadType
+			codecs = append(codecs, &sdp.Codec{PayloadType: flexFECPayloadType, Name: "flexfec-03", ClockRate: 90000, Fmtp: "repair-window=10000000"})
+		}
+	}
+	return codecs
+}
+
+func audioClockRates(codecs []*sdp.Codec) (clockRates []uint32) {
+	for _, codec := range codecs {
+		if strings.EqualFold(codec.Name, "red") {
+			continue
+		}
+
+		duplicate := false
+		for _, clockRate := range clockRates {
+			duplicate = duplicate || clockRate == codec.ClockRate
+		}
+		if !duplicate {
+			clockRates = append(clockRates, codec.ClockRate)
+		}
+	}
+	return clockRates
+}
+
+func (r *RTCPeerConnection) validateTrack(codec *RTCRtpCodec, trackID, streamID string) error {
+	if codec == nil {
+		return errors.Errorf("codec must not be nil")
+	} else if r.mediaEngine.getCodec(codec) == nil {
+		return errors.Errorf("%s/%d is not registered in the MediaEngine", codec.MimeType, codec.ClockRate)
+	}
+
+	// The ids are announced in the msid attribute, they are tokens without whitespace
+	// https://tools.ietf.org/html/draft-ietf-mmusic-msid-16#section-2
+	for _, id := range []string{trackID, streamID} {
+		if id == "" || strings.ContainsAny(id, " \t\r\n") {
+			return errors.Errorf("track and stream ids must not be empty or contain whitespace")
+		}
+	}
 	return nil
 }
 
-// AddTrack adds a new track to the RTCPeerConnection
-// This function returns a channel to push buffers on, and an error if the channel can't be added
-// Closing the channel ends this stream
-func (r *RTCPeerConnection) AddTrack(mediaType TrackType, clockRate uint32) (samples chan<- RTCSample, err error) {
-	if mediaType != VP8 && mediaType != H264 && mediaType != Opus {
-		panic("TODO Discarding packet, need media parsing")
+func (r *RTCPeerConnection) addLocalTrack(codec *RTCRtpCodec, track *sdp.SessionBuilderTrack, samples chan<- RTCSample, dtmfSender *RTCDTMFSender) (
+	sender *RTCRtpSender, replaced <-chan (<-chan RTCSample), removed <-chan struct{}) {
+	sender, replaced, removed = r.setLocalTrack(codec, track, samples, dtmfSender)
+
+	// Once the session was negotiated the remote has to offer a MediaDescription to send the track in
+	r.descriptionsLock.RLock()
+	negotiated := r.LocalDescription != nil
+	r.descriptionsLock.RUnlock()
+	if negotiated && r.OnNegotiationNeeded != nil {
+		r.OnNegotiationNeeded()
 	}
+	return sender, replaced, removed
+}
 
-	trackInput := make(chan RTCSample, 15)
-	go func() {
-		ssrc := rand.Uint32()
-		sdpTrack := &sdp.SessionBuilderTrack{SSRC: ssrc}
-		var payloader rtp.Payloader
-		var payloadType uint8
-		switch mediaType {
-		case Opus:
-			sdpTrack.IsAudio = true
-			payloader = &codecs.OpusPayloader{}
-			payloadType = 111
-
-		case VP8:
-			payloader = &codecs.VP8Payloader{}
-			payloadType = 96
-
-		case H264:
-			payloader = &codecs.H264Payloader{}
-			payloadType = 100
-		}
-
-		r.localTracks = append(r.localTracks, sdpTrack)
-		packetizer := rtp.NewPacketizer(1400, payloadType, ssrc, payloader, rtp.NewRandomSequencer(), clockRate)
-		for {
-			in := <-trackInput
-			packets := packetizer.Packetize(in.Data, in.Samples)
-			for _, p := range packets {
-				for _, port := range r.ports {
-					port.Send(p)
+func (r *RTCPeerConnection) setLocalTrack(codec *RTCRtpCodec, track *sdp.SessionBuilderTrack, samples chan<- RTCSample, dtmfSender *RTCDTMFSender) (
+	sender *RTCRtpSender, replaced <-chan (<-chan RTCSample), removed <-chan struct{}) {
+	r.transceiversLock.Lock()
+	defer r.transceiversLock.Unlock()
+
+	for _, t := range r.transceivers {
+		if t.kind != track.Kind || t.Stopped() || t.sender.getTrack() != nil {
+			continue
+		}
+
+		// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-addtrack
+		t.lock.Lock()
+		switch t.direction {
+		case RTCRtpTransceiverDirectionRecvonly:
+			t.direction = RTCRtpTransceiverDirectionSendrecv
+		case RTCRtpTransceiverDirectionInactive:
+			t.direction = RTCRtpTransceiverDirectionSendonly
+		}
+		t.lock.Unlock()
+
+		replaced, removed = t.sender.setTrack(codec, track, samples, dtmfSender)
+		return t.sender, replaced, removed
+	}
+
+	t := newRTCRtpTransceiver(r, track.Kind, RTCRtpTransceiverDirectionSendrecv)
+	r.transceivers = append(r.transceivers, t)
+	replaced, removed = t.sender.setTrack(codec, track, samples, dtmfSender)
+	return t.sender, replaced, removed
+}
+
+func (r *RTCPeerConnection) hasTransceiver(kind string) bool {
+	for _, t := range r.transceivers {
+		if t.kind == kind && !t.Stopped() {
+			return true
+		}
+	}
+	return false
+}
+
+// associateTransceiver returns the transceiver an offered MediaDescription is negotiated with, it must be called
+// with transceiversLock held. It is nil if the MediaDescription was negotiated with a transceiver that was stopped,
+// the MediaDescription is then rejected
+func (r *RTCPeerConnection) associateTransceiver(mLineIndex int, kind, mid string) *RTCRtpTransceiver {
+	for _, t := range r.transceivers {
+		if t.kind != kind || !t.isAssociated(mLineIndex, mid) {
+			continue
+		}
+		if t.Stopped() {
+			return nil
+		}
+		t.associate(mLineIndex, mid)
+		return t
+	}
+
+	// A new MediaDescription is negotiated with the first transceiver of its kind that wasn't negotiated yet, or
+	// received by a recvonly transceiver that is added for it
+	// https://www.w3.org/TR/webrtc/#set-description
+	for _, t := range r.transceivers {
+		if t.kind == kind && !t.Stopped() && t.getMLineIndex() == -1 {
+			t.associate(mLineIndex, mid)
+			return t
+		}
+	}
+
+	t := newRTCRtpTransceiver(r, kind, RTCRtpTransceiverDirectionRecvonly)
+	t.associate(mLineIndex, mid)
+	r.transceivers = append(r.transceivers, t)
+	return t
+}
+
+// getReceiver returns the receiver of a remote SSRC. It is the one of the transceiver negotiated in the
+// MediaDescription announcing the SSRC, SSRCs that weren't announced are received by the first transceiver of their
+// kind that doesn't receive one yet
+func (r *RTCPeerConnection) getReceiver(kind string, ssrc uint32) *RTCRtpReceiver {
+	announcedIndex := -1
+	r.descriptionsLock.RLock()
+	if r.remoteDescription != nil {
+		for i, m := range r.remoteDescription.MediaDescriptions {
+			for _, source := range m.SSRCs() {
+				if source.SSRC == ssrc && m.MediaName.Media == kind {
+					announcedIndex = i
 				}
 			}
 		}
-	}()
-	return trackInput, nil
+	}
+	r.descriptionsLock.RUnlock()
+
+	var receiver *RTCRtpReceiver
+	for _, t := range r.GetTransceivers() {
+		if t.kind != kind || !t.isReceiving() {
+			continue
+		}
+		if announcedIndex != -1 {
+			if t.getMLineIndex() == announcedIndex {
+				return t.receiver
+			}
+			continue
+		}
+		if receiver == nil || (receiver.hasSSRCs() && !t.receiver.hasSSRCs()) {
+			receiver = t.receiver
+		}
+	}
+	return receiver
 }
 
-// Close ends the RTCPeerConnection
-func (r *RTCPeerConnection) Close() error {
-	r.portsLock.Lock()
-	defer r.portsLock.Unlock()
+func (r *RTCPeerConnection) getNegotiatedCodecs(kind string) (codecs []*RTCRtpCodec) {
+	r.codecsLock.RLock()
+	defer r.codecsLock.RUnlock()
 
-	// Walk all ports remove and close them
-	for _, p := range r.ports {
-		if err := p.Close(); err != nil {
-			return err
+	for _, codec := range r.codecs {
+		if codec.Kind() == kind {
+			codecs = append(codecs, codec)
+		}
+	}
+	return codecs
+}
+
+func (r *RTCPeerConnection) getHeaderExtensionParameters(kind string) (parameters []RTCRtpHeaderExtensionParameters) {
+	r.headerExtensionsLock.RLock()
+	defer r.headerExtensionsLock.RUnlock()
+
+	headerExtensions := r.videoHeaderExtensions
+	if kind == "audio" {
+		headerExtensions = r.audioHeaderExtensions
+	} else if kind != "video" {
+		return nil
+	}
+
+	for uri, id := range headerExtensions {
+		parameters = append(parameters, RTCRtpHeaderExtensionParameters{URI: uri, ID: id})
+	}
+	sort.Slice(parameters, func(i, j int) bool { return parameters[i].ID < parameters[j].ID })
+	return parameters
+}
+
+// answerDirection returns the direction of an answer that sends and receives as wanted, as far as the offer allows
+// https://tools.ietf.org/html/rfc3264#section-6.1
+func answerDirection(offered sdp.Direction, send, receive bool) sdp.Direction {
+	send = send && (offered == sdp.DirectionSendRecv || offered == sdp.DirectionRecvOnly)
+	receive = receive && (offered == sdp.DirectionSendRecv || offered == sdp.DirectionSendOnly)
+	switch {
+	case send && receive:
+		return sdp.DirectionSendRecv
+	case send:
+		return sdp.DirectionSendOnly
+	case receive:
+		return sdp.DirectionRecvOnly
+	default:
+		return sdp.DirectionInactive
+	}
+}
+
+func (r *RTCPeerConnection) getRemoteCodec(payloadType uint8) *sdp.Codec {
+	r.descriptionsLock.RLock()
+	defer r.descriptionsLock.RUnlock()
+
+	for _, m := range r.remoteDescription.MediaDescriptions {
+		for _, codec := range m.Codecs() {
+			if codec.PayloadType == payloadType {
+				return codec
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RTCPeerConnection) getNegotiatedCodec(codec *RTCRtpCodec) *RTCRtpCodec {
+	r.codecsLock.RLock()
+	defer r.codecsLock.RUnlock()
+
+	for _, negotiated := range r.codecs {
+		if negotiated.Kind() == codec.Kind() && negotiated.matches(codec.Name(), codec.ClockRate, codec.Channels) {
+			return negotiated
 		}
 	}
-	r.ports = nil
 	return nil
 }
 
+func (r *RTCPeerConnection) getCodecForPayloadType(payloadType uint8) *RTCRtpCodec {
+	r.codecsLock.RLock()
+	defer r.codecsLock.RUnlock()
+
+	for _, codec := range r.codecs {
+		if codec.PayloadType == payloadType {
+			return codec
+		}
+	}
+	return nil
+}
+
+func (r *RTCPeerConnection) isREDPayloadType(payloadType uint8) bool {
+	r.codecsLock.RLock()
+	defer r.codecsLock.RUnlock()
+
+	return payloadType != 0 && (payloadType == r.redPayloadType || payloadType == r.videoREDPayloadType)
+}
+
+func (r *RTCPeerConnection) getREDPayloadType() (uint8, bool) {
+	r.codecsLock.RLock()
+	defer r.codecsLock.RUnlock()
+
+	if r.redPayloadType == 0 || r.redDistance() < 0 {
+		return 0, false
+	}
+	return r.redPayloadType, true
+}
+
+func (r *RTCPeerConnection) redDistance() int {
+	if r.config == nil || r.config.REDDistance == 0 {
+		return defaultREDDistance
+	}
+	return r.config.REDDistance
+}
+
+func (r *RTCPeerConnection) getFECPayloadTypes() (flexFECPayloadType, ulpFECPayloadType, videoREDPayloadType uint8) {
+	r.codecsLock.RLock()
+	defer r.codecsLock.RUnlock()
+
+	return r.flexFECPayloadType, r.ulpFECPayloadType, r.videoREDPayloadType
+}
+
+func (r *RTCPeerConnection) fecProtectionRatio() float64 {
+	if r.config == nil {
+		return 0
+	}
+	return r.config.FECProtectionRatio
+}
+
+// newSamplePacketizer returns the packetizer of a track sending codec, Opus is sent in RED if the remote supports it
+// and video is protected with FEC if RTCConfiguration.FECProtectionRatio is set
+func (r *RTCPeerConnection) newSamplePacketizer(codec *RTCRtpCodec, track *sdp.SessionBuilderTrack, sequencer rtp.Sequencer) (
+	packetizer rtp.Packetizer, fecEncoder *fec.Encoder, fecREDPayloadType uint8) {
+	payloader := codec.NewPayloader()
+	payloadType := codec.PayloadType
+	if redPayloadType, ok := r.getREDPayloadType(); ok && codec.Type == Opus {
+		payloader = &codecs.REDPayloader{PrimaryPayloadType: payloadType, Distance: r.redDistance()}
+		payloadType = redPayloadType
+	}
+
+	packetizer = rtp.NewPacketizer(1400, payloadType, track.SSRC, payloader, sequencer, codec.ClockRate)
+	if codec.Kind() == "video" {
+		fecEncoder, fecREDPayloadType = r.newFECEncoder(track.SSRC, track.FECSSRC, sequencer)
+	}
+	return packetizer, fecEncoder, fecREDPayloadType
+}
+
+func (r *RTCPeerConnection) newFECEncoder(ssrc, fecSSRC uint32, sequencer rtp.Sequencer) (encoder *fec.Encoder, redPayloadType uint8) {
+	protectionRatio := r.fecProtectionRatio()
+	if protectionRatio <= 0 {
+		return nil, 0
+	}
+
+	// FlexFEC is preferred, ULPFEC shares the SSRC and sequence numbers of the media
+	flexFECPayloadType, ulpFECPayloadType, videoREDPayloadType := r.getFECPayloadTypes()
+	switch {
+	case flexFECPayloadType != 0 && fecSSRC != 0:
+		return fec.NewEncoder(fec.FlexFEC03, flexFECPayloadType, fecSSRC, rtp.NewRandomSequencer(), protectionRatio), 0
+	case ulpFECPayloadType != 0:
+		return fec.NewEncoder(fec.ULPFEC, ulpFECPayloadType, ssrc, sequencer, protectionRatio), videoREDPayloadType
+	default:
+		return nil, 0
+	}
+}
+
+func (r *RTCPeerConnection) getTelephoneEventPayloadType(clockRate uint32) (uint8, bool) {
+	r.codecsLock.RLock()
+	defer r.codecsLock.RUnlock()
+
+	payloadType, ok := r.telephoneEventPayloadTypes[clockRate]
+	return payloadType, ok
+}
+
+func (r *RTCPeerConnection) getTelephoneEventClockRate(payloadType uint8) (uint32, bool) {
+	r.codecsLock.RLock()
+	defer r.codecsLock.RUnlock()
+
+	for clockRate, telephoneEventPayloadType := range r.telephoneEventPayloadTypes {
+		if telephoneEventPayloadType == payloadType {
+			return clockRate, true
+		}
+	}
+	return 0, false
+}
+
+func (r *RTCPeerConnection) stampHeaderExtensions(t *RTCRtpTransceiver, codec *RTCRtpCodec, packetizer rtp.Packetizer, in RTCSample) {
+	setHeaderExtension := func(uri string, extension rtp.HeaderExtension) {
+		if id, ok := r.GetHeaderExtensionID(codec, uri); ok {
+			if err := packetizer.SetHeaderExtension(id, extension); err != nil {
+				fmt.Println(errors.Wrapf(err, "Failed to set %s", uri))
+			}
+		}
+	}
+
+	if id, ok := r.GetHeaderExtensionID(codec, rtp.AbsSendTimeURI); ok {
+		packetizer.EnableAbsSendTime(id)
+	}
+
+	if mid := t.Mid(); mid != "" {
+		setHeaderExtension(rtp.SDESMidURI, &rtp.SDESItemExtension{Value: mid})
+	}
+
+	if in.AudioLevel != nil {
+		setHeaderExtension(rtp.AudioLevelURI, in.AudioLevel)
+	} else {
+		setHeaderExtension(rtp.AudioLevelURI, nil)
+	}
+
+	if in.VideoOrientation != nil {
+		setHeaderExtension(rtp.VideoOrientationURI, in.VideoOrientation)
+	} else {
+		setHeaderExtension(rtp.VideoOrientationURI, nil)
+	}
+
+	if in.PlayoutDelay != nil {
+		setHeaderExtension(rtp.PlayoutDelayURI, in.PlayoutDelay)
+	} else {
+		setHeaderExtension(rtp.PlayoutDelayURI, nil)
+	}
+}
+
 // Private
 func (r *RTCPeerConnection) generateChannel(ssrc uint32, payloadType uint8) (buffers chan<- *rtp.Packet) {
 	if r.Ontrack == nil {
 		return nil
 	}
 
-	var codec TrackType
-	ok, codecStr := sdp.GetCodecForPayloadType(payloadType, r.remoteDescription)
-	if !ok {
-		fmt.Printf("No codec could be found in RemoteDescription for payloadType %d \n", payloadType)
+	// FlexFEC has its own SSRC, its packets are handed to the track of the SSRC they protect
+	flexFECPayloadType, ulpFECPayloadType, _ := r.getFECPayloadTypes()
+	if flexFECPayloadType != 0 && payloadType == flexFECPayloadType {
+		flexFECInput := make(chan *rtp.Packet, 15)
+		go r.forwardFlexFEC(flexFECInput)
+		return flexFECInput
+	}
+
+	// Telephone events share the SSRC of an audio track, which is started when its first audio packet arrives
+	_, isDTMF := r.getTelephoneEventClockRate(payloadType)
+
+	// RED carries another codec, its fmtp gives the payload type of the primary encoding. Video RED usually has
+	// no fmtp, the codec is then found from the first packet it carries
+	isRED := r.isREDPayloadType(payloadType)
+	var codec *RTCRtpCodec
+	if isRED {
+		codec = r.getREDPrimaryCodec(payloadType)
+	} else if !isDTMF {
+		if codec = r.getCodecForPayloadType(payloadType); codec == nil {
+			fmt.Printf("No codec was negotiated for payloadType %d \n", payloadType)
+			return nil
+		}
+	}
+
+	// A track whose codec isn't known yet is RED video or telephone events
+	kind := "video"
+	if codec != nil {
+		kind = codec.Kind()
+	} else if isDTMF {
+		kind = "audio"
+	}
+
+	receiver := r.getReceiver(kind, ssrc)
+	if receiver == nil {
 		return nil
 	}
+	receiver.addSSRC(ssrc)
 
-	switch codecStr {
-	case "VP8":
-		codec = VP8
-	case "VP9":
-		codec = VP9
-	case "opus":
-		codec = Opus
-	case "H264":
-		codec = H264
-	default:
-		fmt.Printf("Codec %s in not supported by pion-WebRTC \n", codecStr)
+	if codec != nil {
+		buffers = r.startTrack(receiver, ssrc, codec)
+	} else {
+		trackInput := make(chan *rtp.Packet, 15)
+		go r.startTrackOnFirstPacket(receiver, ssrc, trackInput)
+		buffers = trackInput
+	}
+
+	if kind == "video" && (flexFECPayloadType != 0 || ulpFECPayloadType != 0) {
+		buffers = r.startFECRecovery(ssrc, flexFECPayloadType, ulpFECPayloadType, buffers)
+	}
+
+	if isRED {
+		redInput := make(chan *rtp.Packet, 15)
+		go unwrapRED(payloadType, redInput, buffers)
+		buffers = redInput
+	}
+
+	if kind == "audio" {
+		dtmfInput := make(chan *rtp.Packet, 15)
+		go r.receiveDTMF(dtmfInput, buffers)
+		buffers = dtmfInput
+	}
+
+	receiverInput := make(chan *rtp.Packet, 15)
+	go receiver.receive(receiverInput, buffers)
+	return receiverInput
+}
+
+// getREDPrimaryCodec returns the codec carried in RED, which is given by the payload type of the primary encoding in
+// its fmtp
+// https://tools.ietf.org/html/rfc2198#section-5
+func (r *RTCPeerConnection) getREDPrimaryCodec(redPayloadType uint8) *RTCRtpCodec {
+	red := r.getRemoteCodec(redPayloadType)
+	if red == nil {
 		return nil
 	}
 
+	primaryPayloadType, err := strconv.ParseUint(strings.Split(red.Fmtp, "/")[0], 10, 8)
+	if err != nil {
+		return nil
+	}
+	return r.getCodecForPayloadType(uint8(primaryPayloadType))
+}
+
+// startFECRecovery returns the channel the packets of a video SSRC are passed through to recover lost packets from
+// FEC before they reach out. FlexFEC packets arrive on their own SSRC, they are forwarded by forwardFlexFEC
+func (r *RTCPeerConnection) startFECRecovery(ssrc uint32, flexFECPayloadType, ulpFECPayloadType uint8, out chan<- *rtp.Packet) chan<- *rtp.Packet {
+	fecInput := make(chan *rtp.Packet, 15)
+	flexFECInput := make(chan *rtp.Packet, 15)
+	if flexFECPayloadType != 0 {
+		r.fecInputsLock.Lock()
+		if r.fecInputs == nil {
+			r.fecInputs = map[uint32]chan<- *rtp.Packet{}
+		}
+		r.fecInputs[ssrc] = flexFECInput
+		r.fecInputsLock.Unlock()
+	}
+	go r.recoverFEC(ssrc, ulpFECPayloadType, fecInput, flexFECInput, out)
+	return fecInput
+}
+
+func (r *RTCPeerConnection) startTrack(receiver *RTCRtpReceiver, ssrc uint32, codec *RTCRtpCodec) (buffers chan<- *rtp.Packet) {
+	track := &RTCTrack{ssrc: ssrc, codec: codec, receiver: receiver}
+	track.id, track.streamID = r.getRemoteTrackIDs(receiver, ssrc)
+
 	bufferTransport := make(chan *rtp.Packet, 15)
-	go r.Ontrack(codec, bufferTransport)
-	return bufferTransport
+	go r.Ontrack(track, bufferTransport)
+
+	buffers = bufferTransport
+	if r.config != nil && r.config.JitterBuffer != nil {
+		// Reordering happens in the jitter buffer, give it room to absorb bursts
+		jitterBufferInput := make(chan *rtp.Packet, 128)
+		go jitterbuffer.New(codec.ClockRate, *r.config.JitterBuffer).Run(jitterBufferInput, bufferTransport)
+		buffers = jitterBufferInput
+	}
+	return buffers
+}
+
+func (r *RTCPeerConnection) startTrackOnFirstPacket(receiver *RTCRtpReceiver, ssrc uint32, in <-chan *rtp.Packet) {
+	var out chan<- *rtp.Packet
+	for p := range in {
+		if out == nil {
+			codec := r.getCodecForPayloadType(p.PayloadType)
+			if codec == nil {
+				continue
+			}
+			out = r.startTrack(receiver, ssrc, codec)
+		}
+		out <- p
+	}
+
+	if out != nil {
+		close(out)
+	}
+}
+
+func (r *RTCPeerConnection) getRemoteTrackIDs(receiver *RTCRtpReceiver, ssrc uint32) (id, streamID string) {
+	r.descriptionsLock.RLock()
+	defer r.descriptionsLock.RUnlock()
+
+	if r.remoteDescription == nil {
+		return "", ""
+	}
+
+	// Sources announce the ids in their msid, or in mslabel and label like older browsers do
+	for _, m := range r.remoteDescription.MediaDescriptions {
+		for _, source := range m.SSRCs() {
+			if source.SSRC != ssrc {
+				continue
+			}
+			if fields := strings.Fields(source.MSID); len(fields) == 2 {
+				return fields[1], fields[0]
+			}
+			if source.Label != "" || source.MSLabel != "" {
+				return source.Label, source.MSLabel
+			}
+		}
+	}
+
+	// Without ssrc attributes the ids are in the msid of the MediaDescription the track is received in
+	index := receiver.transceiver.getMLineIndex()
+	if index < 0 || index >= len(r.remoteDescription.MediaDescriptions) {
+		return "", ""
+	}
+	if streamID, id, ok := r.remoteDescription.MediaDescriptions[index].MSID(); ok {
+		return id, streamID
+	}
+	return "", ""
+}
+
+func (r *RTCPeerConnection) forwardFlexFEC(in <-chan *rtp.Packet) {
+	for p := range in {
+		ssrc, err := fec.FlexFECProtectedSSRC(p.Payload)
+		if err != nil {
+			fmt.Println(errors.Wrap(err, "Failed to parse FlexFEC packet"))
+			continue
+		}
+
+		r.fecInputsLock.Lock()
+		if fecInput, ok := r.fecInputs[ssrc]; ok {
+			select {
+			case fecInput <- p:
+			default:
+			}
+		}
+		r.fecInputsLock.Unlock()
+	}
+}
+
+func (r *RTCPeerConnection) recoverFEC(ssrc uint32, ulpFECPayloadType uint8, in, flexFECIn <-chan *rtp.Packet, out chan<- *rtp.Packet) {
+	defer func() {
+		r.fecInputsLock.Lock()
+		delete(r.fecInputs, ssrc)
+		r.fecInputsLock.Unlock()
+		close(out)
+	}()
+
+	decoder := fec.NewDecoder()
+	for {
+		var packets []*rtp.Packet
+		var err error
+		select {
+		case p, ok := <-in:
+			if !ok {
+				return
+			}
+			if ulpFECPayloadType != 0 && p.PayloadType == ulpFECPayloadType {
+				packets, err = decoder.PushFEC(fec.ULPFEC, p)
+			} else {
+				packets, err = decoder.PushMedia(p)
+			}
+		case p := <-flexFECIn:
+			packets, err = decoder.PushFEC(fec.FlexFEC03, p)
+		}
+
+		if err != nil {
+			fmt.Println(errors.Wrap(err, "Failed to recover packets with FEC"))
+			continue
+		}
+		for _, p := range packets {
+			out <- p
+		}
+	}
+}
+
+func unwrapRED(redPayloadType uint8, in <-chan *rtp.Packet, out chan<- *rtp.Packet) {
+	decoder := &codecs.REDDecoder{}
+	for p := range in {
+		if p.PayloadType != redPayloadType {
+			out <- p
+			continue
+		}
+
+		packets, err := decoder.Decode(p)
+		if err != nil {
+			fmt.Println(errors.Wrap(err, "Failed to unwrap RED packet"))
+			continue
+		}
+		for _, p := range packets {
+			out <- p
+		}
+	}
+	close(out)
+}
+
+func (r *RTCPeerConnection) receiveDTMF(in <-chan *rtp.Packet, out chan<- *rtp.Packet) {
+	// Every packet of an event has the timestamp of its start, the end is sent several times. eventOffset is where
+	// the segment of a long event starts
+	var event codecs.TelephoneEvent
+	var eventTimestamp, eventOffset, eventClockRate uint32
+	started, reported := false, true
+	report := func() {
+		reported = true
+		if tone, ok := event.DTMFTone(); ok && r.OnDTMF != nil {
+			r.OnDTMF(tone, time.Duration(eventOffset+uint32(event.Duration))*time.Second/time.Duration(eventClockRate))
+		}
+	}
+
+	for p := range in {
+		clockRate, ok := r.getTelephoneEventClockRate(p.PayloadType)
+		if !ok {
+			out <- p
+			continue
+		}
+
+		e := codecs.TelephoneEvent{}
+		if err := e.Unmarshal(p.Payload); err != nil {
+			fmt.Println(errors.Wrap(err, "Failed to parse telephone event"))
+			continue
+		}
+
+		switch {
+		case !reported && !p.Marker && e.Event == event.Event && p.Timestamp != eventTimestamp:
+			// An event longer than the duration field can hold continues in a new segment, which starts where the
+			// previous one ended
+			// https://tools.ietf.org/html/rfc4733#section-2.5.2.3
+			eventOffset += p.Timestamp - eventTimestamp
+			eventTimestamp = p.Timestamp
+		case !started || p.Timestamp != eventTimestamp:
+			// The end of the previous event was lost
+			if !reported {
+				report()
+			}
+			started, eventTimestamp, eventOffset, reported = true, p.Timestamp, 0, false
+		case reported:
+			continue
+		}
+
+		event, eventClockRate = e, clockRate
+		if e.EndOfEvent {
+			report()
+		}
+	}
+
+	if !reported {
+		report()
+	}
+	close(out)
+}
+
+func wrapRED(redPayloadType uint8, p *rtp.Packet) *rtp.Packet {
+	// A single primary block only has the 1 byte header, F=0 and the block payload type
+	// https://tools.ietf.org/html/rfc2198#section-3
+	red := &rtp.Packet{Header: p.Header, Payload: append([]byte{p.PayloadType & 0x7F}, p.Payload...)}
+	red.PayloadType = redPayloadType
+	return red
+}
+
+func (r *RTCPeerConnection) sendGoodbye(track *sdp.SessionBuilderTrack) {
+	goodbye := &rtcp.Goodbye{Sources: []uint32{track.SSRC}}
+	if track.FECSSRC != 0 {
+		goodbye.Sources = append(goodbye.Sources, track.FECSSRC)
+	}
+
+	// The BYE is sent on its own, as reduced-size RTCP is negotiated with rtcp-rsize
+	// https://tools.ietf.org/html/rfc5506#section-3
+	raw, err := goodbye.Marshal()
+	if err != nil {
+		fmt.Println(errors.Wrap(err, "Failed to marshal RTCP BYE"))
+		return
+	}
+
+	r.portsLock.RLock()
+	defer r.portsLock.RUnlock()
+	for _, port := range r.ports {
+		port.SendRTCP(raw)
+	}
 }
 
 // Private
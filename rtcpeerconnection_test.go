@@ -0,0 +1,568 @@
+package webrtc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/rtp/codecs"
+	"github.com/pions/webrtc/pkg/sdp"
+)
+
+func TestNegotiateMediaMirrorsOffer(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddTrack(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8), "video", "stream"); err != nil {
+		t.Fatal(err)
+	}
+
+	protos := []string{"UDP", "TLS", "RTP", "SAVPF"}
+	r.remoteDescription = &sdp.SessionDescription{
+		Attributes: []sdp.Attribute{{Key: "group", Value: "BUNDLE 0 1 2"}},
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "video", Port: 9, Protos: protos, Formats: []string{"120"}}, Attributes: []sdp.Attribute{
+				{Key: "mid", Value: "0"},
+				{Key: "sendrecv"},
+				{Key: "rtpmap", Value: "120 VP8/90000"},
+			}},
+			{MediaName: sdp.MediaName{Media: "audio", Port: 9, Protos: protos, Formats: []string{"109"}}, Attributes: []sdp.Attribute{
+				{Key: "mid", Value: "1"},
+				{Key: "sendonly"},
+				{Key: "rtpmap", Value: "109 opus/48000/2"},
+			}},
+			{MediaName: sdp.MediaName{Media: "application", Port: 9, Protos: []string{"DTLS", "SCTP"}, Formats: []string{"5000"}}, Attributes: []sdp.Attribute{
+				{Key: "mid", Value: "2"},
+			}},
+		},
+	}
+
+	media, bundle := r.negotiateMedia()
+	if len(media) != 3 {
+		t.Fatalf("expected a MediaDescription for every offered one, got %d", len(media))
+	}
+
+	for i, expected := range []struct {
+		kind, mid string
+		codecs    int
+		direction sdp.Direction
+	}{
+		{"video", "0", 1, sdp.DirectionSendRecv},
+		{"audio", "1", 1, sdp.DirectionRecvOnly},
+		{"application", "2", 0, sdp.DirectionInactive},
+	} {
+		m := media[i]
+		if m.Kind != expected.kind || m.MID != expected.mid || len(m.Codecs) != expected.codecs || m.Direction != expected.direction {
+			t.Errorf("expected %s with mid %s, %d codecs and %s, got %s with mid %s, %d codecs and %s",
+				expected.kind, expected.mid, expected.codecs, expected.direction, m.Kind, m.MID, len(m.Codecs), m.Direction)
+		}
+	}
+
+	if len(bundle) != 2 || bundle[0] != "0" || bundle[1] != "1" {
+		t.Errorf("expected only the accepted mids to be bundled, got %v", bundle)
+	}
+}
+
+func TestAnswerDirection(t *testing.T) {
+	for _, test := range []struct {
+		offered       sdp.Direction
+		send, receive bool
+		expected      sdp.Direction
+	}{
+		{sdp.DirectionSendRecv, true, true, sdp.DirectionSendRecv},
+		{sdp.DirectionSendRecv, false, true, sdp.DirectionRecvOnly},
+		{sdp.DirectionSendRecv, true, false, sdp.DirectionSendOnly},
+		{sdp.DirectionSendOnly, true, true, sdp.DirectionRecvOnly},
+		{sdp.DirectionSendOnly, true, false, sdp.DirectionInactive},
+		{sdp.DirectionRecvOnly, true, true, sdp.DirectionSendOnly},
+		{sdp.DirectionRecvOnly, false, true, sdp.DirectionInactive},
+		{sdp.DirectionInactive, true, true, sdp.DirectionInactive},
+	} {
+		if direction := answerDirection(test.offered, test.send, test.receive); direction != test.expected {
+			t.Errorf("expected %s offered with send %t and receive %t to be answered %s, got %s",
+				test.offered, test.send, test.receive, test.expected, direction)
+		}
+	}
+}
+
+func TestAddTransceiverDirection(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddTransceiver("text", RTCRtpTransceiverDirectionSendrecv); err == nil {
+		t.Errorf("expected AddTransceiver to fail for a kind without codecs")
+	}
+
+	audio, err := r.AddTransceiver("audio", RTCRtpTransceiverDirectionRecvonly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddTransceiver("video", RTCRtpTransceiverDirectionInactive); err != nil {
+		t.Fatal(err)
+	}
+
+	protos := []string{"UDP", "TLS", "RTP", "SAVPF"}
+	r.remoteDescription = &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "audio", Port: 9, Protos: protos, Formats: []string{"111"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "111 opus/48000/2"},
+			}},
+			{MediaName: sdp.MediaName{Media: "video", Port: 9, Protos: protos, Formats: []string{"96"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "96 VP8/90000"},
+			}},
+		},
+	}
+
+	media, _ := r.negotiateMedia()
+	if media[0].Direction != sdp.DirectionRecvOnly || media[1].Direction != sdp.DirectionInactive {
+		t.Errorf("expected a receive only recorder to answer recvonly and inactive, got %s and %s", media[0].Direction, media[1].Direction)
+	}
+
+	// A track is sent by the transceiver of its kind, which then sends too
+	if _, err := r.AddTrack(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus), "audio", "stream"); err != nil {
+		t.Fatal(err)
+	}
+	if audio.Direction() != RTCRtpTransceiverDirectionSendrecv || len(r.transceivers) != 2 {
+		t.Errorf("expected AddTrack to use the recvonly audio transceiver")
+	}
+	if media, _ = r.negotiateMedia(); media[0].Direction != sdp.DirectionSendRecv {
+		t.Errorf("expected audio with a track to be answered sendrecv, got %s", media[0].Direction)
+	}
+
+	// Only media the answer receives is delivered to Ontrack
+	r.Ontrack = func(track *RTCTrack, packets <-chan *rtp.Packet) {}
+	r.LocalDescription = sdp.BaseSessionDescription(&sdp.SessionBuilder{Media: media})
+	if r.generateChannel(1, 111) == nil {
+		t.Errorf("expected audio to be received")
+	}
+	if r.generateChannel(2, 96) != nil {
+		t.Errorf("expected video of an inactive MediaDescription to be dropped")
+	}
+}
+
+func TestRemoveTrack(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	negotiationNeeded := make(chan struct{}, 2)
+	r.OnNegotiationNeeded = func() {
+		negotiationNeeded <- struct{}{}
+	}
+
+	samples, err := r.AddTrack(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus), "audio", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := r.GetSender(samples)
+	if err != nil {
+		t.Fatal(err)
+	} else if sender.DTMF() == nil {
+		t.Errorf("expected an audio track to have an RTCDTMFSender")
+	}
+
+	// A replaced source is read instead, closing it ends the track
+	replacement := make(chan RTCSample)
+	if err := sender.ReplaceTrack(replacement); err != nil {
+		t.Fatal(err)
+	}
+	close(replacement)
+
+	select {
+	case <-negotiationNeeded:
+	case <-time.After(time.Second):
+		t.Fatalf("expected closing the source to remove the track")
+	}
+	if _, err := r.GetSender(samples); err == nil {
+		t.Errorf("expected the channel of a removed track to have no sender")
+	}
+	if direction := sender.Transceiver().Direction(); direction != RTCRtpTransceiverDirectionRecvonly {
+		t.Errorf("expected the transceiver of a removed track to only receive, got %s", direction)
+	}
+	if len(sender.GetParameters().Encodings) != 0 || sender.Transceiver().sends() {
+		t.Errorf("expected a removed track to no longer be sent")
+	}
+	if err := r.RemoveTrack(sender); err == nil {
+		t.Errorf("expected removing a removed track to fail")
+	}
+	if err := sender.ReplaceTrack(replacement); err == nil {
+		t.Errorf("expected replacing a removed track to fail")
+	}
+
+	// The transceiver is reused by the next track of its kind
+	if _, err := r.AddTrack(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus), "audio", "stream"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.GetTransceivers()) != 1 || sender.Transceiver().Direction() != RTCRtpTransceiverDirectionSendrecv {
+		t.Errorf("expected the transceiver of the removed track to send the new track")
+	}
+}
+
+func TestRemoteTrackIDs(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddTrack(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus), "", "stream"); err == nil {
+		t.Errorf("expected AddTrack to fail without a track id")
+	}
+
+	protos := []string{"UDP", "TLS", "RTP", "SAVPF"}
+	r.remoteDescription = &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "audio", Port: 9, Protos: protos, Formats: []string{"111"}}, Attributes: []sdp.Attribute{
+				{Key: "msid", Value: "microphone-stream microphone"},
+				{Key: "rtpmap", Value: "111 opus/48000/2"},
+			}},
+			{MediaName: sdp.MediaName{Media: "video", Port: 9, Protos: protos, Formats: []string{"126"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "126 H264/90000"},
+				{Key: "fmtp", Value: "126 profile-level-id=42e01f;packetization-mode=1"},
+				{Key: "ssrc", Value: "3000 cname:remote"},
+				{Key: "ssrc", Value: "3000 msid:camera-stream camera"},
+			}},
+		},
+	}
+	media, _ := r.negotiateMedia()
+	r.LocalDescription = sdp.BaseSessionDescription(&sdp.SessionBuilder{Media: media})
+
+	tracks := make(chan *RTCTrack, 2)
+	r.Ontrack = func(track *RTCTrack, packets <-chan *rtp.Packet) {
+		tracks <- track
+	}
+	r.generateChannel(3000, 126)
+	r.generateChannel(4000, 111)
+
+	// Ontrack is called from its own goroutine, the tracks can arrive in any order
+	byKind := map[string]*RTCTrack{}
+	for i := 0; i < 2; i++ {
+		track := <-tracks
+		byKind[track.Kind()] = track
+	}
+
+	for _, expected := range []struct {
+		id, streamID, kind string
+		ssrc               uint32
+	}{
+		{"camera", "camera-stream", "video", 3000},
+		{"microphone", "microphone-stream", "audio", 4000},
+	} {
+		track, ok := byKind[expected.kind]
+		if !ok {
+			t.Errorf("expected a %s track", expected.kind)
+			continue
+		}
+		if track.ID() != expected.id || track.StreamID() != expected.streamID || track.Kind() != expected.kind || track.SSRC() != expected.ssrc {
+			t.Errorf("expected %s track %s of %s with SSRC %d, got %s track %s of %s with SSRC %d",
+				expected.kind, expected.id, expected.streamID, expected.ssrc, track.Kind(), track.ID(), track.StreamID(), track.SSRC())
+		}
+		if track.Kind() == "video" && track.Codec().SDPFmtpLine != "profile-level-id=42e01f;packetization-mode=1" {
+			t.Errorf("expected the track to have the fmtp of the remote, got %q", track.Codec().SDPFmtpLine)
+		}
+	}
+}
+
+func TestUnifiedPlan(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	camera, err := r.AddRTPTrack(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8), "camera", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	screen, err := r.AddRTPTrack(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8), "screen", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protos := []string{"UDP", "TLS", "RTP", "SAVPF"}
+	video := func(mid, ssrc string) *sdp.MediaDescription {
+		return &sdp.MediaDescription{MediaName: sdp.MediaName{Media: "video", Port: 9, Protos: protos, Formats: []string{"96"}}, Attributes: []sdp.Attribute{
+			{Key: "mid", Value: mid},
+			{Key: "rtpmap", Value: "96 VP8/90000"},
+			{Key: "ssrc", Value: ssrc + " cname:remote"},
+		}}
+	}
+	r.remoteDescription = &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{video("0", "5000"), video("1", "6000"), video("2", "7000")},
+	}
+	media, _ := r.negotiateMedia()
+	r.LocalDescription = sdp.BaseSessionDescription(&sdp.SessionBuilder{Media: media})
+
+	// Every track is sent in a MediaDescription of its own, the third is received by a transceiver that was added
+	for i, expected := range []string{"stream camera", "stream screen", ""} {
+		msid, _ := r.LocalDescription.MediaDescriptions[i].Attribute("msid")
+		if msid != expected {
+			t.Errorf("expected MediaDescription %d to have msid %q, got %q", i, expected, msid)
+		}
+	}
+	if camera.Sender().Transceiver().Mid() != "0" || screen.Sender().Transceiver().Mid() != "1" || len(r.GetTransceivers()) != 3 {
+		t.Errorf("expected the tracks to be negotiated with their own transceivers")
+	}
+
+	// Remote SSRCs are received by the transceiver of the MediaDescription announcing them
+	r.Ontrack = func(track *RTCTrack, packets <-chan *rtp.Packet) {}
+	transceivers := r.GetTransceivers()
+	for _, expected := range []struct {
+		ssrc        uint32
+		transceiver *RTCRtpTransceiver
+	}{
+		{7000, transceivers[2]},
+		{5000, transceivers[0]},
+		{6000, transceivers[1]},
+	} {
+		if r.generateChannel(expected.ssrc, 96) == nil {
+			t.Fatalf("expected SSRC %d to be received", expected.ssrc)
+		}
+		if encodings := expected.transceiver.Receiver().GetParameters().Encodings; len(encodings) != 1 || encodings[0].SSRC != expected.ssrc {
+			t.Errorf("expected SSRC %d to be received by the transceiver of its MediaDescription, got %+v", expected.ssrc, encodings)
+		}
+	}
+
+	// Tracks added after the answer need a new offer with a MediaDescription for them
+	negotiationNeeded := false
+	r.OnNegotiationNeeded = func() {
+		negotiationNeeded = true
+	}
+	if _, err := r.AddRTPTrack(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8), "window", "stream"); err != nil {
+		t.Fatal(err)
+	}
+	if !negotiationNeeded {
+		t.Errorf("expected a track added after the answer to need negotiation")
+	}
+}
+
+func TestDTMFSender(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, err := r.AddTrack(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus), "audio", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dtmf, err := r.GetDTMFSender(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.remoteDescription = &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "audio", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"111", "0", "110", "126"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "111 opus/48000/2"},
+				{Key: "rtpmap", Value: "0 PCMU/8000"},
+				{Key: "rtpmap", Value: "110 telephone-event/48000"},
+				{Key: "rtpmap", Value: "126 telephone-event/8000"},
+			}},
+		},
+	}
+	media, _ := r.negotiateMedia()
+	r.LocalDescription = sdp.BaseSessionDescription(&sdp.SessionBuilder{Media: media})
+
+	// Telephone events are answered with the clock rate of every audio codec
+	for _, clockRate := range []uint32{48000, 8000} {
+		if _, ok := r.getTelephoneEventPayloadType(clockRate); !ok {
+			t.Errorf("expected telephone-event/%d to be answered", clockRate)
+		}
+	}
+	if !dtmf.CanInsertDTMF() {
+		t.Fatalf("expected DTMF to be sent with telephone-event/48000 on an Opus track")
+	}
+
+	// Removing the track stops a tone that is playing
+	if err := dtmf.InsertDTMF("1", 6000*time.Millisecond, 0); err != nil {
+		t.Fatal(err)
+	}
+	sender, err := r.GetSender(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RemoveTrack(sender); err != nil {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		dtmf.lock.Lock()
+		playing := dtmf.playing
+		dtmf.lock.Unlock()
+		if !playing {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected the tone to stop when the track was removed")
+		}
+	}
+	if err := dtmf.InsertDTMF("2", 100*time.Millisecond, 0); err == nil {
+		t.Errorf("expected InsertDTMF to fail once the track was removed")
+	}
+}
+
+func TestDTMFLongTone(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.remoteDescription = &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "audio", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{"0", "126"}}, Attributes: []sdp.Attribute{
+				{Key: "rtpmap", Value: "0 PCMU/8000"},
+				{Key: "rtpmap", Value: "126 telephone-event/8000"},
+			}},
+		},
+	}
+	r.negotiateMedia()
+
+	tones := make(chan time.Duration, 2)
+	r.OnDTMF = func(tone rune, duration time.Duration) {
+		if tone != '5' {
+			t.Errorf("expected tone 5, got %c", tone)
+		}
+		tones <- duration
+	}
+
+	// 8.5s is more than the 0xFFFF samples a segment can hold at 8000Hz, the tone is paced without waiting
+	d := newRTCDTMFSender(r, 8000)
+	d.after = func(time.Duration) <-chan time.Time {
+		now := make(chan time.Time, 1)
+		now <- time.Now()
+		return now
+	}
+	event, _ := codecs.DTMFEvent('5')
+	go func() {
+		d.playTone(event, 8500*time.Millisecond)
+		close(d.packets)
+	}()
+
+	s := &sampleSender{track: &sdp.SessionBuilderTrack{SSRC: 5000}, sequencer: rtp.NewRandomSequencer()}
+	in, out := make(chan *rtp.Packet), make(chan *rtp.Packet)
+	go r.receiveDTMF(in, out)
+	for packet := range d.packets {
+		p, err := s.packetizeDTMF(126, packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in <- p
+	}
+	close(in)
+	for range out {
+	}
+
+	close(tones)
+	if len(tones) != 1 {
+		t.Fatalf("expected the segments to be reported as one tone, got %d tones", len(tones))
+	} else if duration := <-tones; duration != 8500*time.Millisecond {
+		t.Errorf("expected the tone to last 8.5s, got %v", duration)
+	}
+}
+
+func TestRenegotiationWhileSending(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, err := r.AddTrack(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus), "audio", "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The track keeps sending while the remote renegotiates, run with -race to check the descriptions are guarded
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case samples <- RTCSample{Data: []byte{0x00}, Samples: 960}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for _, direction := range []string{"sendrecv", "sendonly", "sendrecv"} {
+		if err := r.SetRemoteDescription(`v=0
+o=- 1 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=audio 9 UDP/TLS/RTP/SAVPF 111
+a=mid:0
+a=` + direction + `
+a=rtpmap:111 opus/48000/2
+`); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.CreateAnswer(); err != nil {
+			t.Fatal(err)
+		}
+
+		if sending := r.GetTransceivers()[0].isSending(); sending != (direction != "sendonly") {
+			t.Errorf("expected the answer to a %s offer to send: %t, got %t", direction, !sending, sending)
+		}
+	}
+}
+
+func TestRenegotiatedPayloadType(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &sampleSender{
+		peerConnection: r,
+		codec:          NewRTCRtpOpusCodec(DefaultPayloadTypeOpus),
+		track:          &sdp.SessionBuilderTrack{SSRC: 5000},
+		sequencer:      rtp.NewRandomSequencer(),
+	}
+
+	negotiate := func(payloadType string) {
+		r.remoteDescription = &sdp.SessionDescription{
+			MediaDescriptions: []*sdp.MediaDescription{
+				{MediaName: sdp.MediaName{Media: "audio", Port: 9, Protos: []string{"UDP", "TLS", "RTP", "SAVPF"}, Formats: []string{payloadType}}, Attributes: []sdp.Attribute{
+					{Key: "rtpmap", Value: payloadType + " opus/48000/2"},
+				}},
+			},
+		}
+		r.negotiateMedia()
+	}
+	packetize := func() *rtp.Packet {
+		if !s.updatePacketizer() {
+			t.Fatalf("expected Opus to be negotiated")
+		}
+		return s.packetizer.Packetize([]byte{0x00}, 960)[0]
+	}
+
+	negotiate("111")
+	first := packetize()
+	packetizer := s.packetizer
+
+	// A renegotiation that doesn't change the codec keeps the packetizer
+	negotiate("111")
+	if packetize(); s.packetizer != packetizer {
+		t.Errorf("expected the packetizer to be kept when the codec didn't change")
+	}
+
+	// A new payload type rebuilds it, the sequence numbers of the SSRC continue
+	negotiate("109")
+	p := packetize()
+	if p.PayloadType != 109 || p.SequenceNumber != first.SequenceNumber+2 {
+		t.Errorf("expected payload type 109 and sequence number %d, got %d and %d", first.SequenceNumber+2, p.PayloadType, p.SequenceNumber)
+	}
+}
+
+func TestAudioTalkspurtMarker(t *testing.T) {
+	s := &sampleSender{talkspurt: true}
+	packetizer := rtp.NewPacketizer(1200, DefaultPayloadTypePCMU, 5000, &codecs.G711Payloader{}, rtp.NewRandomSequencer(), 8000)
+
+	// 60ms of PCMU is sent in three packets, only the first one starts the talkspurt
+	var markers []bool
+	for i := 0; i < 2; i++ {
+		packets := packetizer.Packetize(make([]byte, 480), 480)
+		s.markTalkspurt(packets)
+		for _, p := range packets {
+			markers = append(markers, p.Marker)
+		}
+	}
+	if !reflect.DeepEqual(markers, []bool{true, false, false, false, false, false}) {
+		t.Errorf("expected the marker on the first packet of the talkspurt only, got %v", markers)
+	}
+}
@@ -0,0 +1,25 @@
+package webrtc
+
+// RTCRtpParameters are the negotiated parameters of an RTCRtpSender or RTCRtpReceiver
+// https://www.w3.org/TR/webrtc/#rtcrtpparameters
+type RTCRtpParameters struct {
+	HeaderExtensions []RTCRtpHeaderExtensionParameters
+	Codecs           []*RTCRtpCodec
+	Encodings        []RTCRtpEncodingParameters
+}
+
+// RTCRtpHeaderExtensionParameters is an RTP header extension and the ID it was negotiated with
+// https://www.w3.org/TR/webrtc/#rtcrtpheaderextensionparameters
+type RTCRtpHeaderExtensionParameters struct {
+	URI string
+	ID  uint8
+}
+
+// RTCRtpEncodingParameters describes an RTP stream of a track
+// https://www.w3.org/TR/webrtc/#rtcrtpencodingparameters
+type RTCRtpEncodingParameters struct {
+	SSRC uint32
+
+	// FECSSRC is the SSRC FlexFEC packets protecting the stream are sent with, 0 without FlexFEC
+	FECSSRC uint32
+}
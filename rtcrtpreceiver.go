@@ -0,0 +1,72 @@
+package webrtc
+
+import (
+	"sync"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+// RTCRtpReceiver receives the remote media of the MediaDescription its RTCRtpTransceiver was negotiated in, which
+// is delivered to Ontrack
+// https://www.w3.org/TR/webrtc/#rtcrtpreceiver-interface
+type RTCRtpReceiver struct {
+	peerConnection *RTCPeerConnection
+	transceiver    *RTCRtpTransceiver
+
+	// stopped is closed when the transceiver is stopped
+	stopped chan struct{}
+
+	lock  sync.RWMutex
+	ssrcs []uint32
+}
+
+// Transceiver returns the RTCRtpTransceiver of the receiver
+func (r *RTCRtpReceiver) Transceiver() *RTCRtpTransceiver {
+	return r.transceiver
+}
+
+// GetParameters returns the negotiated codecs the remote may send, the header extensions it sends and the SSRCs
+// that were received so far
+func (r *RTCRtpReceiver) GetParameters() RTCRtpParameters {
+	parameters := RTCRtpParameters{
+		HeaderExtensions: r.peerConnection.getHeaderExtensionParameters(r.transceiver.kind),
+		Codecs:           r.peerConnection.getNegotiatedCodecs(r.transceiver.kind),
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, ssrc := range r.ssrcs {
+		parameters.Encodings = append(parameters.Encodings, RTCRtpEncodingParameters{SSRC: ssrc})
+	}
+	return parameters
+}
+
+// addSSRC records a remote SSRC the receiver receives
+func (r *RTCRtpReceiver) addSSRC(ssrc uint32) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ssrcs = append(r.ssrcs, ssrc)
+}
+
+func (r *RTCRtpReceiver) hasSSRCs() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.ssrcs) != 0
+}
+
+// receive forwards the packets of a remote SSRC until the transceiver is stopped, closing out stops the rest of
+// the receive pipeline and the channel given to Ontrack
+func (r *RTCRtpReceiver) receive(in <-chan *rtp.Packet, out chan<- *rtp.Packet) {
+	defer close(out)
+	for {
+		select {
+		case p, ok := <-in:
+			if !ok {
+				return
+			}
+			out <- p
+		case <-r.stopped:
+			return
+		}
+	}
+}
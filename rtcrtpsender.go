@@ -0,0 +1,332 @@
+package webrtc
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/rtp/fec"
+	"github.com/pions/webrtc/pkg/sdp"
+	"github.com/pkg/errors"
+)
+
+// RTCRtpSender sends the track added with AddTrack to the remote
+// https://www.w3.org/TR/webrtc/#rtcrtpsender-interface
+type RTCRtpSender struct {
+	peerConnection *RTCPeerConnection
+	transceiver    *RTCRtpTransceiver
+
+	lock    sync.RWMutex
+	codec   *RTCRtpCodec
+	track   *sdp.SessionBuilderTrack
+	samples chan<- RTCSample
+	dtmf    *RTCDTMFSender
+
+	// replace hands a new source of samples to the goroutine sending the track, removed is closed by RemoveTrack
+	replace chan (<-chan RTCSample)
+	removed chan struct{}
+}
+
+// Transceiver returns the RTCRtpTransceiver of the sender
+func (s *RTCRtpSender) Transceiver() *RTCRtpTransceiver {
+	return s.transceiver
+}
+
+// DTMF returns the RTCDTMFSender of an audio track, it is nil for other tracks or if there is no track
+func (s *RTCRtpSender) DTMF() *RTCDTMFSender {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.dtmf
+}
+
+// ReplaceTrack replaces the source of the track with samples without renegotiation, like when switching cameras.
+// The track keeps its SSRC, its sequence numbers and timestamps continue from the previous source. samples must be
+// encoded with the codec the track was added with, closing it ends the track
+// https://www.w3.org/TR/webrtc/#dom-rtcrtpsender-replacetrack
+func (s *RTCRtpSender) ReplaceTrack(samples <-chan RTCSample) error {
+	if samples == nil {
+		return errors.Errorf("samples must not be nil")
+	}
+
+	s.lock.RLock()
+	track, replace, removed := s.track, s.replace, s.removed
+	s.lock.RUnlock()
+	if track == nil {
+		return errors.Errorf("sender has no track")
+	} else if replace == nil {
+		return errors.Errorf("the track is written with WriteRTP")
+	}
+
+	select {
+	case replace <- samples:
+		return nil
+	case <-removed:
+		return errors.Errorf("the track was removed")
+	}
+}
+
+// GetParameters returns the negotiated codec the track is sent with, the header extensions it is sent with and its
+// SSRC. There is no codec until the answer was created, and no encoding until a track was added
+func (s *RTCRtpSender) GetParameters() RTCRtpParameters {
+	parameters := RTCRtpParameters{HeaderExtensions: s.peerConnection.getHeaderExtensionParameters(s.transceiver.kind)}
+
+	s.lock.RLock()
+	codec, track := s.codec, s.track
+	s.lock.RUnlock()
+	if track == nil {
+		return parameters
+	}
+
+	if negotiated := s.peerConnection.getNegotiatedCodec(codec); negotiated != nil {
+		parameters.Codecs = []*RTCRtpCodec{negotiated}
+	}
+	parameters.Encodings = []RTCRtpEncodingParameters{{SSRC: track.SSRC, FECSSRC: track.FECSSRC}}
+	return parameters
+}
+
+func (s *RTCRtpSender) setTrack(codec *RTCRtpCodec, track *sdp.SessionBuilderTrack, samples chan<- RTCSample, dtmf *RTCDTMFSender) (
+	replaced <-chan (<-chan RTCSample), removed <-chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.codec, s.track, s.samples, s.dtmf = codec, track, samples, dtmf
+	s.replace, s.removed = nil, make(chan struct{})
+	// Tracks written with WriteRTP have no source of samples to replace
+	if samples != nil {
+		s.replace = make(chan (<-chan RTCSample))
+	}
+	return s.replace, s.removed
+}
+
+// removeTrack clears the track, it returns the track and the channel AddTrack returned for it
+func (s *RTCRtpSender) removeTrack() (track *sdp.SessionBuilderTrack, samples chan<- RTCSample, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.track == nil {
+		return nil, nil, false
+	}
+
+	track, samples = s.track, s.samples
+	s.codec, s.track, s.samples, s.dtmf = nil, nil, nil, nil
+	close(s.removed)
+	return track, samples, true
+}
+
+func (s *RTCRtpSender) getTrack() *sdp.SessionBuilderTrack {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.track
+}
+
+// sampleSender packetizes and sends the RTCSamples and DTMF tones of a track added with AddTrack, it is only used
+// by the goroutine of the track
+type sampleSender struct {
+	peerConnection *RTCPeerConnection
+	sender         *RTCRtpSender
+	codec          *RTCRtpCodec
+	track          *sdp.SessionBuilderTrack
+	dtmf           *RTCDTMFSender
+	sequencer      rtp.Sequencer
+
+	// The packetizer is created for the first sample sent after the codec was negotiated, and rebuilt when a
+	// renegotiation changes how the track is packetized
+	negotiated        packetization
+	packetizer        rtp.Packetizer
+	fecEncoder        *fec.Encoder
+	fecREDPayloadType uint8
+	notNegotiated     bool
+
+	// Telephone events are timestamped with the audio that was sent last when they start
+	lastTimestamp uint32
+	dtmfTimestamp uint32
+
+	// talkspurt is set when the next audio packet starts a talkspurt, the first one after audio wasn't sent
+	talkspurt bool
+}
+
+// packetization is what a track is packetized with, as negotiated with the remote
+type packetization struct {
+	codec                                                 *RTCRtpCodec
+	redPayloadType, flexFECPayloadType, ulpFECPayloadType uint8
+}
+
+func newSampleSender(sender *RTCRtpSender, codec *RTCRtpCodec, track *sdp.SessionBuilderTrack, dtmf *RTCDTMFSender) *sampleSender {
+	return &sampleSender{
+		peerConnection: sender.peerConnection,
+		sender:         sender,
+		codec:          codec,
+		track:          track,
+		dtmf:           dtmf,
+		sequencer:      rtp.NewRandomSequencer(),
+		lastTimestamp:  rand.Uint32(),
+		talkspurt:      true,
+	}
+}
+
+// run sends the samples of source until the track is removed, replaced hands it a new source
+func (s *sampleSender) run(source <-chan RTCSample, replaced <-chan (<-chan RTCSample), removed <-chan struct{}) {
+	var dtmfPackets <-chan *dtmfPacket
+	if s.dtmf != nil {
+		dtmfPackets = s.dtmf.packets
+		defer s.dtmf.stop()
+	}
+
+	for {
+		select {
+		case sample, ok := <-source:
+			if !ok {
+				// Closing the channel of the source ends the track
+				source = nil
+				if err := s.peerConnection.RemoveTrack(s.sender); err != nil {
+					fmt.Println(errors.Wrap(err, "Failed to remove track"))
+				}
+				continue
+			}
+			s.sendSample(sample)
+		case source = <-replaced:
+			s.talkspurt = true
+		case <-removed:
+			return
+		case d := <-dtmfPackets:
+			s.sendDTMF(d)
+		}
+	}
+}
+
+func (s *sampleSender) sendSample(in RTCSample) {
+	r := s.peerConnection
+
+	if !s.updatePacketizer() {
+		return
+	}
+
+	// The remote may have offered to only send, the MediaDescription may be inactive or the transceiver stopped
+	if !s.sender.transceiver.isSending() {
+		s.talkspurt = true
+		return
+	}
+
+	r.stampHeaderExtensions(s.sender.transceiver, s.negotiated.codec, s.packetizer, in)
+	packets := s.packetizer.Packetize(in.Data, in.Samples)
+	if len(packets) != 0 {
+		s.lastTimestamp = packets[len(packets)-1].Timestamp
+	}
+
+	if s.codec.isAudio() {
+		s.markTalkspurt(packets)
+	}
+	for _, p := range packets {
+		s.send(p)
+		if s.fecEncoder == nil {
+			continue
+		}
+
+		fecPackets, err := s.fecEncoder.Push(p)
+		if err != nil {
+			fmt.Println(errors.Wrap(err, "Failed to generate FEC packets"))
+			continue
+		}
+		for _, f := range fecPackets {
+			s.send(f)
+		}
+	}
+}
+
+// updatePacketizer creates the packetizer of the negotiated codec, the codec is only known once the remote description
+// is. A renegotiation can change its payload type or fmtp, or RED and FEC, the packetizer is then rebuilt with the
+// same sequencer so the sequence numbers of the SSRC continue. It returns false if the codec isn't negotiated
+func (s *sampleSender) updatePacketizer() bool {
+	r := s.peerConnection
+	codec := r.getNegotiatedCodec(s.codec)
+	if codec == nil {
+		if !s.notNegotiated {
+			fmt.Printf("%s was not negotiated, samples of the track are dropped \n", s.codec.MimeType)
+			s.notNegotiated = true
+		}
+		s.packetizer = nil
+		return false
+	}
+	s.notNegotiated = false
+
+	negotiated := packetization{codec: codec}
+	negotiated.redPayloadType, _ = r.getREDPayloadType()
+	if codec.Kind() == "video" {
+		negotiated.flexFECPayloadType, negotiated.ulpFECPayloadType, _ = r.getFECPayloadTypes()
+	}
+	if s.packetizer != nil && negotiated.equal(s.negotiated) {
+		return true
+	}
+
+	s.negotiated = negotiated
+	s.packetizer, s.fecEncoder, s.fecREDPayloadType = r.newSamplePacketizer(codec, s.track, s.sequencer)
+	return true
+}
+
+func (p packetization) equal(other packetization) bool {
+	return p.codec.PayloadType == other.codec.PayloadType && p.codec.SDPFmtpLine == other.codec.SDPFmtpLine &&
+		p.redPayloadType == other.redPayloadType && p.flexFECPayloadType == other.flexFECPayloadType &&
+		p.ulpFECPayloadType == other.ulpFECPayloadType
+}
+
+// markTalkspurt sets the marker of audio packets, it is only set on the first packet of a talkspurt instead of at
+// the end of every sample like for video
+// https://tools.ietf.org/html/rfc3551#section-4.1
+func (s *sampleSender) markTalkspurt(packets []*rtp.Packet) {
+	for i, p := range packets {
+		p.Marker = i == 0 && s.talkspurt
+	}
+	s.talkspurt = s.talkspurt && len(packets) == 0
+}
+
+func (s *sampleSender) sendDTMF(d *dtmfPacket) {
+	r := s.peerConnection
+	telephoneEventPayloadType, ok := r.getTelephoneEventPayloadType(s.codec.ClockRate)
+	if !ok || !s.sender.transceiver.isSending() {
+		return
+	}
+
+	p, err := s.packetizeDTMF(telephoneEventPayloadType, d)
+	if err != nil {
+		fmt.Println(errors.Wrap(err, "Failed to marshal telephone event"))
+		return
+	}
+	s.send(p)
+}
+
+// packetizeDTMF returns the RTP packet of a telephone event, an event starts at the timestamp of the audio sent last
+func (s *sampleSender) packetizeDTMF(payloadType uint8, d *dtmfPacket) (*rtp.Packet, error) {
+	if d.marker {
+		s.dtmfTimestamp = s.lastTimestamp
+	}
+
+	payload, err := d.event.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         d.marker,
+			PayloadType:    payloadType,
+			SequenceNumber: s.sequencer.NextSequenceNumber(),
+			Timestamp:      s.dtmfTimestamp + d.offset,
+			SSRC:           s.track.SSRC,
+		},
+		Payload: payload,
+	}, nil
+}
+
+func (s *sampleSender) send(p *rtp.Packet) {
+	// ULPFEC is carried in RED, and so is the media it protects
+	if s.fecREDPayloadType != 0 {
+		p = wrapRED(s.fecREDPayloadType, p)
+	}
+
+	r := s.peerConnection
+	r.portsLock.RLock()
+	defer r.portsLock.RUnlock()
+	for _, port := range r.ports {
+		port.Send(p)
+	}
+}
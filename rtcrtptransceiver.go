@@ -0,0 +1,182 @@
+package webrtc
+
+import (
+	"sync"
+
+	"github.com/pions/webrtc/pkg/sdp"
+)
+
+// RTCRtpTransceiverDirection is the direction an RTCRtpTransceiver wants to send and receive media in
+// https://www.w3.org/TR/webrtc/#dom-rtcrtptransceiverdirection
+type RTCRtpTransceiverDirection int
+
+// List of RTCRtpTransceiverDirections
+const (
+	RTCRtpTransceiverDirectionSendrecv RTCRtpTransceiverDirection = iota + 1
+	RTCRtpTransceiverDirectionSendonly
+	RTCRtpTransceiverDirectionRecvonly
+	RTCRtpTransceiverDirectionInactive
+)
+
+func (d RTCRtpTransceiverDirection) String() string {
+	switch d {
+	case RTCRtpTransceiverDirectionSendrecv:
+		return "sendrecv"
+	case RTCRtpTransceiverDirectionSendonly:
+		return "sendonly"
+	case RTCRtpTransceiverDirectionRecvonly:
+		return "recvonly"
+	case RTCRtpTransceiverDirectionInactive:
+		return "inactive"
+	default:
+		return "Unknown"
+	}
+}
+
+func (d RTCRtpTransceiverDirection) sends() bool {
+	return d == RTCRtpTransceiverDirectionSendrecv || d == RTCRtpTransceiverDirectionSendonly
+}
+
+func (d RTCRtpTransceiverDirection) receives() bool {
+	return d == RTCRtpTransceiverDirectionSendrecv || d == RTCRtpTransceiverDirectionRecvonly
+}
+
+// RTCRtpTransceiver is the pair of an RTCRtpSender and an RTCRtpReceiver, sending and receiving one kind of media.
+// Every transceiver is negotiated in a MediaDescription of its own, so it sends at most one track
+// https://www.w3.org/TR/webrtc/#rtcrtptransceiver-interface
+type RTCRtpTransceiver struct {
+	kind     string
+	sender   *RTCRtpSender
+	receiver *RTCRtpReceiver
+
+	lock      sync.RWMutex
+	mid       string
+	direction RTCRtpTransceiverDirection
+	stopped   bool
+
+	// mLineIndex is the index of the MediaDescription the transceiver was negotiated in, -1 until it was
+	mLineIndex int
+
+	// negotiatedDirection is the direction of the answered MediaDescription, the goroutines sending and receiving
+	// media read it instead of the LocalDescription
+	negotiatedDirection sdp.Direction
+}
+
+func newRTCRtpTransceiver(peerConnection *RTCPeerConnection, kind string, direction RTCRtpTransceiverDirection) *RTCRtpTransceiver {
+	t := &RTCRtpTransceiver{kind: kind, direction: direction, mLineIndex: -1, negotiatedDirection: sdp.DirectionInactive}
+	t.sender = &RTCRtpSender{peerConnection: peerConnection, transceiver: t}
+	t.receiver = &RTCRtpReceiver{peerConnection: peerConnection, transceiver: t, stopped: make(chan struct{})}
+	return t
+}
+
+// Kind returns the kind of media of the transceiver, like "audio" or "video"
+func (t *RTCRtpTransceiver) Kind() string {
+	return t.kind
+}
+
+// Mid returns the mid of the MediaDescription the transceiver was negotiated in, it is empty until the answer
+// was created or if the offer had no mid for it
+func (t *RTCRtpTransceiver) Mid() string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.mid
+}
+
+// Direction returns the direction the transceiver was created with, or the one AddTrack changed it to
+func (t *RTCRtpTransceiver) Direction() RTCRtpTransceiverDirection {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.direction
+}
+
+// Sender returns the RTCRtpSender of the transceiver, it sends the track added with AddTrack
+func (t *RTCRtpTransceiver) Sender() *RTCRtpSender {
+	return t.sender
+}
+
+// Receiver returns the RTCRtpReceiver of the transceiver
+func (t *RTCRtpTransceiver) Receiver() *RTCRtpReceiver {
+	return t.receiver
+}
+
+// Stop permanently stops the transceiver. Samples of its track are dropped, and the channels given to Ontrack for
+// the media it received are closed
+func (t *RTCRtpTransceiver) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.stopped {
+		return
+	}
+
+	t.stopped = true
+	close(t.receiver.stopped)
+}
+
+// Stopped returns if Stop was called
+func (t *RTCRtpTransceiver) Stopped() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.stopped
+}
+
+func (t *RTCRtpTransceiver) sends() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return !t.stopped && t.direction.sends() && t.sender.getTrack() != nil
+}
+
+func (t *RTCRtpTransceiver) receives() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return !t.stopped && t.direction.receives()
+}
+
+func (t *RTCRtpTransceiver) getMLineIndex() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.mLineIndex
+}
+
+// negotiate records the direction of the answered MediaDescription, it is inactive if the transceiver was not
+// negotiated in the answer
+func (t *RTCRtpTransceiver) negotiate(direction sdp.Direction) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.negotiatedDirection = direction
+}
+
+// isSending returns if the answer sends the track of the transceiver
+func (t *RTCRtpTransceiver) isSending() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return !t.stopped && (t.negotiatedDirection == sdp.DirectionSendRecv || t.negotiatedDirection == sdp.DirectionSendOnly)
+}
+
+// isReceiving returns if the answer receives media in the MediaDescription of the transceiver, it only receives
+// what the remote offered to send
+func (t *RTCRtpTransceiver) isReceiving() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return !t.stopped && (t.negotiatedDirection == sdp.DirectionSendRecv || t.negotiatedDirection == sdp.DirectionRecvOnly)
+}
+
+// associate records the MediaDescription the transceiver is negotiated in
+func (t *RTCRtpTransceiver) associate(mLineIndex int, mid string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.mLineIndex = mLineIndex
+	t.mid = mid
+}
+
+// isAssociated returns if the transceiver was negotiated in the offered MediaDescription with mid at mLineIndex.
+// MediaDescriptions are identified by their mid, or by their index in offers without mids as they keep it in
+// later offers
+// https://tools.ietf.org/html/rfc3264#section-8
+func (t *RTCRtpTransceiver) isAssociated(mLineIndex int, mid string) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if mid != "" {
+		return t.mid == mid
+	}
+	return t.mid == "" && t.mLineIndex == mLineIndex
+}
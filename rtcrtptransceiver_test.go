@@ -0,0 +1,118 @@
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/sdp"
+)
+
+func TestRTCRtpTransceiver(t *testing.T) {
+	r, err := New(&RTCConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddTrack(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8), "video", "stream"); err != nil {
+		t.Fatal(err)
+	}
+
+	protos := []string{"UDP", "TLS", "RTP", "SAVPF"}
+	r.remoteDescription = &sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{MediaName: sdp.MediaName{Media: "audio", Port: 9, Protos: protos, Formats: []string{"109"}}, Attributes: []sdp.Attribute{
+				{Key: "mid", Value: "0"},
+				{Key: "rtpmap", Value: "109 opus/48000/2"},
+			}},
+			{MediaName: sdp.MediaName{Media: "video", Port: 9, Protos: protos, Formats: []string{"120"}}, Attributes: []sdp.Attribute{
+				{Key: "mid", Value: "1"},
+				{Key: "rtpmap", Value: "120 VP8/90000"},
+			}},
+		},
+	}
+	media, _ := r.negotiateMedia()
+	r.LocalDescription = sdp.BaseSessionDescription(&sdp.SessionBuilder{Media: media})
+
+	// The subtests run in order, each one building on the state left by the previous one
+	fixture := &transceiverFixture{peerConnection: r}
+	for _, test := range []struct {
+		name string
+		test func(t *testing.T, f *transceiverFixture)
+	}{
+		{"Negotiation", testTransceiverNegotiation},
+		{"SenderParameters", testTransceiverSenderParameters},
+		{"Receive", testTransceiverReceive},
+		{"Stop", testTransceiverStop},
+	} {
+		test := test
+		if !t.Run(test.name, func(t *testing.T) { test.test(t, fixture) }) {
+			break
+		}
+	}
+}
+
+type transceiverFixture struct {
+	peerConnection *RTCPeerConnection
+	video, audio   *RTCRtpTransceiver
+	received       <-chan *rtp.Packet
+}
+
+func testTransceiverNegotiation(t *testing.T, f *transceiverFixture) {
+	// The offered audio is received by a transceiver the answer added
+	r := f.peerConnection
+	transceivers := r.GetTransceivers()
+	if len(transceivers) != 2 || len(r.GetSenders()) != 2 || len(r.GetReceivers()) != 2 {
+		t.Fatalf("expected a transceiver for the video track and one for the offered audio, got %d", len(transceivers))
+	}
+	f.video, f.audio = transceivers[0], transceivers[1]
+	if f.video.Kind() != "video" || f.video.Mid() != "1" || f.video.Direction() != RTCRtpTransceiverDirectionSendrecv {
+		t.Errorf("expected the video track to be sent by a sendrecv transceiver with mid 1")
+	}
+	if f.audio.Kind() != "audio" || f.audio.Mid() != "0" || f.audio.Direction() != RTCRtpTransceiverDirectionRecvonly {
+		t.Errorf("expected the offered audio to be received by a recvonly transceiver with mid 0")
+	}
+}
+
+func testTransceiverSenderParameters(t *testing.T, f *transceiverFixture) {
+	parameters := f.video.Sender().GetParameters()
+	if len(parameters.Codecs) != 1 || parameters.Codecs[0].PayloadType != 120 || len(parameters.Encodings) != 1 || parameters.Encodings[0].SSRC == 0 {
+		t.Errorf("expected the sender to be sent with the offered VP8 payload type and an SSRC: %+v", parameters)
+	}
+	if parameters := f.audio.Sender().GetParameters(); len(parameters.Encodings) != 0 {
+		t.Errorf("expected a sender without a track to have no encodings")
+	}
+}
+
+func testTransceiverReceive(t *testing.T, f *transceiverFixture) {
+	tracks := make(chan (<-chan *rtp.Packet), 1)
+	f.peerConnection.Ontrack = func(track *RTCTrack, buffers <-chan *rtp.Packet) {
+		tracks <- buffers
+	}
+	buffers := f.peerConnection.generateChannel(5000, 109)
+	if buffers == nil {
+		t.Fatalf("expected audio to be received")
+	}
+	buffers <- &rtp.Packet{Header: rtp.Header{SSRC: 5000, PayloadType: 109}}
+	f.received = <-tracks
+	<-f.received
+
+	if parameters := f.audio.Receiver().GetParameters(); len(parameters.Encodings) != 1 || parameters.Encodings[0].SSRC != 5000 || len(parameters.Codecs) != 1 {
+		t.Errorf("expected the receiver to have the received SSRC and the negotiated codec: %+v", parameters)
+	}
+}
+
+func testTransceiverStop(t *testing.T, f *transceiverFixture) {
+	// Stopping closes the channel given to Ontrack
+	f.audio.Stop()
+	select {
+	case _, ok := <-f.received:
+		if ok {
+			t.Errorf("expected no packets after Stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the channel given to Ontrack to be closed by Stop")
+	}
+	if !f.audio.Stopped() || len(f.peerConnection.GetReceivers()) != 1 || f.peerConnection.generateChannel(5001, 109) != nil {
+		t.Errorf("expected a stopped transceiver to no longer receive")
+	}
+}
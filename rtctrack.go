@@ -0,0 +1,44 @@
+package webrtc
+
+// RTCTrack is a track of the remote peer, it is given to Ontrack with the channel its RTP packets are delivered on
+// https://www.w3.org/TR/webrtc/#dom-mediastreamtrack
+type RTCTrack struct {
+	id       string
+	streamID string
+	ssrc     uint32
+	codec    *RTCRtpCodec
+	receiver *RTCRtpReceiver
+}
+
+// ID returns the id the remote announced for the track in its msid, or the label of its source. It is empty if
+// the remote announced neither
+// https://tools.ietf.org/html/draft-ietf-mmusic-msid-16#section-2
+func (t *RTCTrack) ID() string {
+	return t.id
+}
+
+// StreamID returns the id of the MediaStream the remote added the track to, it is empty if it wasn't announced
+func (t *RTCTrack) StreamID() string {
+	return t.streamID
+}
+
+// SSRC returns the SSRC the track is received with
+func (t *RTCTrack) SSRC() uint32 {
+	return t.ssrc
+}
+
+// Codec returns the negotiated codec of the track, with the payload type and fmtp of the remote, like the
+// packetization-mode of H264
+func (t *RTCTrack) Codec() *RTCRtpCodec {
+	return t.codec
+}
+
+// Kind returns the kind of media of the track, like "audio" or "video"
+func (t *RTCTrack) Kind() string {
+	return t.codec.Kind()
+}
+
+// Receiver returns the RTCRtpReceiver the track is received by
+func (t *RTCTrack) Receiver() *RTCRtpReceiver {
+	return t.receiver
+}